@@ -0,0 +1,27 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// APIKeyPrefix prefixes API keys, telling them apart from JWT tokens in Authorization header
+const APIKeyPrefix = "gsk_"
+
+// APIKey represents user's long-lived personal access token used by machine clients
+type APIKey struct {
+	Base
+	UserID int `json:"-"`
+
+	Name string     `json:"name"`
+	Hint string     `json:"hint"`
+	Hash string     `json:"-"`
+	Role AccessRole `json:"role"`
+
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired checks whether the API key has expired. Keys with zero expiry time never expire.
+func (k *APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
@@ -0,0 +1,38 @@
+package gorsk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+)
+
+func TestAPIKeyExpired(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name string
+		key  gorsk.APIKey
+		want bool
+	}{
+		{
+			name: "No expiry",
+			key:  gorsk.APIKey{},
+		},
+		{
+			name: "Not expired",
+			key:  gorsk.APIKey{ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name: "Expired",
+			key:  gorsk.APIKey{ExpiresAt: now.Add(-time.Hour)},
+			want: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.Expired(now); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
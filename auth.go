@@ -1,27 +1,59 @@
 package gorsk
 
 import (
+	"time"
+
 	"github.com/labstack/echo"
 )
 
-// AuthToken holds authentication token details with refresh token
+// AuthToken holds authentication token details with refresh token.
+// When two-factor authentication is required, it holds only the MFA challenge token.
+// When user's password has to be changed, it holds only the password change challenge token.
 type AuthToken struct {
-	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token"`
+	Token         string   `json:"token,omitempty"`
+	RefreshToken  string   `json:"refresh_token,omitempty"`
+	MFAToken      string   `json:"mfa_token,omitempty"`
+	PasswordToken string   `json:"password_token,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// MFAEnrollment holds two-factor authentication secret to be added to an authenticator app
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// JWKS represents JSON Web Key Set holding public keys used to verify tokens
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK represents public JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
 }
 
-// RefreshToken holds authentication token details
-type RefreshToken struct {
-	Token string `json:"token"`
+// RevokedToken represents access token revoked before its expiry
+type RevokedToken struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // RBACService represents role-based access control service interface
 type RBACService interface {
 	User(echo.Context) AuthUser
-	EnforceRole(echo.Context, AccessRole) error
-	EnforceUser(echo.Context, int) error
+	Enforce(echo.Context, Permission, Resource) error
+	Scope(echo.Context, Permission) (Scope, error)
+	EnforceUser(echo.Context, Permission, int) error
 	EnforceCompany(echo.Context, int) error
 	EnforceLocation(echo.Context, int) error
 	AccountCreate(echo.Context, AccessRole, int, int) error
-	IsLowerRole(echo.Context, AccessRole) error
 }
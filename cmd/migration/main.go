@@ -29,17 +29,22 @@ func main() {
 	db := pg.Connect(u)
 	_, err = db.Exec("SELECT 1")
 	checkErr(err)
-	createSchema(db, &gorsk.Company{}, &gorsk.Location{}, &gorsk.Role{}, &gorsk.User{})
+	createSchema(db, &gorsk.Company{}, &gorsk.Location{}, &gorsk.Role{}, &gorsk.User{}, &gorsk.Session{}, &gorsk.RevokedToken{}, &gorsk.APIKey{}, &gorsk.PasswordReset{}, &gorsk.EmailVerification{}, &gorsk.Invitation{}, &gorsk.LoginLink{}, &gorsk.LoginEvent{}, &gorsk.RolePermission{})
 
 	for _, v := range queries[0 : len(queries)-1] {
 		_, err := db.Exec(v)
 		checkErr(err)
 	}
 
-	sec := secure.New(1, nil)
+	perms := gorsk.DefaultPermissions
+	checkErr(db.Insert(&perms))
 
-	userInsert := `INSERT INTO public.users (id, created_at, updated_at, first_name, last_name, username, password, email, active, role_id, company_id, location_id) VALUES (1, now(),now(),'Admin', 'Admin', 'admin', '%s', 'johndoe@mail.com', true, 100, 1, 1);`
-	_, err = db.Exec(fmt.Sprintf(userInsert, sec.Hash("admin")))
+	sec := secure.New(1)
+
+	userInsert := `INSERT INTO public.users (id, created_at, updated_at, first_name, last_name, username, password, email, email_verified_at, active, role_id, company_id, location_id) VALUES (1, now(),now(),'Admin', 'Admin', 'admin', '%s', 'johndoe@mail.com', now(), true, 100, 1, 1);`
+	hash, err := sec.Hash("admin")
+	checkErr(err)
+	_, err = db.Exec(fmt.Sprintf(userInsert, hash))
 	checkErr(err)
 }
 
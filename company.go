@@ -3,8 +3,14 @@ package gorsk
 // Company represents company model
 type Company struct {
 	Base
-	Name      string     `json:"name"`
-	Active    bool       `json:"active"`
-	Locations []Location `json:"locations,omitempty"`
-	Owner     User       `json:"owner"`
+	Name       string     `json:"name"`
+	Active     bool       `json:"active"`
+	RequireMFA bool       `json:"require_mfa"`
+	MagicLink  bool       `json:"magic_link"`
+	Locations  []Location `json:"locations,omitempty"`
+	Owner      User       `json:"owner"`
+
+	// PasswordHistory and PasswordMaxAgeDays override the default password policy, unless nil
+	PasswordHistory    *int `json:"password_history"`
+	PasswordMaxAgeDays *int `json:"password_max_age_days"`
 }
@@ -2,6 +2,7 @@ package gorsk
 
 import (
 	"errors"
+	"net/http"
 
 	"github.com/labstack/echo"
 )
@@ -15,4 +16,19 @@ var (
 
 	// ErrUnauthorized (401) is returned when user is not authorized
 	ErrUnauthorized = echo.ErrUnauthorized
+
+	// ErrImpersonation (403) is returned for actions not allowed while impersonating a user
+	ErrImpersonation = echo.NewHTTPError(http.StatusForbidden, "Not allowed while impersonating a user")
+
+	// ErrAccountExists (409) is returned when creating a user with taken username or email
+	ErrAccountExists = echo.NewHTTPError(http.StatusConflict, "Username or email already exists.")
+
+	// ErrInsecurePassword (400) is returned for passwords not strong enough
+	ErrInsecurePassword = echo.NewHTTPError(http.StatusBadRequest, "insecure password")
+
+	// ErrBreachedPassword (400) is returned for passwords known from data breaches
+	ErrBreachedPassword = echo.NewHTTPError(http.StatusBadRequest, "password has appeared in a data breach and can not be used, please choose a different one")
+
+	// ErrUnverifiedEmail (403) is returned to users who have to verify their email address first
+	ErrUnverifiedEmail = echo.NewHTTPError(http.StatusForbidden, "Email address is not verified")
 )
@@ -0,0 +1,24 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// Invitation represents invitation to create a user account with given email, role, company and location.
+// Only the hash of invitation's token is stored.
+type Invitation struct {
+	Base
+	Email      string     `json:"email"`
+	RoleID     AccessRole `json:"role_id"`
+	CompanyID  int        `json:"company_id"`
+	LocationID int        `json:"location_id"`
+	InvitedBy  int        `json:"invited_by"`
+
+	Hash      string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired checks whether the invitation has expired
+func (i *Invitation) Expired(now time.Time) bool {
+	return now.After(i.ExpiresAt)
+}
@@ -0,0 +1,36 @@
+package gorsk
+
+// Login failure reasons
+const (
+	LoginInvalidPassword = "invalid_password"
+	LoginInvalidMFACode  = "invalid_mfa_code"
+	LoginLockedOut       = "locked_out"
+	LoginInactive        = "inactive"
+	LoginUnverifiedEmail = "unverified_email"
+)
+
+// LoginEvent represents single login attempt of a user, either successful or failed
+type LoginEvent struct {
+	Base
+	UserID int `json:"-"`
+
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+
+	Success bool   `json:"success"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// LoginOrigin tells whether user has logged in successfully before, and whether from the same device and IP address
+type LoginOrigin struct {
+	Returning   bool
+	KnownDevice bool
+	KnownIP     bool
+}
+
+// New checks whether login comes from a device or IP address the user has not logged in from before.
+// First logins of a user are not considered new, as there is nothing to compare them to.
+func (o LoginOrigin) New() bool {
+	return o.Returning && !(o.KnownDevice && o.KnownIP)
+}
@@ -0,0 +1,18 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// LoginLink represents single-use token emailed to log in without password. Only the token's hash is stored.
+type LoginLink struct {
+	Base
+	UserID    int       `json:"-"`
+	Hash      string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired checks whether the login link has expired
+func (l *LoginLink) Expired(now time.Time) bool {
+	return now.After(l.ExpiresAt)
+}
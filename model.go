@@ -13,10 +13,12 @@ type Base struct {
 	DeletedAt time.Time `json:"deleted_at,omitempty" pg:",soft_delete"`
 }
 
-// ListQuery holds company/location data used for list db queries
+// ListQuery holds company/location data used for list db queries,
+// and roles of users listed resources are limited to, if any
 type ListQuery struct {
 	Query string
 	ID    int
+	Roles []AccessRole
 }
 
 // BeforeInsert hooks into insert operations, setting createdAt and updatedAt to current time
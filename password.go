@@ -0,0 +1,72 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// PasswordReset represents one-time token allowing user to reset forgotten password.
+// Only the token's hash is stored.
+type PasswordReset struct {
+	Base
+	UserID    int       `json:"-"`
+	Hash      string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired checks whether the reset token has expired
+func (r *PasswordReset) Expired(now time.Time) bool {
+	return now.After(r.ExpiresAt)
+}
+
+// PasswordPolicy represents password lifecycle rules
+type PasswordPolicy struct {
+	// History is the number of most recent passwords, including the current one, that cannot be reused
+	History int
+	// MaxAge is how long a password can be used before it has to be changed. Zero means forever.
+	MaxAge time.Duration
+}
+
+// ForCompany returns the policy with company's overrides applied
+func (p PasswordPolicy) ForCompany(c Company) PasswordPolicy {
+	if c.PasswordHistory != nil {
+		p.History = *c.PasswordHistory
+	}
+	if c.PasswordMaxAgeDays != nil {
+		p.MaxAge = time.Duration(*c.PasswordMaxAgeDays) * 24 * time.Hour
+	}
+	return p
+}
+
+// Expired checks whether the user has to change the password before logging in,
+// either because it is older than MaxAge or because the user was told to change it.
+// Passwords never changed are as old as the user.
+func (p PasswordPolicy) Expired(u User, now time.Time) bool {
+	if u.MustChangePassword {
+		return true
+	}
+	if p.MaxAge <= 0 {
+		return false
+	}
+	changed := u.LastPasswordChange
+	if changed.IsZero() {
+		changed = u.CreatedAt
+	}
+	return now.After(changed.Add(p.MaxAge))
+}
+
+// Reused checks whether the password matches one of user's passwords covered by History
+func (p PasswordPolicy) Reused(u User, pass string, matches func(hash, pass string) bool) bool {
+	if p.History <= 0 {
+		return false
+	}
+	hashes := append([]string{u.Password}, u.PasswordHistory...)
+	if len(hashes) > p.History {
+		hashes = hashes[:p.History]
+	}
+	for _, hash := range hashes {
+		if hash != "" && matches(hash, pass) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package gorsk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+)
+
+func TestPasswordResetExpired(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name  string
+		reset gorsk.PasswordReset
+		want  bool
+	}{
+		{
+			name:  "Not expired",
+			reset: gorsk.PasswordReset{ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:  "Expired",
+			reset: gorsk.PasswordReset{ExpiresAt: now.Add(-time.Hour)},
+			want:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reset.Expired(now); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyForCompany(t *testing.T) {
+	history, maxAge := 0, 30
+	policy := gorsk.PasswordPolicy{History: 5, MaxAge: time.Hour}
+	cases := []struct {
+		name    string
+		company gorsk.Company
+		want    gorsk.PasswordPolicy
+	}{
+		{
+			name: "No overrides",
+			want: policy,
+		},
+		{
+			name:    "Overrides",
+			company: gorsk.Company{PasswordHistory: &history, PasswordMaxAgeDays: &maxAge},
+			want:    gorsk.PasswordPolicy{MaxAge: 30 * 24 * time.Hour},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.ForCompany(tt.company); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyExpired(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name   string
+		policy gorsk.PasswordPolicy
+		user   gorsk.User
+		want   bool
+	}{
+		{
+			name: "No max age",
+			user: gorsk.User{LastPasswordChange: now.Add(-1000 * time.Hour)},
+		},
+		{
+			name: "Must change password",
+			user: gorsk.User{LastPasswordChange: now, MustChangePassword: true},
+			want: true,
+		},
+		{
+			name:   "Recently changed",
+			policy: gorsk.PasswordPolicy{MaxAge: 24 * time.Hour},
+			user:   gorsk.User{LastPasswordChange: now.Add(-time.Hour)},
+		},
+		{
+			name:   "Expired",
+			policy: gorsk.PasswordPolicy{MaxAge: 24 * time.Hour},
+			user:   gorsk.User{LastPasswordChange: now.Add(-25 * time.Hour)},
+			want:   true,
+		},
+		{
+			name:   "Never changed",
+			policy: gorsk.PasswordPolicy{MaxAge: 24 * time.Hour},
+			user:   gorsk.User{Base: gorsk.Base{CreatedAt: now.Add(-25 * time.Hour)}},
+			want:   true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Expired(tt.user, now); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyReused(t *testing.T) {
+	user := gorsk.User{Password: "c", PasswordHistory: []string{"b", "a"}}
+	matches := func(hash, pass string) bool { return hash == pass }
+	cases := []struct {
+		name    string
+		history int
+		pass    string
+		want    bool
+	}{
+		{
+			name: "No history",
+			pass: "c",
+		},
+		{
+			name:    "Current password",
+			history: 1,
+			pass:    "c",
+			want:    true,
+		},
+		{
+			name:    "Older than history",
+			history: 2,
+			pass:    "a",
+		},
+		{
+			name:    "In history",
+			history: 3,
+			pass:    "a",
+			want:    true,
+		},
+		{
+			name:    "New password",
+			history: 3,
+			pass:    "d",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := gorsk.PasswordPolicy{History: tt.history}
+			if got := policy.Reused(user, tt.pass, matches); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package gorsk
+
+// Permission is a named action that can be granted to roles
+type Permission string
+
+const (
+	// UsersRead allows viewing users
+	UsersRead Permission = "users:read"
+
+	// UsersCreate allows creating users and inviting them
+	UsersCreate Permission = "users:create"
+
+	// UsersUpdate allows updating users' details
+	UsersUpdate Permission = "users:update"
+
+	// UsersDelete allows deleting users
+	UsersDelete Permission = "users:delete"
+
+	// UsersCredentials allows unlocking users, resetting their passwords and requiring password changes
+	UsersCredentials Permission = "users:credentials"
+
+	// UsersImpersonate allows acting as another user
+	UsersImpersonate Permission = "users:impersonate"
+
+	// LoginsRead allows viewing users' login history
+	LoginsRead Permission = "logins:read"
+
+	// CompaniesUpdate allows updating companies
+	CompaniesUpdate Permission = "companies:update"
+
+	// CompaniesSecurity allows changing login and password policies of companies
+	CompaniesSecurity Permission = "companies:security"
+
+	// LocationsUpdate allows updating locations
+	LocationsUpdate Permission = "locations:update"
+)
+
+// Valid checks whether permission is one of known permissions
+func (p Permission) Valid() bool {
+	switch p {
+	case UsersRead, UsersCreate, UsersUpdate, UsersDelete, UsersCredentials, UsersImpersonate,
+		LoginsRead, CompaniesUpdate, CompaniesSecurity, LocationsUpdate:
+		return true
+	}
+	return false
+}
+
+// Scope limits resources a permission applies to
+type Scope string
+
+const (
+	// ScopeAny grants permission on all resources
+	ScopeAny Scope = "any"
+
+	// ScopeCompany grants permission on resources of user's company
+	ScopeCompany Scope = "company"
+
+	// ScopeLocation grants permission on resources of user's location
+	ScopeLocation Scope = "location"
+
+	// ScopeOwn grants permission only on user's own resources
+	ScopeOwn Scope = "own"
+)
+
+// Valid checks whether scope is one of known scopes
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeAny, ScopeCompany, ScopeLocation, ScopeOwn:
+		return true
+	}
+	return false
+}
+
+// RolePermission grants permission within scope to a role. Roles, if any, limit the grant
+// to resources of users with one of the roles.
+type RolePermission struct {
+	tableName struct{} `pg:"role_permissions"`
+
+	RoleID     AccessRole   `json:"role_id" pg:",pk"`
+	Permission Permission   `json:"permission" pg:",pk"`
+	Scope      Scope        `json:"scope" pg:",notnull"`
+	Roles      []AccessRole `json:"roles,omitempty" pg:",array"`
+}
+
+// Covers checks whether the grant applies to the resource, acted on by the given user
+func (rp RolePermission) Covers(u AuthUser, r Resource) bool {
+	if !rp.Scope.Contains(u, r) {
+		return false
+	}
+	return len(rp.Roles) == 0 || r.RoleID == 0 || hasRole(rp.Roles, r.RoleID)
+}
+
+// Includes checks whether the grant allows at least what the other grant of the same permission allows,
+// in at least as broad scope and on at least the same roles of users. Roles are not compared for grants
+// in own scope, as users granted a permission can always act on their own account.
+func (rp RolePermission) Includes(o RolePermission) bool {
+	if o.Scope == "" {
+		return true
+	}
+	if !rp.Scope.Includes(o.Scope) {
+		return false
+	}
+	if len(rp.Roles) == 0 || o.Scope == ScopeOwn {
+		return true
+	}
+	if len(o.Roles) == 0 {
+		return false
+	}
+	for _, role := range o.Roles {
+		if !hasRole(rp.Roles, role) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasRole(roles []AccessRole, role AccessRole) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource identifies what permission is enforced on, by the user, company and location it belongs to.
+// RoleID is the role of the user, and is zero for resources not belonging to a user.
+type Resource struct {
+	UserID     int
+	CompanyID  int
+	LocationID int
+	RoleID     AccessRole
+}
+
+// Contains checks whether resource is within scope of the given user
+func (s Scope) Contains(u AuthUser, r Resource) bool {
+	switch s {
+	case ScopeAny:
+		return true
+	case ScopeCompany:
+		return u.CompanyID == r.CompanyID
+	case ScopeLocation:
+		return u.CompanyID == r.CompanyID && u.LocationID == r.LocationID
+	case ScopeOwn:
+		return u.ID == r.UserID
+	}
+	return false
+}
+
+// scopeBreadth orders scopes from the narrowest to the broadest
+var scopeBreadth = map[Scope]int{ScopeOwn: 1, ScopeLocation: 2, ScopeCompany: 3, ScopeAny: 4}
+
+// Includes checks whether the scope is at least as broad as the other scope
+func (s Scope) Includes(o Scope) bool {
+	return scopeBreadth[s] >= scopeBreadth[o]
+}
+
+// Roles of users managed by built-in roles, each acting only on users of lower roles
+var (
+	belowSuperAdmin    = []AccessRole{AdminRole, CompanyAdminRole, LocationAdminRole, UserRole}
+	belowAdmin         = []AccessRole{CompanyAdminRole, LocationAdminRole, UserRole}
+	belowCompanyAdmin  = []AccessRole{LocationAdminRole, UserRole}
+	belowLocationAdmin = []AccessRole{UserRole}
+)
+
+// DefaultPermissions are permissions of built-in roles
+var DefaultPermissions = []RolePermission{
+	{RoleID: SuperAdminRole, Permission: UsersRead, Scope: ScopeAny},
+	{RoleID: SuperAdminRole, Permission: UsersCreate, Scope: ScopeAny, Roles: belowSuperAdmin},
+	{RoleID: SuperAdminRole, Permission: UsersUpdate, Scope: ScopeAny},
+	{RoleID: SuperAdminRole, Permission: UsersDelete, Scope: ScopeAny, Roles: belowSuperAdmin},
+	{RoleID: SuperAdminRole, Permission: UsersCredentials, Scope: ScopeAny, Roles: belowSuperAdmin},
+	{RoleID: SuperAdminRole, Permission: UsersImpersonate, Scope: ScopeAny, Roles: belowSuperAdmin},
+	{RoleID: SuperAdminRole, Permission: LoginsRead, Scope: ScopeAny, Roles: belowSuperAdmin},
+	{RoleID: SuperAdminRole, Permission: CompaniesUpdate, Scope: ScopeAny},
+	{RoleID: SuperAdminRole, Permission: CompaniesSecurity, Scope: ScopeAny},
+	{RoleID: SuperAdminRole, Permission: LocationsUpdate, Scope: ScopeAny},
+
+	{RoleID: AdminRole, Permission: UsersRead, Scope: ScopeAny},
+	{RoleID: AdminRole, Permission: UsersCreate, Scope: ScopeAny, Roles: belowAdmin},
+	{RoleID: AdminRole, Permission: UsersUpdate, Scope: ScopeAny},
+	{RoleID: AdminRole, Permission: UsersDelete, Scope: ScopeAny, Roles: belowAdmin},
+	{RoleID: AdminRole, Permission: UsersCredentials, Scope: ScopeAny, Roles: belowAdmin},
+	{RoleID: AdminRole, Permission: LoginsRead, Scope: ScopeAny, Roles: belowAdmin},
+	{RoleID: AdminRole, Permission: CompaniesUpdate, Scope: ScopeAny},
+	{RoleID: AdminRole, Permission: CompaniesSecurity, Scope: ScopeCompany},
+	{RoleID: AdminRole, Permission: LocationsUpdate, Scope: ScopeAny},
+
+	{RoleID: CompanyAdminRole, Permission: UsersRead, Scope: ScopeCompany, Roles: belowCompanyAdmin},
+	{RoleID: CompanyAdminRole, Permission: UsersCreate, Scope: ScopeCompany, Roles: belowCompanyAdmin},
+	{RoleID: CompanyAdminRole, Permission: UsersUpdate, Scope: ScopeCompany, Roles: belowCompanyAdmin},
+	{RoleID: CompanyAdminRole, Permission: UsersDelete, Scope: ScopeCompany, Roles: belowCompanyAdmin},
+	{RoleID: CompanyAdminRole, Permission: UsersCredentials, Scope: ScopeCompany, Roles: belowCompanyAdmin},
+	{RoleID: CompanyAdminRole, Permission: LoginsRead, Scope: ScopeCompany, Roles: belowCompanyAdmin},
+	{RoleID: CompanyAdminRole, Permission: CompaniesUpdate, Scope: ScopeCompany},
+	{RoleID: CompanyAdminRole, Permission: LocationsUpdate, Scope: ScopeCompany},
+
+	{RoleID: LocationAdminRole, Permission: UsersRead, Scope: ScopeLocation, Roles: belowLocationAdmin},
+	{RoleID: LocationAdminRole, Permission: UsersCreate, Scope: ScopeLocation, Roles: belowLocationAdmin},
+	{RoleID: LocationAdminRole, Permission: UsersUpdate, Scope: ScopeLocation, Roles: belowLocationAdmin},
+	{RoleID: LocationAdminRole, Permission: UsersDelete, Scope: ScopeLocation, Roles: belowLocationAdmin},
+	{RoleID: LocationAdminRole, Permission: UsersCredentials, Scope: ScopeLocation, Roles: belowLocationAdmin},
+	{RoleID: LocationAdminRole, Permission: LoginsRead, Scope: ScopeLocation, Roles: belowLocationAdmin},
+	{RoleID: LocationAdminRole, Permission: LocationsUpdate, Scope: ScopeLocation},
+
+	{RoleID: UserRole, Permission: UsersRead, Scope: ScopeOwn},
+	{RoleID: UserRole, Permission: UsersUpdate, Scope: ScopeOwn},
+}
@@ -32,24 +32,63 @@
 package api
 
 import (
-	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ribice/gorsk"
 
 	"github.com/ribice/gorsk/pkg/utl/zlog"
 
+	"github.com/ribice/gorsk/pkg/api/apikey"
+	kl "github.com/ribice/gorsk/pkg/api/apikey/logging"
+	kt "github.com/ribice/gorsk/pkg/api/apikey/transport"
 	"github.com/ribice/gorsk/pkg/api/auth"
 	al "github.com/ribice/gorsk/pkg/api/auth/logging"
 	at "github.com/ribice/gorsk/pkg/api/auth/transport"
+	"github.com/ribice/gorsk/pkg/api/invitation"
+	il "github.com/ribice/gorsk/pkg/api/invitation/logging"
+	it "github.com/ribice/gorsk/pkg/api/invitation/transport"
+	"github.com/ribice/gorsk/pkg/api/loginhistory"
+	lhl "github.com/ribice/gorsk/pkg/api/loginhistory/logging"
+	lht "github.com/ribice/gorsk/pkg/api/loginhistory/transport"
+	"github.com/ribice/gorsk/pkg/api/magiclink"
+	mll "github.com/ribice/gorsk/pkg/api/magiclink/logging"
+	mlt "github.com/ribice/gorsk/pkg/api/magiclink/transport"
+	"github.com/ribice/gorsk/pkg/api/mfa"
+	ml "github.com/ribice/gorsk/pkg/api/mfa/logging"
+	mt "github.com/ribice/gorsk/pkg/api/mfa/transport"
 	"github.com/ribice/gorsk/pkg/api/password"
 	pl "github.com/ribice/gorsk/pkg/api/password/logging"
 	pt "github.com/ribice/gorsk/pkg/api/password/transport"
+	"github.com/ribice/gorsk/pkg/api/session"
+	sl "github.com/ribice/gorsk/pkg/api/session/logging"
+	st "github.com/ribice/gorsk/pkg/api/session/transport"
+	"github.com/ribice/gorsk/pkg/api/signup"
+	sgl "github.com/ribice/gorsk/pkg/api/signup/logging"
+	sgt "github.com/ribice/gorsk/pkg/api/signup/transport"
 	"github.com/ribice/gorsk/pkg/api/user"
 	ul "github.com/ribice/gorsk/pkg/api/user/logging"
 	ut "github.com/ribice/gorsk/pkg/api/user/transport"
+	"github.com/ribice/gorsk/pkg/api/verification"
+	vl "github.com/ribice/gorsk/pkg/api/verification/logging"
+	vt "github.com/ribice/gorsk/pkg/api/verification/transport"
 
 	"github.com/ribice/gorsk/pkg/utl/config"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
+	"github.com/ribice/gorsk/pkg/utl/denylist"
 	"github.com/ribice/gorsk/pkg/utl/jwt"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+	"github.com/ribice/gorsk/pkg/utl/mail"
 	authMw "github.com/ribice/gorsk/pkg/utl/middleware/auth"
+	"github.com/ribice/gorsk/pkg/utl/notify"
+	"github.com/ribice/gorsk/pkg/utl/oidc"
 	"github.com/ribice/gorsk/pkg/utl/postgres"
 	"github.com/ribice/gorsk/pkg/utl/rbac"
 	"github.com/ribice/gorsk/pkg/utl/secure"
@@ -63,27 +102,138 @@ func Start(cfg *config.Configuration) error {
 		return err
 	}
 
-	sec := secure.New(cfg.App.MinPasswordStr, sha1.New())
-	rbac := rbac.Service{}
-	jwt, err := jwt.New(cfg.JWT.SigningAlgorithm, os.Getenv("JWT_SECRET"), cfg.JWT.DurationMinutes, cfg.JWT.MinSecretLength)
+	hasher, err := newHasher(cfg.PasswordHashing)
+	if err != nil {
+		return err
+	}
+
+	breaches, err := newBreachChecker(cfg.BreachedPasswords)
+	if err != nil {
+		return err
+	}
+
+	sec := secure.NewWithHasher(newPasswordPolicy(cfg.App.MinPasswordStr, cfg.PasswordRules), hasher, breaches)
+	perms, err := newPermissionStore(cfg.PolicyFile, db)
+	if err != nil {
+		return err
+	}
+
+	rbac := rbac.New(perms, rbac.NewPG(db), time.Duration(cfg.App.PermissionsCacheTTL)*time.Second)
+	jwt, err := newJWT(cfg.JWT)
+	if err != nil {
+		return err
+	}
+
+	dl, err := newDenylist(cfg.JWT.DenylistStore, db)
+	if err != nil {
+		return err
+	}
+
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.App.EmailVerification {
+	case "", "login", "restrict":
+	default:
+		return fmt.Errorf("invalid email verification policy: %s", cfg.App.EmailVerification)
+	}
+
+	notifier, err := newNotifier(cfg.App.LoginNotifier, mailer)
+	if err != nil {
+		return err
+	}
+
+	signupCfg, err := newSignupConfig(cfg.Signup)
 	if err != nil {
 		return err
 	}
 
 	log := zlog.New()
 
-	e := server.New()
+	cookies, err := newCookieJar(cfg.Cookies, cfg.JWT)
+	if err != nil {
+		return err
+	}
+
+	e := server.New(cfg.Server.AllowOrigins...)
+	if len(cfg.Server.TrustedProxies) > 0 {
+		proxies, err := server.TrustProxies(cfg.Server.TrustedProxies...)
+		if err != nil {
+			return err
+		}
+		e.Use(proxies)
+	}
 	e.Static("/swaggerui", cfg.App.SwaggerUIPath)
 
-	authMiddleware := authMw.Middleware(jwt)
+	keys := apikey.Initialize(db, rbac)
+	ver := verification.Initialize(db, rbac, mailer, verification.Config{
+		Duration: time.Duration(cfg.App.EmailVerificationTTL) * time.Minute,
+		URL:      cfg.App.EmailVerificationURL,
+	})
+	authMiddleware := authMw.Middleware(jwt, dl, keys)
 
-	at.NewHTTP(al.New(auth.Initialize(db, jwt, sec, rbac), log), e, authMiddleware)
+	lockoutPolicy := func(attempts int) lockout.Policy {
+		return lockout.Policy{
+			MaxAttempts: attempts,
+			Delay:       time.Duration(cfg.App.LoginDelay) * time.Second,
+			Lockout:     time.Duration(cfg.App.LockoutDuration) * time.Minute,
+		}
+	}
+
+	passwordPolicy := gorsk.PasswordPolicy{
+		History: cfg.App.PasswordHistory,
+		MaxAge:  time.Duration(cfg.App.PasswordMaxAge) * 24 * time.Hour,
+	}
+
+	ipLockout := lockout.NewMemory(lockoutPolicy(cfg.App.MaxIPLoginAttempts))
+	authSvc := auth.Initialize(db, jwt, sec, rbac, dl, ipLockout, log, auth.Config{
+		ClockSkew:       time.Duration(cfg.JWT.ClockSkew) * time.Second,
+		RefreshDuration: time.Duration(cfg.JWT.RefreshDuration) * time.Minute,
+		MaxRefresh:      time.Duration(cfg.JWT.MaxRefresh) * time.Minute,
+		Lockout:         lockoutPolicy(cfg.App.MaxLoginAttempts),
+		MFAIssuer:       cfg.App.MFAIssuer,
+		Providers:       newProviders(cfg.OIDC),
+		Notifier:        notifier,
+
+		RequireVerifiedEmail: cfg.App.EmailVerification == "login",
+		PasswordPolicy:       passwordPolicy,
+	})
+	at.NewHTTP(al.New(authSvc, log), e, authMiddleware, cookies)
+
+	sgt.NewHTTP(sgl.New(signup.Initialize(db, sec, ver, log, signupCfg), log), e)
+
+	// Users with unverified email can still change it, in case it was mistyped
+	vt.NewHTTP(vl.New(ver, log), e, e.Group("/v1", authMiddleware))
 
 	v1 := e.Group("/v1")
 	v1.Use(authMiddleware)
+	if cfg.App.EmailVerification == "restrict" {
+		v1.Use(authMw.VerifiedEmail())
+	}
 
-	ut.NewHTTP(ul.New(user.Initialize(db, rbac, sec), log), v1)
-	pt.NewHTTP(pl.New(password.Initialize(db, rbac, sec), log), v1)
+	ut.NewHTTP(ul.New(user.Initialize(db, rbac, sec, jwt, ver, log), log), v1)
+	pt.NewHTTP(pl.New(password.Initialize(db, rbac, sec, mailer, password.Config{
+		ResetDuration: time.Duration(cfg.App.PasswordResetTTL) * time.Minute,
+		ResetURL:      cfg.App.PasswordResetURL,
+		Policy:        passwordPolicy,
+	}), log), e, v1)
+	st.NewHTTP(sl.New(session.Initialize(db, rbac), log), v1)
+	lht.NewHTTP(lhl.New(loginhistory.Initialize(db, rbac), log), v1)
+	mt.NewHTTP(ml.New(mfa.Initialize(db, rbac, ipLockout, lockoutPolicy(cfg.App.MaxLoginAttempts), cfg.App.MFAIssuer), log), v1)
+	kt.NewHTTP(kl.New(keys, log), v1)
+	mlt.NewHTTP(mll.New(magiclink.Initialize(db, authSvc, rbac, lockout.NewMemory(lockout.Policy{
+		MaxAttempts: cfg.App.MagicLinkMaxRequests,
+		Lockout:     time.Duration(cfg.App.MagicLinkWindow) * time.Minute,
+	}), mailer, magiclink.Config{
+		Duration: time.Duration(cfg.App.MagicLinkTTL) * time.Minute,
+		URL:      cfg.App.MagicLinkURL,
+	}), log), e, v1, cookies)
+	it.NewHTTP(il.New(invitation.Initialize(db, rbac, sec, mailer, invitation.Config{
+		Duration: time.Duration(cfg.App.InvitationTTL) * time.Hour,
+		URL:      cfg.App.InvitationURL,
+	}), log), e, v1)
 
 	server.Start(e, &server.Config{
 		Port:                cfg.Server.Port,
@@ -94,3 +244,256 @@ func Start(cfg *config.Configuration) error {
 
 	return nil
 }
+
+// denylistStore is implemented by revoked access token storages
+type denylistStore interface {
+	Add(string, time.Time) error
+	Contains(string) (bool, error)
+}
+
+func newDenylist(store string, db *pg.DB) (denylistStore, error) {
+	switch store {
+	case "", "memory":
+		return denylist.NewMemory(), nil
+	case "postgres":
+		return denylist.NewPG(db), nil
+	default:
+		return nil, fmt.Errorf("invalid denylist store: %s", store)
+	}
+}
+
+func newPermissionStore(policyFile string, db *pg.DB) (rbac.Store, error) {
+	if policyFile == "" {
+		return rbac.NewPG(db), nil
+	}
+	policy, err := rbac.LoadPolicy(policyFile)
+	if err != nil {
+		return nil, err
+	}
+	return rbac.NewOverride(rbac.NewPG(db), policy), nil
+}
+
+// mailer is implemented by email delivery drivers
+type mailer interface {
+	Send(mail.Message) error
+}
+
+func newMailer(cfg *config.Mail) (mailer, error) {
+	if cfg == nil || cfg.Driver == "" {
+		return nil, fmt.Errorf("mail driver is not configured, set mail.driver to smtp, file or log")
+	}
+	switch cfg.Driver {
+	case "log":
+		return mail.NewLog(os.Stdout, cfg.From), nil
+	case "file":
+		f, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return mail.NewLog(f, cfg.From), nil
+	case "smtp":
+		return mail.NewSMTP(cfg.SMTPAddr, cfg.Username, os.Getenv("SMTP_PASSWORD"), cfg.From), nil
+	default:
+		return nil, fmt.Errorf("invalid mail driver: %s", cfg.Driver)
+	}
+}
+
+func newNotifier(driver string, m mailer) (auth.Notifier, error) {
+	switch driver {
+	case "":
+		return nil, nil
+	case "mail":
+		return notify.NewMail(m), nil
+	default:
+		return nil, fmt.Errorf("invalid login notifier: %s", driver)
+	}
+}
+
+// newHasher creates password hasher, using default parameters for those not configured
+func newHasher(cfg *config.PasswordHashing) (secure.Hasher, error) {
+	if cfg == nil {
+		return secure.DefaultArgon2id(), nil
+	}
+	switch cfg.Algorithm {
+	case "", "argon2id":
+		h := secure.DefaultArgon2id()
+		if cfg.Memory > 0 {
+			h.Memory = cfg.Memory
+		}
+		if cfg.Iterations > 0 {
+			h.Iterations = cfg.Iterations
+		}
+		if cfg.Parallelism > 0 {
+			h.Parallelism = cfg.Parallelism
+		}
+		return h, nil
+	case "bcrypt":
+		cost := cfg.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost: %d", cost)
+		}
+		return secure.Bcrypt{Cost: cost}, nil
+	default:
+		return nil, fmt.Errorf("invalid password hashing algorithm: %s", cfg.Algorithm)
+	}
+}
+
+// newPasswordPolicy creates password policy out of configured rules and minimum zxcvbn score
+func newPasswordPolicy(minScore int, cfg *config.PasswordRules) secure.Policy {
+	if cfg == nil {
+		return secure.DefaultPolicy(minScore)
+	}
+
+	minLength := cfg.MinLength
+	if minLength == 0 {
+		minLength = secure.DefaultMinLength
+	}
+	rules := []secure.Rule{secure.MinLength(minLength), secure.MinScore(minScore)}
+	if cfg.CharacterClasses > 0 {
+		rules = append(rules, secure.CharacterClasses(cfg.CharacterClasses))
+	}
+	if cfg.NotSimilar {
+		rules = append(rules, secure.NotSimilar())
+	}
+	if len(cfg.Dictionary) > 0 {
+		rules = append(rules, secure.Dictionary(cfg.Dictionary...))
+	}
+	return secure.NewPolicy(rules...)
+}
+
+// newBreachChecker opens breached passwords corpus, returning nil if it is not configured
+func newBreachChecker(cfg *config.BreachedPasswords) (secure.BreachChecker, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	switch cfg.Format {
+	case "hibp":
+		return secure.NewHIBPFile(cfg.File)
+	case "index":
+		return secure.NewIndex(cfg.File)
+	default:
+		return nil, fmt.Errorf("invalid breached passwords format: %s", cfg.Format)
+	}
+}
+
+func newCookieJar(cfg *config.Cookies, jwtCfg *config.JWT) (*cookie.Jar, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var sameSite http.SameSite
+	switch cfg.SameSite {
+	case "", "strict":
+		sameSite = http.SameSiteStrictMode
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		if cfg.Insecure {
+			return nil, fmt.Errorf("cookies with same_site none have to be secure")
+		}
+		sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid cookie same site policy: %s", cfg.SameSite)
+	}
+	refresh := jwtCfg.RefreshDuration
+	if refresh == 0 {
+		refresh = jwtCfg.MaxRefresh
+	}
+	return cookie.New(cookie.Config{
+		Domain:          cfg.Domain,
+		SameSite:        sameSite,
+		Insecure:        cfg.Insecure,
+		AccessDuration:  time.Duration(jwtCfg.DurationMinutes) * time.Minute,
+		RefreshDuration: time.Duration(refresh) * time.Minute,
+	}), nil
+}
+
+func newSignupConfig(cfg *config.Signup) (signup.Config, error) {
+	if cfg == nil {
+		return signup.Config{Policy: signup.PolicyDisabled}, nil
+	}
+	switch cfg.Policy {
+	case "", signup.PolicyDisabled, signup.PolicyDomain, signup.PolicyInvite:
+	default:
+		return signup.Config{}, fmt.Errorf("invalid signup policy: %s", cfg.Policy)
+	}
+
+	domains := make(map[string]signup.Membership, len(cfg.Domains))
+	for domain, m := range cfg.Domains {
+		if m == nil {
+			return signup.Config{}, fmt.Errorf("missing company of signup domain: %s", domain)
+		}
+		domains[strings.ToLower(domain)] = signup.Membership{CompanyID: m.CompanyID, LocationID: m.LocationID}
+	}
+
+	codes := make(map[string]signup.Membership, len(cfg.InviteCodes))
+	for code, m := range cfg.InviteCodes {
+		if m == nil {
+			return signup.Config{}, fmt.Errorf("missing company of signup invite code: %s", code)
+		}
+		codes[code] = signup.Membership{CompanyID: m.CompanyID, LocationID: m.LocationID}
+	}
+
+	return signup.Config{
+		Policy:      cfg.Policy,
+		Domains:     domains,
+		InviteCodes: codes,
+	}, nil
+}
+
+// newJWT creates JWT service signing tokens with asymmetric keys when a signing key is configured,
+// falling back to JWT_SECRET otherwise
+func newJWT(cfg *config.JWT) (jwt.Service, error) {
+	claims := jwt.Config{
+		Issuer:    cfg.Issuer,
+		Audience:  cfg.Audience,
+		ClockSkew: time.Duration(cfg.ClockSkew) * time.Second,
+	}
+
+	if cfg.SigningKeyID == "" {
+		return jwt.New(cfg.SigningAlgorithm, os.Getenv("JWT_SECRET"), cfg.DurationMinutes, cfg.MinSecretLength, claims)
+	}
+
+	keys := make([]jwt.Key, len(cfg.Keys))
+	for i, k := range cfg.Keys {
+		file := k.PrivateKeyFile
+		if file == "" {
+			file = k.PublicKeyFile
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return jwt.Service{}, err
+		}
+		algo := k.Algorithm
+		if algo == "" {
+			algo = cfg.SigningAlgorithm
+		}
+		if keys[i], err = jwt.ParseKey(k.ID, algo, data); err != nil {
+			return jwt.Service{}, err
+		}
+	}
+
+	return jwt.NewWithKeys(cfg.SigningKeyID, keys, cfg.DurationMinutes, claims)
+}
+
+func newProviders(cfg map[string]*config.OIDCProvider) map[string]auth.Provider {
+	providers := make(map[string]auth.Provider, len(cfg))
+	for name, p := range cfg {
+		providers[name] = auth.Provider{
+			IdP: oidc.New(oidc.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: os.Getenv("OIDC_" + strings.ToUpper(name) + "_CLIENT_SECRET"),
+				RedirectURL:  p.RedirectURL,
+				Scopes:       p.Scopes,
+			}, &http.Client{Timeout: 10 * time.Second}),
+			Provision:  p.Provision,
+			RoleID:     gorsk.AccessRole(p.RoleID),
+			CompanyID:  p.CompanyID,
+			LocationID: p.LocationID,
+		}
+	}
+	return providers
+}
@@ -0,0 +1,150 @@
+// Package apikey contains services for managing personal access tokens used by machine clients
+package apikey
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+)
+
+// Custom errors
+var (
+	ErrKeyNotFound   = echo.NewHTTPError(http.StatusNotFound, "API key not found")
+	ErrInvalidKey    = echo.NewHTTPError(http.StatusUnauthorized, "API key is invalid or expired")
+	ErrInvalidRole   = echo.NewHTTPError(http.StatusForbidden, "API key role cannot be granted permissions its owner is not granted")
+	ErrInvalidExpiry = echo.NewHTTPError(http.StatusBadRequest, "API key expiry must be in the future")
+)
+
+// List returns API keys of currently logged user
+func (a APIKey) List(c echo.Context) ([]gorsk.APIKey, error) {
+	return a.kdb.List(a.db, a.rbac.User(c).ID)
+}
+
+// Create creates a new API key for currently logged user, returning the key itself.
+// The key is shown only once, as only its hash is stored.
+// Key's role defaults to the owner's role, and can only be granted permissions the owner's role is granted.
+// Keys cannot be created while impersonating, as they would outlive the impersonation.
+func (a APIKey) Create(c echo.Context, req gorsk.APIKey) (gorsk.APIKey, string, error) {
+	au := a.rbac.User(c)
+	if au.ActorID != 0 {
+		return gorsk.APIKey{}, "", gorsk.ErrImpersonation
+	}
+
+	if req.Role == 0 {
+		req.Role = au.Role
+	}
+	ok, err := a.rbac.RoleIncludes(au.Role, req.Role)
+	if err != nil {
+		return gorsk.APIKey{}, "", err
+	}
+	if !ok {
+		return gorsk.APIKey{}, "", ErrInvalidRole
+	}
+
+	if !req.ExpiresAt.IsZero() && !req.ExpiresAt.After(time.Now()) {
+		return gorsk.APIKey{}, "", ErrInvalidExpiry
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return gorsk.APIKey{}, "", err
+	}
+
+	req.UserID = au.ID
+	req.Hash = secure.HashToken(key)
+	req.Hint = key[len(key)-4:]
+
+	k, err := a.kdb.Create(a.db, req)
+	if err != nil {
+		return gorsk.APIKey{}, "", err
+	}
+
+	return k, key, nil
+}
+
+// Revoke revokes one of currently logged user's API keys
+func (a APIKey) Revoke(c echo.Context, id int) error {
+	k, err := a.kdb.View(a.db, id)
+	if err == pg.ErrNoRows {
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if k.UserID != a.rbac.User(c).ID {
+		return ErrKeyNotFound
+	}
+	return a.kdb.Delete(a.db, k)
+}
+
+// Authenticate returns the owner of an API key, acting with key's role.
+// Should the owner's role no longer be granted all permissions of key's role, for example when it was
+// changed after the key was created, the owner's role is used instead.
+func (a APIKey) Authenticate(key string) (gorsk.AuthUser, error) {
+	if !strings.HasPrefix(key, gorsk.APIKeyPrefix) {
+		return gorsk.AuthUser{}, ErrInvalidKey
+	}
+
+	k, err := a.kdb.FindByHash(a.db, secure.HashToken(key))
+	if err == pg.ErrNoRows {
+		return gorsk.AuthUser{}, ErrInvalidKey
+	}
+	if err != nil {
+		return gorsk.AuthUser{}, err
+	}
+
+	now := time.Now()
+	if k.Expired(now) {
+		return gorsk.AuthUser{}, ErrInvalidKey
+	}
+
+	u, err := a.udb.View(a.db, k.UserID)
+	if err == pg.ErrNoRows {
+		return gorsk.AuthUser{}, ErrInvalidKey
+	}
+	if err != nil {
+		return gorsk.AuthUser{}, err
+	}
+	if !u.Active {
+		return gorsk.AuthUser{}, ErrInvalidKey
+	}
+
+	k.LastUsedAt = now
+	if err := a.kdb.Touch(a.db, k); err != nil {
+		return gorsk.AuthUser{}, err
+	}
+
+	role := k.Role
+	ok, err := a.rbac.RoleIncludes(u.RoleID, role)
+	if err != nil {
+		return gorsk.AuthUser{}, err
+	}
+	if !ok {
+		role = u.RoleID
+	}
+
+	return gorsk.AuthUser{
+		ID:         u.ID,
+		CompanyID:  u.CompanyID,
+		LocationID: u.LocationID,
+		Username:   u.Username,
+		Email:      u.Email,
+		Role:       role,
+
+		EmailVerified: u.EmailVerified(),
+	}, nil
+}
+
+func newKey() (string, error) {
+	token, err := secure.NewToken()
+	if err != nil {
+		return "", err
+	}
+	return gorsk.APIKeyPrefix + token, nil
+}
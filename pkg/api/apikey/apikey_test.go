@@ -0,0 +1,373 @@
+package apikey_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/apikey"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/rbac"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roles grants built-in roles their default permissions
+var roles = rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), nil, 0)
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantData []gorsk.APIKey
+		wantErr  bool
+		kdb      *mockdb.APIKey
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on List",
+			wantErr: true,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ListFn: func(orm.DB, int) ([]gorsk.APIKey, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ListFn: func(db orm.DB, userID int) ([]gorsk.APIKey, error) {
+					return []gorsk.APIKey{
+						{Base: gorsk.Base{ID: 1}, UserID: userID, Name: "CI"},
+					}, nil
+				}},
+			wantData: []gorsk.APIKey{
+				{Base: gorsk.Base{ID: 1}, UserID: 1, Name: "CI"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apikey.New(nil, tt.kdb, nil, tt.rbac)
+			resp, err := s.List(nil)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	custom := rbac.New(rbac.NewMemory(append([]gorsk.RolePermission{
+		{RoleID: gorsk.AccessRole(400), Permission: gorsk.CompaniesSecurity, Scope: gorsk.ScopeCompany},
+	}, gorsk.DefaultPermissions...)), nil, 0)
+	rbac := &mock.RBAC{
+		UserFn: func(echo.Context) gorsk.AuthUser {
+			return gorsk.AuthUser{ID: 1, Role: gorsk.CompanyAdminRole}
+		},
+		RoleIncludesFn: roles.RoleIncludes,
+	}
+	cases := []struct {
+		name     string
+		req      gorsk.APIKey
+		wantRole gorsk.AccessRole
+		wantErr  error
+		kdb      *mockdb.APIKey
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on impersonation",
+			req:     gorsk.APIKey{Name: "CI"},
+			wantErr: gorsk.ErrImpersonation,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, Role: gorsk.CompanyAdminRole, ActorID: 2}
+				}},
+		},
+		{
+			name:    "Role exceeds owner's role",
+			req:     gorsk.APIKey{Name: "CI", Role: gorsk.AdminRole},
+			wantErr: apikey.ErrInvalidRole,
+		},
+		{
+			name:    "Role granted permissions owner is not granted",
+			req:     gorsk.APIKey{Name: "CI", Role: gorsk.AccessRole(400)},
+			wantErr: apikey.ErrInvalidRole,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, Role: gorsk.CompanyAdminRole}
+				},
+				RoleIncludesFn: custom.RoleIncludes,
+			},
+		},
+		{
+			name:    "Fail on loading permissions",
+			req:     gorsk.APIKey{Name: "CI"},
+			wantErr: gorsk.ErrGeneric,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, Role: gorsk.CompanyAdminRole}
+				},
+				RoleIncludesFn: func(gorsk.AccessRole, gorsk.AccessRole) (bool, error) {
+					return false, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Expiry in the past",
+			req:     gorsk.APIKey{Name: "CI", ExpiresAt: time.Now().Add(-time.Hour)},
+			wantErr: apikey.ErrInvalidExpiry,
+		},
+		{
+			name:    "Fail on Create",
+			req:     gorsk.APIKey{Name: "CI"},
+			wantErr: gorsk.ErrGeneric,
+			kdb: &mockdb.APIKey{
+				CreateFn: func(orm.DB, gorsk.APIKey) (gorsk.APIKey, error) {
+					return gorsk.APIKey{}, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:     "Success with owner's role",
+			req:      gorsk.APIKey{Name: "CI"},
+			wantRole: gorsk.CompanyAdminRole,
+			kdb: &mockdb.APIKey{
+				CreateFn: func(db orm.DB, key gorsk.APIKey) (gorsk.APIKey, error) {
+					key.ID = 1
+					return key, nil
+				}},
+		},
+		{
+			name:     "Success with lower role",
+			req:      gorsk.APIKey{Name: "CI", Role: gorsk.UserRole, ExpiresAt: time.Now().Add(time.Hour)},
+			wantRole: gorsk.UserRole,
+			kdb: &mockdb.APIKey{
+				CreateFn: func(db orm.DB, key gorsk.APIKey) (gorsk.APIKey, error) {
+					key.ID = 1
+					return key, nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.rbac == nil {
+				tt.rbac = rbac
+			}
+			s := apikey.New(nil, tt.kdb, nil, tt.rbac)
+			k, key, err := s.Create(nil, tt.req)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.True(t, strings.HasPrefix(key, gorsk.APIKeyPrefix))
+			assert.NotEmpty(t, k.Hash)
+			assert.NotEqual(t, key, k.Hash)
+			assert.Equal(t, key[len(key)-4:], k.Hint)
+			assert.Equal(t, 1, k.UserID)
+			assert.Equal(t, tt.wantRole, k.Role)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      int
+		wantErr error
+		kdb     *mockdb.APIKey
+		rbac    *mock.RBAC
+	}{
+		{
+			name:    "API key does not exist",
+			id:      1,
+			wantErr: apikey.ErrKeyNotFound,
+			kdb: &mockdb.APIKey{
+				ViewFn: func(orm.DB, int) (gorsk.APIKey, error) {
+					return gorsk.APIKey{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:    "Fail on View",
+			id:      1,
+			wantErr: gorsk.ErrGeneric,
+			kdb: &mockdb.APIKey{
+				ViewFn: func(orm.DB, int) (gorsk.APIKey, error) {
+					return gorsk.APIKey{}, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:    "API key belongs to another user",
+			id:      1,
+			wantErr: apikey.ErrKeyNotFound,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ViewFn: func(db orm.DB, id int) (gorsk.APIKey, error) {
+					return gorsk.APIKey{Base: gorsk.Base{ID: id}, UserID: 2}, nil
+				}},
+		},
+		{
+			name: "Success",
+			id:   1,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ViewFn: func(db orm.DB, id int) (gorsk.APIKey, error) {
+					return gorsk.APIKey{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, gorsk.APIKey) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apikey.New(nil, tt.kdb, nil, tt.rbac)
+			err := s.Revoke(nil, tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	key := gorsk.APIKeyPrefix + "key"
+	cases := []struct {
+		name     string
+		key      string
+		wantData gorsk.AuthUser
+		wantErr  error
+		kdb      *mockdb.APIKey
+		udb      *mockdb.User
+	}{
+		{
+			name:    "Missing prefix",
+			key:     "key",
+			wantErr: apikey.ErrInvalidKey,
+		},
+		{
+			name:    "API key does not exist",
+			key:     key,
+			wantErr: apikey.ErrInvalidKey,
+			kdb: &mockdb.APIKey{
+				FindByHashFn: func(orm.DB, string) (gorsk.APIKey, error) {
+					return gorsk.APIKey{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:    "API key expired",
+			key:     key,
+			wantErr: apikey.ErrInvalidKey,
+			kdb: &mockdb.APIKey{
+				FindByHashFn: func(orm.DB, string) (gorsk.APIKey, error) {
+					return gorsk.APIKey{UserID: 1, ExpiresAt: time.Now().Add(-time.Hour)}, nil
+				}},
+		},
+		{
+			name:    "Owner is inactive",
+			key:     key,
+			wantErr: apikey.ErrInvalidKey,
+			kdb: &mockdb.APIKey{
+				FindByHashFn: func(orm.DB, string) (gorsk.APIKey, error) {
+					return gorsk.APIKey{UserID: 1}, nil
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}}, nil
+				}},
+		},
+		{
+			name:    "Fail on Touch",
+			key:     key,
+			wantErr: gorsk.ErrGeneric,
+			kdb: &mockdb.APIKey{
+				FindByHashFn: func(orm.DB, string) (gorsk.APIKey, error) {
+					return gorsk.APIKey{UserID: 1}, nil
+				},
+				TouchFn: func(orm.DB, gorsk.APIKey) error {
+					return gorsk.ErrGeneric
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, Active: true}, nil
+				}},
+		},
+		{
+			name: "Success with key's role",
+			key:  key,
+			kdb: &mockdb.APIKey{
+				FindByHashFn: func(orm.DB, string) (gorsk.APIKey, error) {
+					return gorsk.APIKey{UserID: 1, Role: gorsk.UserRole}, nil
+				},
+				TouchFn: func(db orm.DB, k gorsk.APIKey) error {
+					if k.LastUsedAt.IsZero() {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Base:       gorsk.Base{ID: id},
+						Username:   "johndoe",
+						Email:      "johndoe@mail.com",
+						Active:     true,
+						RoleID:     gorsk.AdminRole,
+						CompanyID:  2,
+						LocationID: 3,
+					}, nil
+				}},
+			wantData: gorsk.AuthUser{
+				ID:         1,
+				CompanyID:  2,
+				LocationID: 3,
+				Username:   "johndoe",
+				Email:      "johndoe@mail.com",
+				Role:       gorsk.UserRole,
+			},
+		},
+		{
+			name: "Success with owner's changed role",
+			key:  key,
+			kdb: &mockdb.APIKey{
+				FindByHashFn: func(orm.DB, string) (gorsk.APIKey, error) {
+					return gorsk.APIKey{UserID: 1, Role: gorsk.AdminRole}, nil
+				},
+				TouchFn: func(orm.DB, gorsk.APIKey) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, Active: true, RoleID: gorsk.UserRole}, nil
+				}},
+			wantData: gorsk.AuthUser{ID: 1, Role: gorsk.UserRole},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apikey.New(nil, tt.kdb, tt.udb, &mock.RBAC{RoleIncludesFn: roles.RoleIncludes})
+			au, err := s.Authenticate(tt.key)
+			assert.Equal(t, tt.wantData, au)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	s := apikey.Initialize(nil, nil)
+	assert.NotNil(t, s)
+}
@@ -0,0 +1,48 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// APIKey represents the client for api_key table
+type APIKey struct{}
+
+// Create creates a new API key
+func (a APIKey) Create(db orm.DB, key gorsk.APIKey) (gorsk.APIKey, error) {
+	err := db.Insert(&key)
+	return key, err
+}
+
+// View returns single API key by ID
+func (a APIKey) View(db orm.DB, id int) (gorsk.APIKey, error) {
+	key := gorsk.APIKey{Base: gorsk.Base{ID: id}}
+	err := db.Model(&key).WherePK().Select()
+	return key, err
+}
+
+// List returns user's API keys, most recently created first
+func (a APIKey) List(db orm.DB, userID int) ([]gorsk.APIKey, error) {
+	var keys []gorsk.APIKey
+	err := db.Model(&keys).Where("user_id = ?", userID).Order("created_at desc").Select()
+	return keys, err
+}
+
+// FindByHash returns API key by its hash
+func (a APIKey) FindByHash(db orm.DB, hash string) (gorsk.APIKey, error) {
+	var key gorsk.APIKey
+	err := db.Model(&key).Where("hash = ?", hash).Select()
+	return key, err
+}
+
+// Touch updates API key's last used time
+func (a APIKey) Touch(db orm.DB, key gorsk.APIKey) error {
+	_, err := db.Model(&key).Column("last_used_at").WherePK().Update()
+	return err
+}
+
+// Delete revokes an API key
+func (a APIKey) Delete(db orm.DB, key gorsk.APIKey) error {
+	return db.Delete(&key)
+}
@@ -0,0 +1,89 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/apikey/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindByHash(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		hash     string
+		wantData gorsk.APIKey
+	}{
+		{
+			name:    "API key does not exist",
+			wantErr: true,
+			hash:    "notexists",
+		},
+		{
+			name: "Success",
+			hash: "hash",
+			wantData: gorsk.APIKey{
+				Base:   gorsk.Base{ID: 1},
+				UserID: 1,
+				Name:   "CI",
+				Hint:   "abcd",
+				Hash:   "hash",
+				Role:   gorsk.UserRole,
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.APIKey{})
+
+	if err := mock.InsertMultiple(db, &cases[1].wantData); err != nil {
+		t.Error(err)
+	}
+
+	kdb := pgsql.APIKey{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := kdb.FindByHash(db, tt.hash)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData.ID != 0 {
+				tt.wantData.CreatedAt = key.CreatedAt
+				tt.wantData.UpdatedAt = key.UpdatedAt
+				assert.Equal(t, tt.wantData, key)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	keys := []gorsk.APIKey{
+		{Base: gorsk.Base{ID: 1}, UserID: 1, Name: "CI", Hash: "a"},
+		{Base: gorsk.Base{ID: 2}, UserID: 1, Name: "Backup", Hash: "b"},
+		{Base: gorsk.Base{ID: 3}, UserID: 2, Name: "CI", Hash: "c"},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.APIKey{})
+
+	for i := range keys {
+		if err := mock.InsertMultiple(db, &keys[i]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	kdb := pgsql.APIKey{}
+
+	resp, err := kdb.List(db, 1)
+	assert.Nil(t, err)
+	if assert.Len(t, resp, 2) {
+		assert.Equal(t, 2, resp[0].ID)
+		assert.Equal(t, 1, resp[1].ID)
+	}
+}
@@ -0,0 +1,17 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// User represents the client for user table
+type User struct{}
+
+// View returns single user by ID
+func (u User) View(db orm.DB, id int) (gorsk.User, error) {
+	user := gorsk.User{Base: gorsk.Base{ID: id}}
+	err := db.Model(&user).WherePK().Select()
+	return user, err
+}
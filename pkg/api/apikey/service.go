@@ -0,0 +1,56 @@
+package apikey
+
+import (
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/apikey/platform/pgsql"
+)
+
+// Service represents API key application interface
+type Service interface {
+	List(echo.Context) ([]gorsk.APIKey, error)
+	Create(echo.Context, gorsk.APIKey) (gorsk.APIKey, string, error)
+	Revoke(echo.Context, int) error
+}
+
+// New creates new API key application service
+func New(db *pg.DB, kdb KDB, udb UDB, rbac RBAC) APIKey {
+	return APIKey{db: db, kdb: kdb, udb: udb, rbac: rbac}
+}
+
+// Initialize initalizes APIKey application service with defaults
+func Initialize(db *pg.DB, rbac RBAC) APIKey {
+	return New(db, pgsql.APIKey{}, pgsql.User{}, rbac)
+}
+
+// APIKey represents API key application service
+type APIKey struct {
+	db   *pg.DB
+	kdb  KDB
+	udb  UDB
+	rbac RBAC
+}
+
+// KDB represents API key repository interface
+type KDB interface {
+	Create(orm.DB, gorsk.APIKey) (gorsk.APIKey, error)
+	View(orm.DB, int) (gorsk.APIKey, error)
+	List(orm.DB, int) ([]gorsk.APIKey, error)
+	FindByHash(orm.DB, string) (gorsk.APIKey, error)
+	Touch(orm.DB, gorsk.APIKey) error
+	Delete(orm.DB, gorsk.APIKey) error
+}
+
+// UDB represents user repository interface
+type UDB interface {
+	View(orm.DB, int) (gorsk.User, error)
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+	RoleIncludes(gorsk.AccessRole, gorsk.AccessRole) (bool, error)
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/apikey"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents API key http service
+type HTTP struct {
+	svc apikey.Service
+}
+
+// NewHTTP creates new API key http service
+func NewHTTP(svc apikey.Service, r *echo.Group) {
+	h := HTTP{svc}
+	kr := r.Group("/me/tokens")
+
+	// swagger:route GET /v1/me/tokens tokens listTokens
+	// Returns API keys of currently logged user.
+	// responses:
+	//  200: tokenListResp
+	//  401: err
+	//  500: err
+	kr.GET("", h.list)
+
+	// swagger:operation POST /v1/me/tokens tokens tokenCreate
+	// ---
+	// summary: Creates a new API key
+	// description: Creates a new API key for currently logged user. The key is returned only once. Its role defaults to the owner's role, and can only be granted permissions the owner's role is granted.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/tokenCreate"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/tokenResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	kr.POST("", h.create)
+
+	// swagger:operation DELETE /v1/me/tokens/{id} tokens tokenRevoke
+	// ---
+	// summary: Revokes an API key
+	// description: Revokes one of currently logged user's API keys. Clients using it will not be able to authenticate anymore.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of API key
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	kr.DELETE("/:id", h.revoke)
+}
+
+// API key create request
+// swagger:model tokenCreate
+type createReq struct {
+	Name      string           `json:"name" validate:"required"`
+	Role      gorsk.AccessRole `json:"role" validate:"omitempty,min=100,max=200"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+type createResponse struct {
+	gorsk.APIKey
+	Key string `json:"key"`
+}
+
+type listResponse struct {
+	Tokens []gorsk.APIKey `json:"tokens"`
+}
+
+func (h HTTP) list(c echo.Context) error {
+	result, err := h.svc.List(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, listResponse{result})
+}
+
+func (h HTTP) create(c echo.Context) error {
+	req := new(createReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	k, key, err := h.svc.Create(c, gorsk.APIKey{
+		Name:      req.Name,
+		Role:      req.Role,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, createResponse{k, key})
+}
+
+func (h HTTP) revoke(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Revoke(c, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,242 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/apikey"
+	"github.com/ribice/gorsk/pkg/api/apikey/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/rbac"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	type listResponse struct {
+		Tokens []gorsk.APIKey `json:"tokens"`
+	}
+	cases := []struct {
+		name       string
+		wantStatus int
+		wantResp   *listResponse
+		kdb        *mockdb.APIKey
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Fail on List",
+			wantStatus: http.StatusInternalServerError,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ListFn: func(orm.DB, int) ([]gorsk.APIKey, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ListFn: func(db orm.DB, userID int) ([]gorsk.APIKey, error) {
+					return []gorsk.APIKey{
+						{
+							Base:       gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2018), UpdatedAt: mock.TestTime(2019)},
+							UserID:     userID,
+							Name:       "CI",
+							Hint:       "abcd",
+							Hash:       "hash",
+							Role:       gorsk.UserRole,
+							LastUsedAt: mock.TestTime(2019),
+						},
+					}, nil
+				}},
+			wantResp: &listResponse{
+				Tokens: []gorsk.APIKey{
+					{
+						Base:       gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2018), UpdatedAt: mock.TestTime(2019)},
+						Name:       "CI",
+						Hint:       "abcd",
+						Role:       gorsk.UserRole,
+						LastUsedAt: mock.TestTime(2019),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(apikey.New(nil, tt.kdb, nil, tt.rbac), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/me/tokens")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	type createResponse struct {
+		Name string           `json:"name"`
+		Role gorsk.AccessRole `json:"role"`
+		Hint string           `json:"hint"`
+		Hash string           `json:"hash"`
+		Key  string           `json:"key"`
+	}
+	roles := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), nil, 0)
+	rbac := &mock.RBAC{
+		UserFn: func(echo.Context) gorsk.AuthUser {
+			return gorsk.AuthUser{ID: 1, Role: gorsk.AdminRole}
+		},
+		RoleIncludesFn: roles.RoleIncludes,
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *createResponse
+		kdb        *mockdb.APIKey
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"role":300}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Role exceeds owner's role",
+			req:        `{"name":"CI","role":100}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			req:        `{"name":"CI","role":200}`,
+			wantStatus: http.StatusOK,
+			kdb: &mockdb.APIKey{
+				CreateFn: func(db orm.DB, key gorsk.APIKey) (gorsk.APIKey, error) {
+					key.ID = 1
+					return key, nil
+				}},
+			wantResp: &createResponse{
+				Name: "CI",
+				Role: gorsk.UserRole,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(apikey.New(nil, tt.kdb, nil, rbac), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/me/tokens", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(createResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Contains(t, response.Key, gorsk.APIKeyPrefix)
+				assert.Equal(t, response.Key[len(response.Key)-4:], response.Hint)
+				assert.Empty(t, response.Hash)
+				tt.wantResp.Key = response.Key
+				tt.wantResp.Hint = response.Hint
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		kdb        *mockdb.APIKey
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         "a",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "API key not found",
+			id:         "1",
+			wantStatus: http.StatusNotFound,
+			kdb: &mockdb.APIKey{
+				ViewFn: func(orm.DB, int) (gorsk.APIKey, error) {
+					return gorsk.APIKey{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			kdb: &mockdb.APIKey{
+				ViewFn: func(db orm.DB, id int) (gorsk.APIKey, error) {
+					return gorsk.APIKey{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, gorsk.APIKey) error {
+					return nil
+				}},
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(apikey.New(nil, tt.kdb, nil, tt.rbac), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/me/tokens/"+tt.id, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package transport
+
+import (
+	"github.com/ribice/gorsk"
+)
+
+// API keys model response
+// swagger:response tokenListResp
+type swaggTokenListResponse struct {
+	// in:body
+	Body struct {
+		Tokens []gorsk.APIKey `json:"tokens"`
+	}
+}
+
+// Created API key model response
+// swagger:response tokenResp
+type swaggTokenResponse struct {
+	// in:body
+	Body struct {
+		*gorsk.APIKey
+		Key string `json:"key"`
+	}
+}
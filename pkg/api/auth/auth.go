@@ -1,54 +1,652 @@
 package auth
 
 import (
+	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-pg/pg/v9"
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/oidc"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+	"github.com/ribice/gorsk/pkg/utl/server"
+	"github.com/ribice/gorsk/pkg/utl/totp"
 )
 
 // Custom errors
 var (
-	ErrInvalidCredentials = echo.NewHTTPError(http.StatusUnauthorized, "Username or password does not exist")
+	ErrInvalidCredentials  = echo.NewHTTPError(http.StatusUnauthorized, "Username or password does not exist")
+	ErrInvalidRefreshToken = echo.NewHTTPError(http.StatusUnauthorized, "Refresh token is invalid or expired")
+	ErrTooManyAttempts     = echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+	ErrInvalidMFAChallenge = echo.NewHTTPError(http.StatusUnauthorized, "Two-factor authentication challenge is invalid or expired")
+	ErrInvalidMFACode      = echo.NewHTTPError(http.StatusUnauthorized, "Two-factor authentication code is invalid")
+	ErrMFANotEnrolled      = echo.NewHTTPError(http.StatusBadRequest, "Two-factor authentication is not enrolled")
+	ErrMFAEnabled          = echo.NewHTTPError(http.StatusBadRequest, "Two-factor authentication is already enabled")
+	ErrUnknownProvider     = echo.NewHTTPError(http.StatusNotFound, "Identity provider does not exist")
+	ErrProviderLogin       = echo.NewHTTPError(http.StatusUnauthorized, "Login with identity provider failed")
+	ErrEmailNotVerified    = echo.NewHTTPError(http.StatusForbidden, "Identity provider did not verify the email")
+	ErrIdentityNotLinked   = echo.NewHTTPError(http.StatusUnauthorized, "No user account matches the identity")
+	ErrInvalidPwChallenge  = echo.NewHTTPError(http.StatusUnauthorized, "Password change challenge is invalid or expired")
+	ErrPasswordReused      = echo.NewHTTPError(http.StatusBadRequest, "Password was used recently")
 )
 
-// Authenticate tries to authenticate the user provided by username and password
+// Authenticate tries to authenticate the user provided by username and password.
+// Failed attempts are tracked per user and per client IP address, delaying and
+// eventually locking out further attempts. Unknown and locked out users get the same
+// error as wrong password, so valid usernames cannot be discovered. Users with two-factor authentication
+// enabled or required by their company get a challenge token instead of auth tokens.
+// Users whose password expired or has to be changed get a password change challenge token.
+// Password hashes of outdated algorithm or parameters are upgraded on successful authentication.
 func (a Auth) Authenticate(c echo.Context, user, pass string) (gorsk.AuthToken, error) {
+	_, ip := server.Client(c)
+	if ip != "" && a.lim.Wait(ip) > 0 {
+		return gorsk.AuthToken{}, ErrTooManyAttempts
+	}
+
 	u, err := a.udb.FindByUsername(a.db, user)
+	if err == pg.ErrNoRows {
+		a.failIP(c, ip)
+		return gorsk.AuthToken{}, ErrInvalidCredentials
+	}
 	if err != nil {
+		a.failIP(c, ip)
 		return gorsk.AuthToken{}, err
 	}
 
+	now := time.Now()
+	if a.cfg.Lockout.Wait(u.FailedLogins, u.LastFailedLogin, now) > 0 {
+		a.failIP(c, ip)
+		a.recordLogin(c, u, gorsk.LoginLockedOut)
+		return gorsk.AuthToken{}, ErrInvalidCredentials
+	}
+
 	if !a.sec.HashMatchesPassword(u.Password, pass) {
+		a.failIP(c, ip)
+		a.recordLogin(c, u, gorsk.LoginInvalidPassword)
+		if err := a.failUser(c, u, now); err != nil {
+			return gorsk.AuthToken{}, err
+		}
 		return gorsk.AuthToken{}, ErrInvalidCredentials
 	}
 
 	if !u.Active {
+		a.recordLogin(c, u, gorsk.LoginInactive)
 		return gorsk.AuthToken{}, gorsk.ErrUnauthorized
 	}
 
-	token, err := a.tg.GenerateToken(u)
+	if a.sec.NeedsRehash(u.Password) {
+		a.rehash(c, &u, pass)
+	}
+
+	if a.cfg.RequireVerifiedEmail && !u.EmailVerified() {
+		a.recordLogin(c, u, gorsk.LoginUnverifiedEmail)
+		return gorsk.AuthToken{}, gorsk.ErrUnverifiedEmail
+	}
+
+	return a.Authorize(c, u)
+}
+
+// ChangeExpiredPassword sets a new password for the user holding the password change challenge,
+// and logs the user in. The challenge is only issued after two-factor authentication, if required.
+// The new password must differ from the expired one and from the passwords covered by password history.
+func (a Auth) ChangeExpiredPassword(c echo.Context, challenge, pass string) (gorsk.AuthToken, error) {
+	id, err := a.tg.ParsePasswordChallenge(challenge)
 	if err != nil {
+		return gorsk.AuthToken{}, ErrInvalidPwChallenge
+	}
+	u, err := a.udb.View(a.db, id)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if !u.Active {
 		return gorsk.AuthToken{}, gorsk.ErrUnauthorized
 	}
 
-	u.UpdateLastLogin(a.sec.Token(token))
+	if err := a.sec.Password(pass, u.FirstName, u.LastName, u.Username, u.Email); err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	policy, err := a.passwordPolicy(u)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if a.sec.HashMatchesPassword(u.Password, pass) || policy.Reused(u, pass, a.sec.HashMatchesPassword) {
+		return gorsk.AuthToken{}, ErrPasswordReused
+	}
 
+	hash, err := a.sec.Hash(pass)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	u.RetirePassword(policy.History - 1)
+	u.ChangePassword(hash)
 	if err := a.udb.Update(a.db, u); err != nil {
 		return gorsk.AuthToken{}, err
 	}
 
-	return gorsk.AuthToken{Token: token, RefreshToken: u.Token}, nil
+	return a.login(c, u)
 }
 
-// Refresh refreshes jwt token and puts new claims inside
-func (a Auth) Refresh(c echo.Context, refreshToken string) (string, error) {
-	user, err := a.udb.FindByToken(a.db, refreshToken)
+// oidcStateCookie binds the state of login with identity provider to the browser that started it
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin starts login with OpenID Connect identity provider, returning URL the user should be redirected to.
+// The login's state is set in a short-lived cookie, sent only to provider's login paths.
+func (a Auth) OIDCLogin(c echo.Context, provider string) (string, error) {
+	p, ok := a.cfg.Providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	url, state, err := p.IdP.AuthCodeURL(requestContext(c))
 	if err != nil {
 		return "", err
 	}
-	return a.tg.GenerateToken(user)
+	c.SetCookie(stateCookie(c, provider, state, oidc.StateTTL))
+	return url, nil
+}
+
+// OIDCCallback completes login with OpenID Connect identity provider. The state has to match the one
+// set in the browser by OIDCLogin. The identity is linked to the user by verified email, marking user's
+// email as verified. Users not found are provisioned, if enabled for the provider.
+func (a Auth) OIDCCallback(c echo.Context, provider, state, code string) (gorsk.AuthToken, error) {
+	p, ok := a.cfg.Providers[provider]
+	if !ok {
+		return gorsk.AuthToken{}, ErrUnknownProvider
+	}
+
+	ck, err := c.Cookie(oidcStateCookie)
+	expired := stateCookie(c, provider, "", 0)
+	expired.MaxAge = -1
+	c.SetCookie(expired)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(ck.Value), []byte(state)) != 1 {
+		a.log.Log(c, "auth", "Identity provider login state does not match the browser", nil, map[string]interface{}{
+			"provider": provider,
+		})
+		return gorsk.AuthToken{}, ErrProviderLogin
+	}
+
+	id, err := p.IdP.Exchange(requestContext(c), state, code)
+	if err != nil {
+		a.log.Log(c, "auth", "Identity provider login failed", err, map[string]interface{}{
+			"provider": provider,
+		})
+		return gorsk.AuthToken{}, ErrProviderLogin
+	}
+
+	if id.Email == "" || !id.EmailVerified {
+		return gorsk.AuthToken{}, ErrEmailNotVerified
+	}
+
+	u, err := a.udb.FindByEmail(a.db, id.Email)
+	if err == pg.ErrNoRows {
+		u, err = a.provision(p, id)
+	}
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	if !u.Active {
+		a.recordLogin(c, u, gorsk.LoginInactive)
+		return gorsk.AuthToken{}, gorsk.ErrUnauthorized
+	}
+
+	if !u.EmailVerified() {
+		u.VerifyEmail(u.Email, time.Now())
+		if err := a.udb.Update(a.db, u); err != nil {
+			return gorsk.AuthToken{}, err
+		}
+	}
+
+	return a.Authorize(c, u)
+}
+
+// provision creates a new user for the identity, with provider's default role, company and location
+func (a Auth) provision(p Provider, id oidc.Identity) (gorsk.User, error) {
+	if !p.Provision {
+		return gorsk.User{}, ErrIdentityNotLinked
+	}
+	u, err := a.udb.Create(a.db, gorsk.User{
+		FirstName:       id.GivenName,
+		LastName:        id.FamilyName,
+		Username:        id.Email,
+		Email:           id.Email,
+		EmailVerifiedAt: time.Now(),
+		Active:          true,
+		RoleID:          p.RoleID,
+		CompanyID:       p.CompanyID,
+		LocationID:      p.LocationID,
+	})
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	return a.udb.View(a.db, u.ID)
+}
+
+// Authorize issues auth tokens to the authenticated user, or a challenge token
+// if the user has to pass two-factor authentication or change the expired password first.
+// Two-factor authentication comes first, so the password can only be changed by the user who passed it.
+// Other services authenticating users by their own means use it to log them in.
+func (a Auth) Authorize(c echo.Context, u gorsk.User) (gorsk.AuthToken, error) {
+	required, err := a.mfaRequired(u)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if required {
+		challenge, err := a.tg.GenerateChallenge(u)
+		if err != nil {
+			return gorsk.AuthToken{}, err
+		}
+		return gorsk.AuthToken{MFAToken: challenge}, nil
+	}
+
+	return a.authorizePassword(c, u)
+}
+
+// authorizePassword logs in the user who passed two-factor authentication if required,
+// or issues a password change challenge if the user's password expired
+func (a Auth) authorizePassword(c echo.Context, u gorsk.User) (gorsk.AuthToken, error) {
+	expired, err := a.passwordExpired(u, time.Now())
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if expired {
+		challenge, err := a.tg.GeneratePasswordChallenge(u)
+		if err != nil {
+			return gorsk.AuthToken{}, err
+		}
+		return gorsk.AuthToken{PasswordToken: challenge}, nil
+	}
+
+	return a.login(c, u)
+}
+
+// VerifyMFA exchanges two-factor authentication challenge and code for auth tokens.
+// The code is either a TOTP code or one of the recovery codes, each accepted only once. Users required to
+// use two-factor authentication confirm their enrollment with the first valid code,
+// getting recovery codes along with auth tokens.
+func (a Auth) VerifyMFA(c echo.Context, challenge, code string) (gorsk.AuthToken, error) {
+	u, err := a.challengeUser(challenge)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	_, ip := server.Client(c)
+	now := time.Now()
+	if a.cfg.Lockout.Wait(u.FailedLogins, u.LastFailedLogin, now) > 0 || (ip != "" && a.lim.Wait(ip) > 0) {
+		a.recordLogin(c, u, gorsk.LoginLockedOut)
+		return gorsk.AuthToken{}, ErrTooManyAttempts
+	}
+
+	var valid bool
+	switch {
+	case u.MFAEnabled:
+		if valid, err = a.useTOTP(&u, code, now); err == nil && !valid {
+			valid, err = a.useRecoveryCode(&u, code)
+		}
+	case u.MFASecret != "":
+		valid, err = a.useTOTP(&u, code, now)
+	default:
+		return gorsk.AuthToken{}, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	if !valid {
+		a.failIP(c, ip)
+		a.recordLogin(c, u, gorsk.LoginInvalidMFACode)
+		if err := a.failUser(c, u, now); err != nil {
+			return gorsk.AuthToken{}, err
+		}
+		return gorsk.AuthToken{}, ErrInvalidMFACode
+	}
+
+	var recoveryCodes []string
+	if !u.MFAEnabled {
+		if recoveryCodes, err = a.enableMFA(&u); err != nil {
+			return gorsk.AuthToken{}, err
+		}
+	}
+
+	token, err := a.authorizePassword(c, u)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	token.RecoveryCodes = recoveryCodes
+	return token, nil
+}
+
+// EnrollMFA generates two-factor authentication secret for the user holding the challenge,
+// whose company requires two-factor authentication. Enrollment is confirmed with VerifyMFA.
+func (a Auth) EnrollMFA(c echo.Context, challenge string) (gorsk.MFAEnrollment, error) {
+	u, err := a.challengeUser(challenge)
+	if err != nil {
+		return gorsk.MFAEnrollment{}, err
+	}
+
+	if u.MFAEnabled {
+		return gorsk.MFAEnrollment{}, ErrMFAEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return gorsk.MFAEnrollment{}, err
+	}
+
+	u.EnrollMFA(secret)
+
+	if err := a.udb.Update(a.db, u); err != nil {
+		return gorsk.MFAEnrollment{}, err
+	}
+
+	return gorsk.MFAEnrollment{Secret: secret, URI: totp.URI(a.cfg.MFAIssuer, u.Username, secret)}, nil
+}
+
+// login creates a new session for the user, returning its auth tokens
+func (a Auth) login(c echo.Context, u gorsk.User) (gorsk.AuthToken, error) {
+	family, err := secure.NewToken()
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	refresh, err := newRefreshToken(family)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	now := time.Now()
+	ua, ip := server.Client(c)
+	s := gorsk.Session{
+		UserID:    u.ID,
+		Device:    device(ua),
+		UserAgent: ua,
+		IP:        ip,
+		Family:    family,
+		ExpiresAt: expiry(now, a.cfg.MaxRefresh, time.Time{}),
+	}
+	s.Rotate(secure.HashToken(refresh), expiry(now, a.cfg.RefreshDuration, s.ExpiresAt))
+
+	s, err = a.sdb.Create(a.db, s)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	token, err := a.tg.GenerateToken(u, s.ID)
+	if err != nil {
+		return gorsk.AuthToken{}, gorsk.ErrUnauthorized
+	}
+
+	u.UpdateLastLogin()
+
+	if err := a.udb.Update(a.db, u); err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	a.recordLogin(c, u, "")
+
+	return gorsk.AuthToken{Token: token, RefreshToken: refresh}, nil
+}
+
+// recordLogin records login attempt of the user, failed for the given reason or successful if it is empty.
+// Users logging in successfully from a new device or IP address are notified. Failures are only logged,
+// as they should not prevent users from logging in.
+func (a Auth) recordLogin(c echo.Context, u gorsk.User, reason string) {
+	ua, ip := server.Client(c)
+	e := gorsk.LoginEvent{
+		UserID:    u.ID,
+		Device:    device(ua),
+		UserAgent: ua,
+		IP:        ip,
+		Success:   reason == "",
+		Reason:    reason,
+	}
+
+	var origin gorsk.LoginOrigin
+	if e.Success && a.cfg.Notifier != nil {
+		var err error
+		if origin, err = a.ldb.Origin(a.db, u.ID, e.Device, e.IP); err != nil {
+			a.logUserError(c, "Checking login origin failed", err, u)
+		}
+	}
+
+	e, err := a.ldb.Create(a.db, e)
+	if err != nil {
+		a.logUserError(c, "Recording login failed", err, u)
+	}
+
+	if origin.New() {
+		if err := a.cfg.Notifier.NewLogin(u, e); err != nil {
+			a.logUserError(c, "Notifying about new login failed", err, u)
+		}
+	}
+}
+
+// logUserError logs an error of operation on the user, not affecting outcome of the request
+func (a Auth) logUserError(c echo.Context, msg string, err error, u gorsk.User) {
+	a.log.Log(c, "auth", msg, err, map[string]interface{}{
+		"user_id": u.ID,
+	})
+}
+
+// mfaRequired checks whether the user has to pass two-factor authentication,
+// either because it is enabled or because the user's company requires it
+func (a Auth) mfaRequired(u gorsk.User) (bool, error) {
+	if u.MFAEnabled || u.CompanyID == 0 {
+		return u.MFAEnabled, nil
+	}
+	company, err := a.cdb.View(a.db, u.CompanyID)
+	if err == pg.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return company.RequireMFA, nil
+}
+
+// passwordPolicy returns password policy applying to the user, with overrides of user's company
+func (a Auth) passwordPolicy(u gorsk.User) (gorsk.PasswordPolicy, error) {
+	if u.CompanyID == 0 {
+		return a.cfg.PasswordPolicy, nil
+	}
+	company, err := a.cdb.View(a.db, u.CompanyID)
+	if err == pg.ErrNoRows {
+		return a.cfg.PasswordPolicy, nil
+	}
+	if err != nil {
+		return gorsk.PasswordPolicy{}, err
+	}
+	return a.cfg.PasswordPolicy.ForCompany(company), nil
+}
+
+// passwordExpired checks whether the user's password expired under the applying policy, or has to be changed
+func (a Auth) passwordExpired(u gorsk.User, now time.Time) (bool, error) {
+	policy, err := a.passwordPolicy(u)
+	if err != nil {
+		return false, err
+	}
+	return policy.Expired(u, now), nil
+}
+
+// challengeUser returns active user the two-factor authentication challenge was issued to
+func (a Auth) challengeUser(challenge string) (gorsk.User, error) {
+	id, err := a.tg.ParseChallenge(challenge)
+	if err != nil {
+		return gorsk.User{}, ErrInvalidMFAChallenge
+	}
+	u, err := a.udb.View(a.db, id)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	if !u.Active {
+		return gorsk.User{}, gorsk.ErrUnauthorized
+	}
+	return u, nil
+}
+
+// enableMFA enables two-factor authentication for the user, returning generated recovery codes
+func (a Auth) enableMFA(u *gorsk.User) ([]string, error) {
+	codes, err := totp.RecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = secure.HashToken(code)
+	}
+	u.EnableMFA(hashes)
+	return codes, nil
+}
+
+// useTOTP checks user's TOTP code, recording its time step so the code cannot be used again
+func (a Auth) useTOTP(u *gorsk.User, code string, now time.Time) (bool, error) {
+	step, ok := totp.Match(u.MFASecret, code, now, u.MFALastStep)
+	if !ok {
+		return false, nil
+	}
+	if ok, err := a.udb.UseMFAStep(a.db, u.ID, step); !ok || err != nil {
+		return false, err
+	}
+	u.MFALastStep = step
+	return true, nil
+}
+
+// useRecoveryCode consumes user's recovery code, looked up by its hash
+func (a Auth) useRecoveryCode(u *gorsk.User, code string) (bool, error) {
+	hash := secure.HashToken(code)
+	if !u.UseRecoveryCode(hash) {
+		return false, nil
+	}
+	return a.udb.UseRecoveryCode(a.db, u.ID, hash)
+}
+
+// rehash upgrades user's password hash to configured algorithm and parameters.
+// Failures are only logged, as the password can be rehashed on next login.
+func (a Auth) rehash(c echo.Context, u *gorsk.User, pass string) {
+	hash, err := a.sec.Hash(pass)
+	if err == nil {
+		upgraded := *u
+		upgraded.Password = hash
+		if err = a.udb.Update(a.db, upgraded); err == nil {
+			*u = upgraded
+			return
+		}
+	}
+	a.logUserError(c, "Upgrading password hash failed", err, *u)
+}
+
+// failUser records a failed login attempt for the user, logging when the account gets locked out
+func (a Auth) failUser(c echo.Context, u gorsk.User, now time.Time) error {
+	failures, err := a.udb.LoginFailed(a.db, u.ID, now, a.cfg.Lockout.Since(now))
+	if err != nil {
+		return err
+	}
+	u.LoginFailed(failures, now)
+	if a.cfg.Lockout.Locked(u.FailedLogins) {
+		a.log.Log(c, "auth", "Account locked out", nil, map[string]interface{}{
+			"username":      u.Username,
+			"failed_logins": u.FailedLogins,
+			"lockout":       a.cfg.Lockout.Lockout,
+		})
+	}
+	return nil
+}
+
+// failIP records a failed login attempt from the client IP address, logging when it gets locked out
+func (a Auth) failIP(c echo.Context, ip string) {
+	if ip == "" {
+		return
+	}
+	if failures := a.lim.Fail(ip); a.lim.Locked(ip) {
+		a.log.Log(c, "auth", "IP address locked out", nil, map[string]interface{}{
+			"ip":            ip,
+			"failed_logins": failures,
+		})
+	}
+}
+
+// Refresh rotates session's refresh token and issues a new jwt token.
+// Presenting a refresh token that was already rotated, even by a concurrent request, revokes the session, as does
+// refreshing it after user's password expired, so the user has to log in and change it.
+func (a Auth) Refresh(c echo.Context, token string) (gorsk.AuthToken, error) {
+	s, err := a.sdb.FindByToken(a.db, secure.HashToken(token))
+	if err == pg.ErrNoRows {
+		return gorsk.AuthToken{}, a.revokeReused(token)
+	}
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	now := time.Now()
+	if s.Expired(now) {
+		return gorsk.AuthToken{}, ErrInvalidRefreshToken
+	}
+
+	user, err := a.udb.View(a.db, s.UserID)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	if !user.Active {
+		return gorsk.AuthToken{}, ErrInvalidRefreshToken
+	}
+
+	expired, err := a.passwordExpired(user, now)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if expired {
+		if err := a.sdb.Delete(a.db, s); err != nil {
+			return gorsk.AuthToken{}, err
+		}
+		return gorsk.AuthToken{}, ErrInvalidRefreshToken
+	}
+
+	jwt, err := a.tg.GenerateToken(user, s.ID)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	refresh, err := newRefreshToken(s.Family)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	s.Rotate(secure.HashToken(refresh), expiry(now, a.cfg.RefreshDuration, s.ExpiresAt))
+
+	err = a.sdb.Rotate(a.db, s, secure.HashToken(token))
+	if err == pg.ErrNoRows {
+		return gorsk.AuthToken{}, a.revokeReused(token)
+	}
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	return gorsk.AuthToken{Token: jwt, RefreshToken: refresh}, nil
+}
+
+// revokeReused revokes the session an unknown refresh token belongs to, in case
+// the session is still active. That means the token was already rotated and is being reused.
+func (a Auth) revokeReused(token string) error {
+	family := tokenFamily(token)
+	if family == "" {
+		return ErrInvalidRefreshToken
+	}
+	s, err := a.sdb.FindByFamily(a.db, family)
+	if err == pg.ErrNoRows {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	if err := a.sdb.Delete(a.db, s); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
 }
 
 // Me returns info about currently logged user
@@ -56,3 +654,104 @@ func (a Auth) Me(c echo.Context) (gorsk.User, error) {
 	au := a.rbac.User(c)
 	return a.udb.View(a.db, au.ID)
 }
+
+// JWKS returns public keys other services can use to verify issued jwt tokens
+func (a Auth) JWKS(c echo.Context) gorsk.JWKS {
+	return a.tg.JWKS()
+}
+
+// Logout revokes current session and denylists the jwt token used for the request until it expires,
+// allowing for clock skew.
+// Logging out of an impersonation token stops the impersonation.
+func (a Auth) Logout(c echo.Context) error {
+	au := a.rbac.User(c)
+	if au.ActorID != 0 {
+		a.log.Log(c, "auth", "Impersonation stopped", nil, map[string]interface{}{
+			"user_id":  au.ID,
+			"actor_id": au.ActorID,
+		})
+	}
+	if au.SessionID != 0 {
+		if err := a.sdb.Delete(a.db, gorsk.Session{Base: gorsk.Base{ID: au.SessionID}}); err != nil {
+			return err
+		}
+	}
+	if au.TokenID == "" {
+		return nil
+	}
+	return a.dl.Add(au.TokenID, au.TokenExpiresAt.Add(a.cfg.ClockSkew))
+}
+
+// newRefreshToken generates random refresh token prefixed with its family, so reused tokens can be traced
+// back to the session. Sessions store only hashes of refresh tokens.
+func newRefreshToken(family string) (string, error) {
+	token, err := secure.NewToken()
+	if err != nil {
+		return "", err
+	}
+	return family + "." + token, nil
+}
+
+func tokenFamily(token string) string {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// expiry returns now+ttl capped at max. Zero ttl means no expiry on its own.
+func expiry(now time.Time, ttl time.Duration, max time.Time) time.Time {
+	if ttl <= 0 {
+		return max
+	}
+	exp := now.Add(ttl)
+	if !max.IsZero() && exp.After(max) {
+		return max
+	}
+	return exp
+}
+
+// stateCookie creates HttpOnly cookie holding the state of login with the provider. It is sent along with
+// top-level navigation from the identity provider, which SameSite strict cookies are not.
+func stateCookie(c echo.Context, provider, state string, maxAge time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/login/oidc/" + provider,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// requestContext returns context of the request, if any
+func requestContext(c echo.Context) context.Context {
+	if c == nil || c.Request() == nil {
+		return context.Background()
+	}
+	return c.Request().Context()
+}
+
+// device returns a short, human readable device description based on user agent
+func device(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		return "iPhone"
+	case strings.Contains(ua, "iPad"):
+		return "iPad"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Macintosh"):
+		return "Mac"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	case ua == "":
+		return "Unknown"
+	default:
+		return "Other"
+	}
+}
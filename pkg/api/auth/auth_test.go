@@ -1,15 +1,26 @@
 package auth_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/auth"
+	"github.com/ribice/gorsk/pkg/utl/denylist"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
 	"github.com/ribice/gorsk/pkg/utl/mock"
 	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/oidc"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+	"github.com/ribice/gorsk/pkg/utl/totp"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,8 +36,12 @@ func TestAuthenticate(t *testing.T) {
 		wantData gorsk.AuthToken
 		wantErr  bool
 		udb      *mockdb.User
+		sdb      *mockdb.Session
+		cdb      *mockdb.Company
 		jwt      *mock.JWT
 		sec      *mock.Secure
+		log      *mock.Logger
+		cfg      auth.Config
 	}{
 		{
 			name:    "Fail on finding user",
@@ -44,134 +59,1340 @@ func TestAuthenticate(t *testing.T) {
 			wantErr: true,
 			udb: &mockdb.User{
 				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
-					return gorsk.User{Username: user}, nil
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Username: user}, nil
+				},
+				LoginFailedFn: func(db orm.DB, id int, at, since time.Time) (int, error) {
+					if id != 1 || at.IsZero() {
+						t.Error("failed login was not recorded")
+					}
+					return 1, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return false
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+		},
+		{
+			name:    "Fail on locked out user",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			cfg:     auth.Config{Lockout: lockout.Policy{MaxAttempts: 3, Lockout: time.Minute}},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username:        user,
+						FailedLogins:    3,
+						LastFailedLogin: time.Now(),
+					}, nil
+				},
+			},
+		},
+		{
+			name:    "Lock out user on wrong password",
+			args:    args{user: "juzernejm", pass: "notHashedPassword"},
+			wantErr: true,
+			cfg:     auth.Config{Lockout: lockout.Policy{MaxAttempts: 3, Lockout: time.Minute}},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username:        user,
+						FailedLogins:    2,
+						LastFailedLogin: time.Now().Add(-time.Second),
+					}, nil
+				},
+				LoginFailedFn: func(db orm.DB, id int, at, since time.Time) (int, error) {
+					if !since.Equal(at.Add(-time.Minute)) {
+						t.Error("failures older than lockout were not forgotten")
+					}
+					return 3, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return false
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			log: &mock.Logger{
+				LogFn: func(c echo.Context, source, msg string, err error, params map[string]interface{}) {
+					if params["failed_logins"] != 3 {
+						t.Error("lockout was not logged")
+					}
+				},
+			},
+		},
+		{
+			name:    "Inactive user",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username: user,
+						Password: "pass",
+						Active:   false,
+					}, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+		},
+		{
+			name:    "Fail on unverified email",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username: user,
+						Active:   true,
+					}, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			cfg: auth.Config{RequireVerifiedEmail: true},
+		},
+		{
+			name: "MFA enabled",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username:   user,
+						Active:     true,
+						MFAEnabled: true,
+					}, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateChallengeFn: func(gorsk.User) (string, error) {
+					return "challenge", nil
+				},
+			},
+			wantData: gorsk.AuthToken{MFAToken: "challenge"},
+		},
+		{
+			name: "MFA required by company",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username:  user,
+						Active:    true,
+						CompanyID: 1,
+					}, nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (gorsk.Company, error) {
+					return gorsk.Company{RequireMFA: true}, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateChallengeFn: func(gorsk.User) (string, error) {
+					return "challenge", nil
+				},
+			},
+			wantData: gorsk.AuthToken{MFAToken: "challenge"},
+		},
+		{
+			name:    "Fail on viewing company",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username:  user,
+						Active:    true,
+						CompanyID: 1,
+					}, nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (gorsk.Company, error) {
+					return gorsk.Company{}, gorsk.ErrGeneric
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+		},
+		{
+			name:    "Fail on token generation",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username: user,
+						Password: "pass",
+						Active:   true,
+					}, nil
+				},
+			},
+			sdb: &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					return s, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "", gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Fail on creating session",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username: user,
+						Password: "pass",
+						Active:   true,
+					}, nil
+				},
+			},
+			sdb: &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					return gorsk.Session{}, gorsk.ErrGeneric
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				},
+			},
+		},
+		{
+			name:    "Fail on updating last login",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username: user,
+						Password: "pass",
+						Active:   true,
+					}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					return gorsk.ErrGeneric
+				},
+			},
+			sdb: &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					return s, nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				},
+			},
+		},
+		{
+			name: "Success",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return gorsk.User{
+						Username: user,
+						Password: "password",
+						Active:   true,
+					}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					if s.Family == "" || s.Token == "" {
+						t.Error("session refresh token was not set")
+					}
+					s.ID = 1
+					return s, nil
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(u gorsk.User, sessionID int) (string, error) {
+					if sessionID != 1 {
+						t.Error("session ID was not passed to jwt token")
+					}
+					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				},
+			},
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+			wantData: gorsk.AuthToken{
+				Token:        "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+				RefreshToken: "refreshtoken",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := auth.New(nil, tt.udb, tt.sdb, tt.cdb, loginDB, tt.jwt, tt.sec, nil, nil, lockout.NewMemory(lockout.Policy{}), tt.log, tt.cfg)
+			token, err := s.Authenticate(nil, tt.args.user, tt.args.pass)
+			if tt.wantData.RefreshToken != "" {
+				tt.wantData.RefreshToken = token.RefreshToken
+			}
+			assert.Equal(t, tt.wantData, token)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAuthenticateUnknownOrLockedUser(t *testing.T) {
+	cases := []struct {
+		name string
+		user gorsk.User
+		err  error
+	}{
+		{
+			name: "Unknown user",
+			err:  pg.ErrNoRows,
+		},
+		{
+			name: "Locked out user",
+			user: gorsk.User{Username: "juzernejm", FailedLogins: 3, LastFailedLogin: time.Now()},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			udb := &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+					return tt.user, tt.err
+				},
+			}
+			s := auth.New(nil, udb, nil, nil, loginDB, nil, nil, nil, nil, lockout.NewMemory(lockout.Policy{}), nil, auth.Config{
+				Lockout: lockout.Policy{MaxAttempts: 3, Lockout: time.Minute},
+			})
+			_, err := s.Authenticate(nil, "juzernejm", "pass")
+			assert.Equal(t, auth.ErrInvalidCredentials, err)
+		})
+	}
+}
+
+func TestAuthenticateIPLockout(t *testing.T) {
+	udb := &mockdb.User{
+		FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
+			return gorsk.User{}, pg.ErrNoRows
+		},
+	}
+	var logged bool
+	log := &mock.Logger{
+		LogFn: func(c echo.Context, source, msg string, err error, params map[string]interface{}) {
+			logged = params["ip"] == "192.0.2.1"
+		},
+	}
+	lim := lockout.NewMemory(lockout.Policy{MaxAttempts: 2, Lockout: time.Minute})
+	s := auth.New(nil, udb, nil, nil, loginDB, nil, nil, nil, nil, lim, log, auth.Config{})
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Authenticate(c, "juzernejm", "pass")
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	}
+	assert.True(t, logged)
+
+	_, err := s.Authenticate(c, "juzernejm", "pass")
+	assert.Equal(t, auth.ErrTooManyAttempts, err)
+}
+
+func TestAuthenticateRehash(t *testing.T) {
+	cases := []struct {
+		name       string
+		hashErr    error
+		updateErr  error
+		wantHash   string
+		wantLogged bool
+	}{
+		{
+			name:     "Success",
+			wantHash: "$argon2id$upgraded",
+		},
+		{
+			name:       "Fail on hashing",
+			hashErr:    gorsk.ErrGeneric,
+			wantHash:   "$2a$10$legacy",
+			wantLogged: true,
+		},
+		{
+			name:       "Fail on update",
+			updateErr:  gorsk.ErrGeneric,
+			wantHash:   "$2a$10$legacy",
+			wantLogged: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored string
+			udb := &mockdb.User{
+				FindByUsernameFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Username: "johndoe", Password: "$2a$10$legacy", Active: true, MFAEnabled: true}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					if tt.updateErr == nil {
+						stored = u.Password
+					}
+					return tt.updateErr
+				},
+			}
+			sec := &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				NeedsRehashFn: func(hash string) bool {
+					return hash == "$2a$10$legacy"
+				},
+				HashFn: func(string) (string, error) {
+					return "$argon2id$upgraded", tt.hashErr
+				},
+			}
+			jwt := &mock.JWT{
+				GenerateChallengeFn: func(u gorsk.User) (string, error) {
+					assert.Equal(t, tt.wantHash, u.Password)
+					return "mfachallenge", nil
+				},
+			}
+			var logged bool
+			log := &mock.Logger{
+				LogFn: func(echo.Context, string, string, error, map[string]interface{}) {
+					logged = true
+				},
+			}
+			s := auth.New(nil, udb, nil, nil, loginDB, jwt, sec, nil, nil, lockout.NewMemory(lockout.Policy{}), log, auth.Config{})
+			token, err := s.Authenticate(nil, "johndoe", "pass")
+			assert.Nil(t, err)
+			assert.Equal(t, "mfachallenge", token.MFAToken)
+			assert.Equal(t, tt.wantLogged, logged)
+			if tt.updateErr == nil && tt.hashErr == nil {
+				assert.Equal(t, tt.wantHash, stored)
+			}
+		})
+	}
+}
+
+func TestAuthenticateLoginEvents(t *testing.T) {
+	cases := []struct {
+		name         string
+		pass         string
+		origin       gorsk.LoginOrigin
+		createErr    error
+		wantErr      error
+		wantEvent    gorsk.LoginEvent
+		wantNotified bool
+		wantLogged   bool
+	}{
+		{
+			name:      "Fail on invalid password",
+			pass:      "wrong",
+			wantErr:   auth.ErrInvalidCredentials,
+			wantEvent: gorsk.LoginEvent{UserID: 1, Device: "Mac", UserAgent: "Mozilla/5.0 (Macintosh)", IP: "192.0.2.1", Reason: gorsk.LoginInvalidPassword},
+		},
+		{
+			name:         "Success from new device",
+			pass:         "pass",
+			origin:       gorsk.LoginOrigin{Returning: true, KnownIP: true},
+			wantEvent:    gorsk.LoginEvent{UserID: 1, Device: "Mac", UserAgent: "Mozilla/5.0 (Macintosh)", IP: "192.0.2.1", Success: true},
+			wantNotified: true,
+		},
+		{
+			name:      "Success from known device",
+			pass:      "pass",
+			origin:    gorsk.LoginOrigin{Returning: true, KnownDevice: true, KnownIP: true},
+			wantEvent: gorsk.LoginEvent{UserID: 1, Device: "Mac", UserAgent: "Mozilla/5.0 (Macintosh)", IP: "192.0.2.1", Success: true},
+		},
+		{
+			name:      "Success on first login",
+			pass:      "pass",
+			wantEvent: gorsk.LoginEvent{UserID: 1, Device: "Mac", UserAgent: "Mozilla/5.0 (Macintosh)", IP: "192.0.2.1", Success: true},
+		},
+		{
+			name:       "Success when recording fails",
+			pass:       "pass",
+			createErr:  gorsk.ErrGeneric,
+			wantEvent:  gorsk.LoginEvent{UserID: 1, Device: "Mac", UserAgent: "Mozilla/5.0 (Macintosh)", IP: "192.0.2.1", Success: true},
+			wantLogged: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			udb := &mockdb.User{
+				FindByUsernameFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Password: "hash", Active: true}, nil
+				},
+				UpdateFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+				LoginFailedFn: func(orm.DB, int, time.Time, time.Time) (int, error) {
+					return 1, nil
+				},
+			}
+			sdb := &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					return s, nil
+				},
+			}
+			sec := &mock.Secure{
+				HashMatchesPasswordFn: func(hash, pass string) bool {
+					return pass == "pass"
+				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			}
+			jwt := &mock.JWT{
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "jwttokenstring", nil
+				},
+			}
+			var event gorsk.LoginEvent
+			ldb := &mockdb.LoginEvent{
+				CreateFn: func(db orm.DB, e gorsk.LoginEvent) (gorsk.LoginEvent, error) {
+					event = e
+					return e, tt.createErr
+				},
+				OriginFn: func(db orm.DB, userID int, device, ip string) (gorsk.LoginOrigin, error) {
+					assert.Equal(t, 1, userID)
+					assert.Equal(t, "Mac", device)
+					assert.Equal(t, "192.0.2.1", ip)
+					return tt.origin, nil
+				},
+			}
+			var notified bool
+			n := &mock.Notifier{
+				NewLoginFn: func(u gorsk.User, e gorsk.LoginEvent) error {
+					notified = u.ID == 1 && e.Success
+					return nil
+				},
+			}
+			var logged bool
+			log := &mock.Logger{
+				LogFn: func(echo.Context, string, string, error, map[string]interface{}) {
+					logged = true
+				},
+			}
+
+			req := httptest.NewRequest("POST", "/login", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh)")
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			s := auth.New(nil, udb, sdb, nil, ldb, jwt, sec, nil, nil, lockout.NewMemory(lockout.Policy{}), log, auth.Config{Notifier: n})
+			_, err := s.Authenticate(c, "johndoe", tt.pass)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantEvent, event)
+			assert.Equal(t, tt.wantNotified, notified)
+			assert.Equal(t, tt.wantLogged, logged)
+		})
+	}
+}
+
+func TestVerifyMFA(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	successSdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+			return s, nil
+		},
+	}
+	successJWT := &mock.JWT{
+		ParseChallengeFn: func(string) (int, error) {
+			return 1, nil
+		},
+		GenerateTokenFn: func(gorsk.User, int) (string, error) {
+			return "jwttoken", nil
+		},
+	}
+	cases := []struct {
+		name              string
+		code              string
+		wantErr           error
+		wantRecoveryCodes bool
+		wantPwChange      bool
+		udb               *mockdb.User
+		sdb               *mockdb.Session
+		jwt               *mock.JWT
+		sec               *mock.Secure
+	}{
+		{
+			name:    "Fail on invalid challenge",
+			wantErr: auth.ErrInvalidMFAChallenge,
+			jwt: &mock.JWT{
+				ParseChallengeFn: func(string) (int, error) {
+					return 0, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Fail on inactive user",
+			wantErr: gorsk.ErrUnauthorized,
+			jwt:     successJWT,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on not enrolled",
+			wantErr: auth.ErrMFANotEnrolled,
+			jwt:     successJWT,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on invalid code",
+			code:    "000000",
+			wantErr: auth.ErrInvalidMFACode,
+			jwt:     successJWT,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true, MFASecret: secret}, nil
+				},
+				LoginFailedFn: func(db orm.DB, id int, at, since time.Time) (int, error) {
+					return 1, nil
 				},
 			},
 			sec: &mock.Secure{
 				HashMatchesPasswordFn: func(string, string) bool {
 					return false
 				},
+				NeedsRehashFn: func(string) bool {
+					return false
+				},
+			},
+		},
+		{
+			name: "Success with TOTP code",
+			code: code,
+			jwt:  successJWT,
+			sdb:  successSdb,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true, MFASecret: secret}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					if u.MFALastStep != totp.Step(time.Now()) {
+						t.Error("code time step was not recorded")
+					}
+					return nil
+				},
+				UseMFAStepFn: func(db orm.DB, id int, step int64) (bool, error) {
+					return true, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on replayed TOTP code",
+			code:    code,
+			wantErr: auth.ErrInvalidMFACode,
+			jwt:     successJWT,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true, MFASecret: secret}, nil
+				},
+				UseMFAStepFn: func(db orm.DB, id int, step int64) (bool, error) {
+					return false, nil
+				},
+				LoginFailedFn: func(db orm.DB, id int, at, since time.Time) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on consuming recovery code",
+			code:    "abcd-efgh",
+			wantErr: gorsk.ErrGeneric,
+			jwt:     successJWT,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true, MFASecret: secret, MFARecoveryCodes: []string{secure.HashToken("abcd-efgh")}}, nil
+				},
+				UseRecoveryCodeFn: func(db orm.DB, id int, hash string) (bool, error) {
+					return false, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Success with recovery code",
+			code: "abcd-efgh",
+			jwt:  successJWT,
+			sdb:  successSdb,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true, MFASecret: secret, MFARecoveryCodes: []string{secure.HashToken("abcd-efgh")}}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					if len(u.MFARecoveryCodes) != 0 {
+						t.Error("recovery code was not consumed")
+					}
+					return nil
+				},
+				UseRecoveryCodeFn: func(db orm.DB, id int, hash string) (bool, error) {
+					if hash != secure.HashToken("abcd-efgh") {
+						t.Error("recovery code was not looked up by its hash")
+					}
+					return true, nil
+				},
+			},
+		},
+		{
+			name:         "Password change required after TOTP code",
+			code:         code,
+			wantPwChange: true,
+			jwt: &mock.JWT{
+				ParseChallengeFn: func(string) (int, error) {
+					return 1, nil
+				},
+				GeneratePasswordChallengeFn: func(gorsk.User) (string, error) {
+					return "pwchallenge", nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true, MFASecret: secret, MustChangePassword: true}, nil
+				},
+				UseMFAStepFn: func(db orm.DB, id int, step int64) (bool, error) {
+					return true, nil
+				},
+			},
+		},
+		{
+			name:              "Success confirming enrollment",
+			code:              code,
+			wantRecoveryCodes: true,
+			jwt:               successJWT,
+			sdb:               successSdb,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFASecret: secret}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					if !u.MFAEnabled || len(u.MFARecoveryCodes) == 0 {
+						t.Error("MFA was not enabled")
+					}
+					return nil
+				},
+				UseMFAStepFn: func(db orm.DB, id int, step int64) (bool, error) {
+					return true, nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := auth.New(nil, tt.udb, tt.sdb, nil, loginDB, tt.jwt, tt.sec, nil, nil, lockout.NewMemory(lockout.Policy{}), nil, auth.Config{})
+			token, err := s.VerifyMFA(nil, "challenge", tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantPwChange {
+				assert.Equal(t, gorsk.AuthToken{PasswordToken: "pwchallenge"}, token)
+				return
+			}
+			if tt.wantErr == nil {
+				assert.Equal(t, "jwttoken", token.Token)
+				assert.Equal(t, tt.wantRecoveryCodes, len(token.RecoveryCodes) > 0)
+			}
+		})
+	}
+}
+
+func TestEnrollMFA(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr error
+		udb     *mockdb.User
+	}{
+		{
+			name:    "Fail on enabled MFA",
+			wantErr: auth.ErrMFAEnabled,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true}, nil
+				},
 			},
 		},
 		{
-			name:    "Inactive user",
-			args:    args{user: "juzernejm", pass: "pass"},
-			wantErr: true,
+			name:    "Fail on update",
+			wantErr: gorsk.ErrGeneric,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					return gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Success",
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Active: true, Username: "johndoe"}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					if u.MFASecret == "" || u.MFAEnabled {
+						t.Error("MFA secret was not enrolled")
+					}
+					return nil
+				},
+			},
+		},
+	}
+	jwt := &mock.JWT{
+		ParseChallengeFn: func(string) (int, error) {
+			return 1, nil
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := auth.New(nil, tt.udb, nil, nil, loginDB, jwt, nil, nil, nil, nil, nil, auth.Config{MFAIssuer: "Gorsk"})
+			enrollment, err := s.EnrollMFA(nil, "challenge")
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.NotEmpty(t, enrollment.Secret)
+				assert.Contains(t, enrollment.URI, "Gorsk:johndoe")
+			}
+		})
+	}
+}
+
+func TestAuthenticatePasswordExpired(t *testing.T) {
+	maxAge := 1
+	cases := []struct {
+		name     string
+		user     gorsk.User
+		cdb      *mockdb.Company
+		wantData gorsk.AuthToken
+	}{
+		{
+			name:     "Must change password",
+			user:     gorsk.User{Active: true, MustChangePassword: true, LastPasswordChange: time.Now()},
+			wantData: gorsk.AuthToken{PasswordToken: "pwchallenge"},
+		},
+		{
+			name:     "Expired by default policy",
+			user:     gorsk.User{Active: true, LastPasswordChange: time.Now().Add(-100 * 24 * time.Hour)},
+			wantData: gorsk.AuthToken{PasswordToken: "pwchallenge"},
+		},
+		{
+			name: "Expired by company policy",
+			user: gorsk.User{Active: true, CompanyID: 1, LastPasswordChange: time.Now().Add(-2 * 24 * time.Hour)},
+			cdb: &mockdb.Company{
+				ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+					return gorsk.Company{PasswordMaxAgeDays: &maxAge}, nil
+				},
+			},
+			wantData: gorsk.AuthToken{PasswordToken: "pwchallenge"},
+		},
+		{
+			name:     "Not expired",
+			user:     gorsk.User{Active: true, LastPasswordChange: time.Now().Add(-2 * 24 * time.Hour)},
+			wantData: gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken.refreshtoken"},
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+			return s, nil
+		},
+	}
+	sec := &mock.Secure{
+		HashMatchesPasswordFn: func(string, string) bool {
+			return true
+		},
+		NeedsRehashFn: func(string) bool {
+			return false
+		},
+	}
+	jwt := &mock.JWT{
+		GenerateTokenFn: func(gorsk.User, int) (string, error) {
+			return "jwttokenstring", nil
+		},
+		GeneratePasswordChallengeFn: func(gorsk.User) (string, error) {
+			return "pwchallenge", nil
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			udb := &mockdb.User{
+				FindByUsernameFn: func(orm.DB, string) (gorsk.User, error) {
+					return tt.user, nil
+				},
+				UpdateFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			}
+			cdb := tt.cdb
+			if cdb == nil {
+				cdb = &mockdb.Company{
+					ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+						return gorsk.Company{}, pg.ErrNoRows
+					},
+				}
+			}
+			s := auth.New(nil, udb, sdb, cdb, loginDB, jwt, sec, nil, nil, lockout.NewMemory(lockout.Policy{}), nil, auth.Config{
+				PasswordPolicy: gorsk.PasswordPolicy{MaxAge: 90 * 24 * time.Hour},
+			})
+			token, err := s.Authenticate(nil, "johndoe", "pass")
+			assert.Nil(t, err)
+			if tt.wantData.RefreshToken != "" {
+				tt.wantData.RefreshToken = token.RefreshToken
+			}
+			assert.Equal(t, tt.wantData, token)
+		})
+	}
+}
+
+func TestAuthorizePasswordExpired(t *testing.T) {
+	jwt := &mock.JWT{
+		GenerateChallengeFn: func(gorsk.User) (string, error) {
+			return "mfachallenge", nil
+		},
+		GeneratePasswordChallengeFn: func(gorsk.User) (string, error) {
+			return "pwchallenge", nil
+		},
+	}
+	s := auth.New(nil, nil, nil, nil, loginDB, jwt, nil, nil, nil, nil, nil, auth.Config{})
+	token, err := s.Authorize(nil, gorsk.User{Base: gorsk.Base{ID: 1}, Active: true, MustChangePassword: true})
+	assert.Nil(t, err)
+	assert.Equal(t, gorsk.AuthToken{PasswordToken: "pwchallenge"}, token)
+
+	token, err = s.Authorize(nil, gorsk.User{Base: gorsk.Base{ID: 1}, Active: true, MustChangePassword: true, MFAEnabled: true})
+	assert.Nil(t, err)
+	assert.Equal(t, gorsk.AuthToken{MFAToken: "mfachallenge"}, token, "two-factor authentication is required before changing password")
+}
+
+func TestChangeExpiredPassword(t *testing.T) {
+	expired := gorsk.User{Base: gorsk.Base{ID: 1}, Password: "old", PasswordHistory: []string{"older"}, Active: true, MustChangePassword: true}
+	cases := []struct {
+		name     string
+		pass     string
+		user     gorsk.User
+		jwt      *mock.JWT
+		wantErr  error
+		wantData gorsk.AuthToken
+	}{
+		{
+			name:    "Fail on invalid challenge",
+			pass:    "new",
+			wantErr: auth.ErrInvalidPwChallenge,
+			jwt: &mock.JWT{
+				ParsePasswordChallengeFn: func(string) (int, error) {
+					return 0, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Fail on inactive user",
+			pass:    "new",
+			user:    gorsk.User{Base: gorsk.Base{ID: 1}},
+			wantErr: gorsk.ErrUnauthorized,
+		},
+		{
+			name:    "Fail on insecure password",
+			pass:    "weak",
+			user:    expired,
+			wantErr: gorsk.ErrInsecurePassword,
+		},
+		{
+			name:    "Fail on breached password",
+			pass:    "breached",
+			user:    expired,
+			wantErr: gorsk.ErrBreachedPassword,
+		},
+		{
+			name:    "Fail on expired password",
+			pass:    "old",
+			user:    expired,
+			wantErr: auth.ErrPasswordReused,
+		},
+		{
+			name:    "Fail on password in history",
+			pass:    "older",
+			user:    expired,
+			wantErr: auth.ErrPasswordReused,
+		},
+		{
+			name:     "Success",
+			pass:     "new",
+			user:     expired,
+			wantData: gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken.refreshtoken"},
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+			return s, nil
+		},
+	}
+	cdb := &mockdb.Company{
+		ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+			return gorsk.Company{}, pg.ErrNoRows
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(pass string, _ ...string) error {
+			switch pass {
+			case "weak":
+				return gorsk.ErrInsecurePassword
+			case "breached":
+				return gorsk.ErrBreachedPassword
+			}
+			return nil
+		},
+		HashMatchesPasswordFn: func(hash, pass string) bool {
+			return hash == pass
+		},
+		NeedsRehashFn: func(string) bool {
+			return false
+		},
+		HashFn: func(pass string) (string, error) {
+			return pass, nil
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated []gorsk.User
+			udb := &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return tt.user, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					updated = append(updated, u)
+					return nil
+				},
+			}
+			jwt := tt.jwt
+			if jwt == nil {
+				jwt = &mock.JWT{
+					ParsePasswordChallengeFn: func(string) (int, error) {
+						return 1, nil
+					},
+					GenerateTokenFn: func(gorsk.User, int) (string, error) {
+						return "jwttokenstring", nil
+					},
+				}
+			}
+			s := auth.New(nil, udb, sdb, cdb, loginDB, jwt, sec, nil, nil, nil, nil, auth.Config{
+				PasswordPolicy: gorsk.PasswordPolicy{History: 2},
+			})
+			token, err := s.ChangeExpiredPassword(nil, "pwchallenge", tt.pass)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantData.RefreshToken != "" {
+				tt.wantData.RefreshToken = token.RefreshToken
+			}
+			assert.Equal(t, tt.wantData, token)
+			if tt.wantErr == nil && assert.NotEmpty(t, updated) {
+				u := updated[0]
+				assert.Equal(t, "new", u.Password)
+				assert.Equal(t, []string{"old"}, u.PasswordHistory)
+				assert.False(t, u.MustChangePassword)
+			}
+		})
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	idp := mock.NewIdP(t, "gorsk", nil)
+	defer idp.Close()
+
+	s := auth.New(nil, nil, nil, nil, loginDB, nil, nil, nil, nil, nil, nil, auth.Config{
+		Providers: map[string]auth.Provider{
+			"corp": {IdP: oidc.New(oidc.Config{Issuer: idp.URL, ClientID: "gorsk"}, nil)},
+		},
+	})
+
+	_, err := s.OIDCLogin(nil, "unknown")
+	assert.Equal(t, auth.ErrUnknownProvider, err)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest("GET", "/login/oidc/corp", nil), rec)
+	url, err := s.OIDCLogin(c, "corp")
+	assert.Nil(t, err)
+	assert.Contains(t, url, idp.URL+"/authorize?")
+
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "oidc_state", cookies[0].Name)
+		assert.Contains(t, url, "state="+cookies[0].Value)
+		assert.Equal(t, "/login/oidc/corp", cookies[0].Path)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	}
+}
+
+func TestOIDCCallback(t *testing.T) {
+	verified := map[string]interface{}{
+		"email":          "johndoe@mail.com",
+		"email_verified": true,
+		"given_name":     "John",
+		"family_name":    "Doe",
+	}
+	cases := []struct {
+		name       string
+		provider   string
+		badState   bool
+		cookie     func(string) string
+		claims     map[string]interface{}
+		provision  bool
+		wantErr    error
+		wantUserID int
+		udb        *mockdb.User
+	}{
+		{
+			name:     "Fail on unknown provider",
+			provider: "unknown",
+			wantErr:  auth.ErrUnknownProvider,
+		},
+		{
+			name:     "Fail on missing state cookie",
+			provider: "corp",
+			claims:   verified,
+			cookie:   func(string) string { return "" },
+			wantErr:  auth.ErrProviderLogin,
+		},
+		{
+			name:     "Fail on state of another browser",
+			provider: "corp",
+			claims:   verified,
+			cookie:   func(string) string { return "attacker" },
+			wantErr:  auth.ErrProviderLogin,
+		},
+		{
+			name:     "Fail on exchange",
+			provider: "corp",
+			badState: true,
+			wantErr:  auth.ErrProviderLogin,
+		},
+		{
+			name:     "Fail on unverified email",
+			provider: "corp",
+			claims:   map[string]interface{}{"email": "johndoe@mail.com", "email_verified": false},
+			wantErr:  auth.ErrEmailNotVerified,
+		},
+		{
+			name:     "Fail on not linked identity",
+			provider: "corp",
+			claims:   verified,
+			wantErr:  auth.ErrIdentityNotLinked,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
-					return gorsk.User{
-						Username: user,
-						Password: "pass",
-						Active:   false,
-					}, nil
-				},
-			},
-			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
 				},
 			},
 		},
 		{
-			name:    "Fail on token generation",
-			args:    args{user: "juzernejm", pass: "pass"},
-			wantErr: true,
+			name:     "Fail on inactive user",
+			provider: "corp",
+			claims:   verified,
+			wantErr:  gorsk.ErrUnauthorized,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
-					return gorsk.User{
-						Username: user,
-						Password: "pass",
-						Active:   true,
-					}, nil
-				},
-			},
-			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
-				},
-			},
-			jwt: &mock.JWT{
-				GenerateTokenFn: func(u gorsk.User) (string, error) {
-					return "", gorsk.ErrGeneric
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}}, nil
 				},
 			},
 		},
 		{
-			name:    "Fail on updating last login",
-			args:    args{user: "juzernejm", pass: "pass"},
-			wantErr: true,
+			name:       "Success linking by email",
+			provider:   "corp",
+			claims:     verified,
+			wantUserID: 1,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
-					return gorsk.User{
-						Username: user,
-						Password: "pass",
-						Active:   true,
-					}, nil
+				FindByEmailFn: func(db orm.DB, email string) (gorsk.User, error) {
+					if email != "johndoe@mail.com" {
+						return gorsk.User{}, pg.ErrNoRows
+					}
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Active: true}, nil
 				},
 				UpdateFn: func(db orm.DB, u gorsk.User) error {
-					return gorsk.ErrGeneric
+					if !u.EmailVerified() {
+						t.Error("linked user's email was not verified")
+					}
+					return nil
 				},
 			},
-			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
-				},
-				TokenFn: func(string) string {
-					return "refreshtoken"
+		},
+		{
+			name:      "Fail on provisioning taken username",
+			provider:  "corp",
+			claims:    verified,
+			provision: true,
+			wantErr:   gorsk.ErrAccountExists,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
 				},
-			},
-			jwt: &mock.JWT{
-				GenerateTokenFn: func(u gorsk.User) (string, error) {
-					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				CreateFn: func(orm.DB, gorsk.User) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrAccountExists
 				},
 			},
 		},
 		{
-			name: "Success",
-			args: args{user: "juzernejm", pass: "pass"},
+			name:       "Success provisioning user",
+			provider:   "corp",
+			claims:     verified,
+			provision:  true,
+			wantUserID: 2,
 			udb: &mockdb.User{
-				FindByUsernameFn: func(db orm.DB, user string) (gorsk.User, error) {
-					return gorsk.User{
-						Username: user,
-						Password: "password",
-						Active:   true,
-					}, nil
-				},
-				UpdateFn: func(db orm.DB, u gorsk.User) error {
-					return nil
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
 				},
-			},
-			jwt: &mock.JWT{
-				GenerateTokenFn: func(u gorsk.User) (string, error) {
-					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+					if u.Email != "johndoe@mail.com" || u.FirstName != "John" || u.RoleID != gorsk.UserRole || u.CompanyID != 3 || !u.Active || !u.EmailVerified() {
+						t.Errorf("unexpected provisioned user: %+v", u)
+					}
+					u.ID = 2
+					return u, nil
 				},
-			},
-			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, Active: true}, nil
 				},
-				TokenFn: func(string) string {
-					return "refreshtoken"
+				UpdateFn: func(orm.DB, gorsk.User) error {
+					return nil
 				},
 			},
-			wantData: gorsk.AuthToken{
-				Token:        "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
-				RefreshToken: "refreshtoken",
-			},
 		},
 	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+			return s, nil
+		},
+	}
+	sec := &mock.Secure{}
+	log := &mock.Logger{
+		LogFn: func(echo.Context, string, string, error, map[string]interface{}) {},
+	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := auth.New(nil, tt.udb, tt.jwt, tt.sec, nil)
-			token, err := s.Authenticate(nil, tt.args.user, tt.args.pass)
-			if tt.wantData.RefreshToken != "" {
-				tt.wantData.RefreshToken = token.RefreshToken
-				assert.Equal(t, tt.wantData, token)
+			idp := mock.NewIdP(t, "gorsk", tt.claims)
+			defer idp.Close()
+
+			var userID int
+			jwt := &mock.JWT{
+				GenerateTokenFn: func(u gorsk.User, sessionID int) (string, error) {
+					userID = u.ID
+					return "jwttoken", nil
+				},
+			}
+			provider := oidc.New(oidc.Config{Issuer: idp.URL, ClientID: "gorsk", RedirectURL: "http://localhost/callback"}, nil)
+			s := auth.New(nil, tt.udb, sdb, nil, loginDB, jwt, sec, nil, nil, nil, log, auth.Config{
+				Providers: map[string]auth.Provider{
+					"corp": {IdP: provider, Provision: tt.provision, RoleID: gorsk.UserRole, CompanyID: 3, LocationID: 4},
+				},
+			})
+
+			authURL, _, err := provider.AuthCodeURL(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, code := idp.Authorize(t, authURL)
+			if tt.badState {
+				state = "invalid"
+			}
+
+			cookie := state
+			if tt.cookie != nil {
+				cookie = tt.cookie(state)
+			}
+			req := httptest.NewRequest("GET", "/login/oidc/corp/callback", nil)
+			if cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "oidc_state", Value: cookie})
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			token, err := s.OIDCCallback(c, tt.provider, state, code)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, "jwttoken", token.Token)
+				assert.Equal(t, tt.wantUserID, userID)
 			}
-			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
 }
+
 func TestRefresh(t *testing.T) {
 	type args struct {
 		c     echo.Context
@@ -180,68 +1401,255 @@ func TestRefresh(t *testing.T) {
 	cases := []struct {
 		name     string
 		args     args
-		wantData string
-		wantErr  bool
+		wantData gorsk.AuthToken
+		wantErr  error
 		udb      *mockdb.User
+		sdb      *mockdb.Session
 		jwt      *mock.JWT
+		sec      *mock.Secure
 	}{
 		{
 			name:    "Fail on finding token",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: gorsk.ErrGeneric,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Unknown token without family",
 			args:    args{token: "refreshtoken"},
-			wantErr: true,
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Unknown token of revoked session",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{}, pg.ErrNoRows
+				},
+				FindByFamilyFn: func(db orm.DB, family string) (gorsk.Session, error) {
+					return gorsk.Session{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Reused token revokes session",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{}, pg.ErrNoRows
+				},
+				FindByFamilyFn: func(db orm.DB, family string) (gorsk.Session, error) {
+					return gorsk.Session{
+						Base:   gorsk.Base{ID: 1},
+						Token:  "family.newrefreshtoken",
+						Family: family,
+					}, nil
+				},
+				DeleteFn: func(db orm.DB, s gorsk.Session) error {
+					if s.ID != 1 {
+						t.Error("session was not revoked")
+					}
+					return nil
+				},
+			},
+		},
+		{
+			name:    "Expired token",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{
+						UserID:         1,
+						Token:          token,
+						Family:         "family",
+						TokenExpiresAt: mock.TestTime(2000),
+					}, nil
+				},
+			},
+		},
+		{
+			name:    "Inactive user",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{UserID: 1, Token: token, Family: "family"}, nil
+				},
+			},
 			udb: &mockdb.User{
-				FindByTokenFn: func(db orm.DB, token string) (gorsk.User, error) {
-					return gorsk.User{}, gorsk.ErrGeneric
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Username: "username", Active: false}, nil
+				},
+			},
+		},
+		{
+			name:    "Password change required revokes session",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{Base: gorsk.Base{ID: 1}, UserID: 1, Token: token, Family: "family"}, nil
+				},
+				DeleteFn: func(db orm.DB, s gorsk.Session) error {
+					if s.ID != 1 {
+						t.Error("session was not revoked")
+					}
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Username: "username", Active: true, MustChangePassword: true}, nil
 				},
 			},
 		},
 		{
 			name:    "Fail on token generation",
-			args:    args{token: "refreshtoken"},
-			wantErr: true,
+			args:    args{token: "family.refreshtoken"},
+			wantErr: gorsk.ErrGeneric,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{UserID: 1, Token: token, Family: "family"}, nil
+				},
+			},
 			udb: &mockdb.User{
-				FindByTokenFn: func(db orm.DB, token string) (gorsk.User, error) {
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
 					return gorsk.User{
 						Username: "username",
 						Password: "password",
 						Active:   true,
-						Token:    token,
 					}, nil
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u gorsk.User) (string, error) {
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
 					return "", gorsk.ErrGeneric
 				},
 			},
 		},
+		{
+			name:    "Fail on update",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: gorsk.ErrGeneric,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{UserID: 1, Token: token, Family: "family"}, nil
+				},
+				RotateFn: func(db orm.DB, s gorsk.Session, old string) error {
+					return gorsk.ErrGeneric
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Username: "username", Active: true}, nil
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				},
+			},
+		},
+		{
+			name:    "Concurrently rotated token revokes session",
+			args:    args{token: "family.refreshtoken"},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					return gorsk.Session{Base: gorsk.Base{ID: 1}, UserID: 1, Token: token, Family: "family"}, nil
+				},
+				RotateFn: func(db orm.DB, s gorsk.Session, old string) error {
+					return pg.ErrNoRows
+				},
+				FindByFamilyFn: func(db orm.DB, family string) (gorsk.Session, error) {
+					return gorsk.Session{Base: gorsk.Base{ID: 1}, Family: family}, nil
+				},
+				DeleteFn: func(db orm.DB, s gorsk.Session) error {
+					if s.ID != 1 {
+						t.Error("session was not revoked")
+					}
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Username: "username", Active: true}, nil
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
+				},
+			},
+		},
 		{
 			name: "Success",
-			args: args{token: "refreshtoken"},
+			args: args{token: "family.refreshtoken"},
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+					if token != secure.HashToken("family.refreshtoken") {
+						t.Error("session was not looked up by refresh token hash")
+					}
+					return gorsk.Session{
+						UserID:         1,
+						Token:          token,
+						Family:         "family",
+						TokenExpiresAt: mock.TestTime(2100),
+						ExpiresAt:      mock.TestTime(2100),
+					}, nil
+				},
+				RotateFn: func(db orm.DB, s gorsk.Session, old string) error {
+					if old != secure.HashToken("family.refreshtoken") {
+						t.Error("session was not rotated conditionally on the presented token")
+					}
+					if s.LastUsedAt.IsZero() {
+						t.Error("session last used time was not updated")
+					}
+					return nil
+				},
+			},
 			udb: &mockdb.User{
-				FindByTokenFn: func(db orm.DB, token string) (gorsk.User, error) {
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
 					return gorsk.User{
 						Username: "username",
 						Password: "password",
 						Active:   true,
-						Token:    token,
 					}, nil
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u gorsk.User) (string, error) {
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
 					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", nil
 				},
 			},
-			wantData: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+			wantData: gorsk.AuthToken{
+				Token:        "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+				RefreshToken: "family.",
+			},
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := auth.New(nil, tt.udb, tt.jwt, nil, nil)
+			s := auth.New(nil, tt.udb, tt.sdb, nil, loginDB, tt.jwt, tt.sec, nil, nil, nil, nil, auth.Config{RefreshDuration: time.Hour})
 			token, err := s.Refresh(tt.args.c, tt.args.token)
+			if tt.wantData.RefreshToken != "" {
+				assert.True(t, strings.HasPrefix(token.RefreshToken, tt.wantData.RefreshToken))
+				tt.wantData.RefreshToken = token.RefreshToken
+			}
 			assert.Equal(t, tt.wantData, token)
-			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, err)
 		})
 	}
 }
@@ -293,10 +1701,109 @@ func TestMe(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := auth.New(nil, tt.udb, nil, nil, tt.rbac)
+			s := auth.New(nil, tt.udb, nil, nil, loginDB, nil, nil, tt.rbac, nil, nil, nil, auth.Config{})
 			user, err := s.Me(nil)
 			assert.Equal(t, tt.wantData, user)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
 }
+
+func TestLogout(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr error
+		sdb     *mockdb.Session
+		rbac    *mock.RBAC
+		dl      *denylist.Memory
+		wantJTI string
+		wantLog string
+	}{
+		{
+			name:    "Fail on revoking session",
+			wantErr: gorsk.ErrGeneric,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, SessionID: 2, TokenID: "jti"}
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteFn: func(orm.DB, gorsk.Session) error {
+					return gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Token without session and ID",
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				},
+			},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, SessionID: 2, TokenID: "jti", TokenExpiresAt: time.Now().Add(time.Minute)}
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteFn: func(db orm.DB, s gorsk.Session) error {
+					if s.ID != 2 {
+						t.Error("current session was not revoked")
+					}
+					return nil
+				},
+			},
+			dl:      denylist.NewMemory(),
+			wantJTI: "jti",
+		},
+		{
+			name: "Success stopping impersonation",
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 2, TokenID: "jti", TokenExpiresAt: time.Now().Add(time.Minute), ActorID: 1}
+				},
+			},
+			dl:      denylist.NewMemory(),
+			wantJTI: "jti",
+			wantLog: "Impersonation stopped",
+		},
+		{
+			name: "Expired token accepted within clock skew",
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, TokenID: "jti", TokenExpiresAt: time.Now().Add(-time.Second)}
+				},
+			},
+			dl:      denylist.NewMemory(),
+			wantJTI: "jti",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged string
+			log := &mock.Logger{
+				LogFn: func(c echo.Context, source, msg string, err error, params map[string]interface{}) {
+					logged = msg
+					assert.Equal(t, map[string]interface{}{"user_id": 2, "actor_id": 1}, params)
+				},
+			}
+			s := auth.New(nil, nil, tt.sdb, nil, loginDB, nil, nil, tt.rbac, tt.dl, nil, log, auth.Config{ClockSkew: time.Minute})
+			err := s.Logout(nil)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantLog, logged)
+			if tt.wantJTI != "" {
+				revoked, _ := tt.dl.Contains(tt.wantJTI)
+				assert.True(t, revoked)
+			}
+		})
+	}
+}
+
+var loginDB = &mockdb.LoginEvent{
+	CreateFn: func(db orm.DB, e gorsk.LoginEvent) (gorsk.LoginEvent, error) {
+		return e, nil
+	},
+}
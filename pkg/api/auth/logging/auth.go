@@ -41,14 +41,12 @@ func (ls *LogService) Authenticate(c echo.Context, user, password string) (resp
 }
 
 // Refresh logging
-func (ls *LogService) Refresh(c echo.Context, req string) (token string, err error) {
+func (ls *LogService) Refresh(c echo.Context, req string) (resp gorsk.AuthToken, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
 			c,
 			name, "Refresh request", err,
 			map[string]interface{}{
-				"req":  req,
-				"resp": token,
 				"took": time.Since(begin),
 			},
 		)
@@ -70,3 +68,89 @@ func (ls *LogService) Me(c echo.Context) (resp gorsk.User, err error) {
 	}(time.Now())
 	return ls.Service.Me(c)
 }
+
+// Logout logging
+func (ls *LogService) Logout(c echo.Context) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Logout request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Logout(c)
+}
+
+// VerifyMFA logging
+func (ls *LogService) VerifyMFA(c echo.Context, challenge, code string) (resp gorsk.AuthToken, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Verify MFA request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.VerifyMFA(c, challenge, code)
+}
+
+// ChangeExpiredPassword logging
+func (ls *LogService) ChangeExpiredPassword(c echo.Context, challenge, pass string) (resp gorsk.AuthToken, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Change expired password request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.ChangeExpiredPassword(c, challenge, pass)
+}
+
+// EnrollMFA logging
+func (ls *LogService) EnrollMFA(c echo.Context, challenge string) (resp gorsk.MFAEnrollment, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Enroll MFA request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.EnrollMFA(c, challenge)
+}
+
+// OIDCLogin logging
+func (ls *LogService) OIDCLogin(c echo.Context, provider string) (resp string, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "OIDC login request", err,
+			map[string]interface{}{
+				"req":  provider,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.OIDCLogin(c, provider)
+}
+
+// OIDCCallback logging
+func (ls *LogService) OIDCCallback(c echo.Context, provider, state, code string) (resp gorsk.AuthToken, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "OIDC callback request", err,
+			map[string]interface{}{
+				"req":  provider,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.OIDCCallback(c, provider, state, code)
+}
@@ -0,0 +1,17 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Company represents the client for company table
+type Company struct{}
+
+// View returns single company by ID
+func (c Company) View(db orm.DB, id int) (gorsk.Company, error) {
+	company := gorsk.Company{Base: gorsk.Base{ID: id}}
+	err := db.Model(&company).WherePK().Select()
+	return company, err
+}
@@ -0,0 +1,61 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/ribice/gorsk/pkg/api/auth/platform/pgsql"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyView(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		id       int
+		wantData gorsk.Company
+	}{
+		{
+			name:    "Company does not exist",
+			wantErr: true,
+			id:      1000,
+		},
+		{
+			name: "Success",
+			id:   1,
+			wantData: gorsk.Company{
+				Name:       "admin_company",
+				Active:     true,
+				RequireMFA: true,
+				Base: gorsk.Base{
+					ID: 1,
+				},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	if err := mock.InsertMultiple(db, &cases[1].wantData); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.Company{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			company, err := cdb.View(db, tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData.ID != 0 {
+				assert.Equal(t, tt.wantData.Name, company.Name)
+				assert.Equal(t, tt.wantData.RequireMFA, company.RequireMFA)
+			}
+		})
+	}
+}
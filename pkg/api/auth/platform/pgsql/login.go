@@ -0,0 +1,29 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// LoginEvent represents the client for login_event table
+type LoginEvent struct{}
+
+// Create records a login attempt
+func (l LoginEvent) Create(db orm.DB, e gorsk.LoginEvent) (gorsk.LoginEvent, error) {
+	err := db.Insert(&e)
+	return e, err
+}
+
+// Origin checks whether the user has logged in successfully before, and from the same device and IP address
+func (l LoginEvent) Origin(db orm.DB, userID int, device, ip string) (gorsk.LoginOrigin, error) {
+	var o gorsk.LoginOrigin
+	err := db.Model((*gorsk.LoginEvent)(nil)).
+		ColumnExpr("count(*) > 0").
+		ColumnExpr("coalesce(bool_or(device = ?), false)", device).
+		ColumnExpr("coalesce(bool_or(ip = ?), false)", ip).
+		Where("user_id = ?", userID).
+		Where("success").
+		Select(&o.Returning, &o.KnownDevice, &o.KnownIP)
+	return o, err
+}
@@ -0,0 +1,71 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/ribice/gorsk/pkg/api/auth/platform/pgsql"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginEventOrigin(t *testing.T) {
+	cases := []struct {
+		name     string
+		userID   int
+		device   string
+		ip       string
+		wantData gorsk.LoginOrigin
+	}{
+		{
+			name:   "First login",
+			userID: 2,
+			device: "Mac",
+			ip:     "127.0.0.1",
+		},
+		{
+			name:     "Known device and IP",
+			userID:   1,
+			device:   "Mac",
+			ip:       "127.0.0.1",
+			wantData: gorsk.LoginOrigin{Returning: true, KnownDevice: true, KnownIP: true},
+		},
+		{
+			name:     "New device",
+			userID:   1,
+			device:   "iPhone",
+			ip:       "127.0.0.1",
+			wantData: gorsk.LoginOrigin{Returning: true, KnownIP: true},
+		},
+		{
+			name:     "Failed logins are not considered",
+			userID:   1,
+			device:   "Windows",
+			ip:       "10.0.0.1",
+			wantData: gorsk.LoginOrigin{Returning: true},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.LoginEvent{})
+
+	if err := mock.InsertMultiple(db,
+		&gorsk.LoginEvent{UserID: 1, Device: "Mac", IP: "127.0.0.1", Success: true},
+		&gorsk.LoginEvent{UserID: 1, Device: "Windows", IP: "10.0.0.1", Reason: gorsk.LoginInvalidPassword},
+		&gorsk.LoginEvent{UserID: 2, Device: "Mac", IP: "127.0.0.1", Reason: gorsk.LoginInvalidPassword}); err != nil {
+		t.Error(err)
+	}
+
+	ldb := pgsql.LoginEvent{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := ldb.Origin(db, tt.userID, tt.device, tt.ip)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantData, resp)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Session represents the client for session table
+type Session struct{}
+
+// Create creates a new session
+func (s Session) Create(db orm.DB, sess gorsk.Session) (gorsk.Session, error) {
+	err := db.Insert(&sess)
+	return sess, err
+}
+
+// FindByToken queries for single active session by refresh token hash
+func (s Session) FindByToken(db orm.DB, token string) (gorsk.Session, error) {
+	var sess gorsk.Session
+	err := db.Model(&sess).Where("token = ?", token).Select()
+	return sess, err
+}
+
+// FindByFamily queries for single active session by refresh token family
+func (s Session) FindByFamily(db orm.DB, family string) (gorsk.Session, error) {
+	var sess gorsk.Session
+	err := db.Model(&sess).Where("family = ?", family).Select()
+	return sess, err
+}
+
+// Rotate stores session's new refresh token, provided the session still holds the old one.
+// pg.ErrNoRows is returned when the token was rotated concurrently.
+func (s Session) Rotate(db orm.DB, sess gorsk.Session, old string) error {
+	res, err := db.Model(&sess).
+		Column("token", "token_expires_at", "last_used_at", "updated_at").
+		WherePK().Where("token = ?", old).
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
+
+// Delete revokes a session
+func (s Session) Delete(db orm.DB, sess gorsk.Session) error {
+	return db.Delete(&sess)
+}
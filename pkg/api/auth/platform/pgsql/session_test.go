@@ -0,0 +1,243 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/ribice/gorsk/pkg/api/auth/platform/pgsql"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		req      gorsk.Session
+		wantData gorsk.Session
+	}{
+		{
+			name:    "Fail on insert duplicate ID",
+			wantErr: true,
+			req: gorsk.Session{
+				Base:   gorsk.Base{ID: 1},
+				UserID: 1,
+				Token:  "family.token",
+				Family: "family",
+			},
+		},
+		{
+			name: "Success",
+			req: gorsk.Session{
+				Base:      gorsk.Base{ID: 2},
+				UserID:    1,
+				Device:    "Mac",
+				UserAgent: "Mozilla/5.0 (Macintosh)",
+				IP:        "127.0.0.1",
+				Token:     "newfamily.token",
+				Family:    "newfamily",
+			},
+			wantData: gorsk.Session{
+				Base:      gorsk.Base{ID: 2},
+				UserID:    1,
+				Device:    "Mac",
+				UserAgent: "Mozilla/5.0 (Macintosh)",
+				IP:        "127.0.0.1",
+				Token:     "newfamily.token",
+				Family:    "newfamily",
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	if err := mock.InsertMultiple(db, &cases[0].req); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := sdb.Create(db, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData.ID != 0 {
+				tt.wantData.CreatedAt = resp.CreatedAt
+				tt.wantData.UpdatedAt = resp.UpdatedAt
+				assert.Equal(t, tt.wantData, resp)
+			}
+		})
+	}
+}
+
+func TestSessionFindByToken(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		token    string
+		wantData gorsk.Session
+	}{
+		{
+			name:    "Session does not exist",
+			wantErr: true,
+			token:   "notExists",
+		},
+		{
+			name:  "Success",
+			token: "family.token",
+			wantData: gorsk.Session{
+				Base:   gorsk.Base{ID: 1},
+				UserID: 1,
+				Token:  "family.token",
+				Family: "family",
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	if err := mock.InsertMultiple(db, &cases[1].wantData); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := sdb.FindByToken(db, tt.token)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData.ID != 0 {
+				tt.wantData.CreatedAt = sess.CreatedAt
+				tt.wantData.UpdatedAt = sess.UpdatedAt
+				assert.Equal(t, tt.wantData, sess)
+			}
+		})
+	}
+}
+
+func TestSessionFindByFamily(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		family   string
+		wantData gorsk.Session
+	}{
+		{
+			name:    "Session does not exist",
+			wantErr: true,
+			family:  "notExists",
+		},
+		{
+			name:   "Success",
+			family: "family",
+			wantData: gorsk.Session{
+				Base:   gorsk.Base{ID: 1},
+				UserID: 1,
+				Token:  "family.token",
+				Family: "family",
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	if err := mock.InsertMultiple(db, &cases[1].wantData); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := sdb.FindByFamily(db, tt.family)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData.ID != 0 {
+				tt.wantData.CreatedAt = sess.CreatedAt
+				tt.wantData.UpdatedAt = sess.UpdatedAt
+				assert.Equal(t, tt.wantData, sess)
+			}
+		})
+	}
+}
+
+func TestSessionRotate(t *testing.T) {
+	cases := []struct {
+		name    string
+		old     string
+		wantErr error
+	}{
+		{
+			name:    "Fail on token rotated concurrently",
+			old:     "family.stale",
+			wantErr: pg.ErrNoRows,
+		},
+		{
+			name: "Success",
+			old:  "family.token",
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	sess := &gorsk.Session{
+		Base:   gorsk.Base{ID: 1},
+		UserID: 1,
+		Token:  "family.token",
+		Family: "family",
+	}
+	if err := mock.InsertMultiple(db, sess); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rotated := *sess
+			rotated.Token = "family.rotated"
+			err := sdb.Rotate(db, rotated, tt.old)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				s, err := sdb.FindByToken(db, "family.rotated")
+				assert.Nil(t, err)
+				assert.Equal(t, 1, s.ID)
+			}
+		})
+	}
+}
+
+func TestSessionDelete(t *testing.T) {
+	sess := &gorsk.Session{
+		Base:   gorsk.Base{ID: 1},
+		UserID: 1,
+		Token:  "family.token",
+		Family: "family",
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	if err := mock.InsertMultiple(db, sess); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+	assert.Nil(t, sdb.Delete(db, *sess))
+
+	_, err := sdb.FindByToken(db, sess.Token)
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,10 @@
 package pgsql
 
 import (
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 
 	"github.com/ribice/gorsk"
@@ -29,16 +33,67 @@ func (u User) FindByUsername(db orm.DB, uname string) (gorsk.User, error) {
 	return user, err
 }
 
-// FindByToken queries for single user by token
-func (u User) FindByToken(db orm.DB, token string) (gorsk.User, error) {
+// FindByEmail queries for single user by email, case insensitive
+func (u User) FindByEmail(db orm.DB, email string) (gorsk.User, error) {
 	var user gorsk.User
 	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
 	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
-	WHERE ("user"."token" = ? and deleted_at is null)`
-	_, err := db.QueryOne(&user, sql, token)
+	WHERE (lower("user"."email") = lower(?) and deleted_at is null)`
+	_, err := db.QueryOne(&user, sql, email)
+	return user, err
+}
+
+// Create creates a new user, unless username or email is already taken
+func (u User) Create(db orm.DB, user gorsk.User) (gorsk.User, error) {
+	var existing = new(gorsk.User)
+	err := db.Model(existing).Where("(lower(username) = ? or lower(email) = ?) and deleted_at is null",
+		strings.ToLower(user.Username), strings.ToLower(user.Email)).First()
+	if err != pg.ErrNoRows {
+		if err == nil {
+			err = gorsk.ErrAccountExists
+		}
+		return gorsk.User{}, err
+	}
+
+	err = db.Insert(&user)
 	return user, err
 }
 
+// LoginFailed atomically increments user's consecutive failed logins, starting over if the last failure
+// happened before since, and returns the new count. Only failed login columns are written, so concurrent
+// attempts are all counted and concurrent changes of other columns are kept.
+func (u User) LoginFailed(db orm.DB, id int, at, since time.Time) (int, error) {
+	var failures int
+	sql := `UPDATE "users" SET 
+	"failed_logins" = CASE WHEN "last_failed_login" IS NULL OR "last_failed_login" < ? THEN 1 ELSE COALESCE("failed_logins", 0) + 1 END, 
+	"last_failed_login" = ? 
+	WHERE ("id" = ?) RETURNING "failed_logins"`
+	_, err := db.QueryOne(pg.Scan(&failures), sql, since, at, id)
+	return failures, err
+}
+
+// UseMFAStep records the time step of TOTP code used by the user, unless the same or a later step was
+// already used. It returns false in that case, so each code is accepted only once, even by concurrent requests.
+func (u User) UseMFAStep(db orm.DB, id int, step int64) (bool, error) {
+	res, err := db.Exec(`UPDATE "users" SET "mfa_last_step" = ? 
+	WHERE ("id" = ?) AND ("mfa_last_step" IS NULL OR "mfa_last_step" < ?)`, step, id, step)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode removes the recovery code with given hash from user's recovery codes. It returns false
+// if the user has no such recovery code, so each code is accepted only once, even by concurrent requests.
+func (u User) UseRecoveryCode(db orm.DB, id int, hash string) (bool, error) {
+	res, err := db.Exec(`UPDATE "users" SET "mfa_recovery_codes" = array_remove("mfa_recovery_codes", ?) 
+	WHERE ("id" = ?) AND (? = ANY("mfa_recovery_codes"))`, hash, id, hash)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
+
 // Update updates user's info
 func (u User) Update(db orm.DB, user gorsk.User) error {
 	return db.Update(&user)
@@ -2,6 +2,7 @@ package pgsql_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/utl/mock"
@@ -143,39 +144,38 @@ func TestFindByUsername(t *testing.T) {
 	}
 }
 
-func TestFindByToken(t *testing.T) {
+func TestFindByEmail(t *testing.T) {
 	cases := []struct {
 		name     string
 		wantErr  bool
-		token    string
+		email    string
 		wantData gorsk.User
 	}{
 		{
 			name:    "User does not exist",
 			wantErr: true,
-			token:   "notExists",
+			email:   "notexists@mail.com",
 		},
 		{
 			name:  "Success",
-			token: "loginrefresh",
+			email: "TomJones@mail.com",
 			wantData: gorsk.User{
-				Email:      "johndoe@mail.com",
-				FirstName:  "John",
-				LastName:   "Doe",
-				Username:   "johndoe",
+				Email:      "tomjones@mail.com",
+				FirstName:  "Tom",
+				LastName:   "Jones",
+				Username:   "tomjones",
 				RoleID:     1,
 				CompanyID:  1,
 				LocationID: 1,
-				Password:   "hunter2",
+				Password:   "newPass",
 				Base: gorsk.Base{
-					ID: 1,
+					ID: 2,
 				},
 				Role: &gorsk.Role{
 					ID:          1,
 					AccessLevel: 1,
 					Name:        "SUPER_ADMIN",
 				},
-				Token: "loginrefresh",
 			},
 		},
 	}
@@ -196,8 +196,9 @@ func TestFindByToken(t *testing.T) {
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := udb.FindByToken(db, tt.token)
+			user, err := udb.FindByEmail(db, tt.email)
 			assert.Equal(t, tt.wantErr, err != nil)
+
 			if tt.wantData.ID != 0 {
 				tt.wantData.CreatedAt = user.CreatedAt
 				tt.wantData.UpdatedAt = user.UpdatedAt
@@ -283,3 +284,164 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginFailed(t *testing.T) {
+	now := time.Now().Round(time.Millisecond)
+	cases := []struct {
+		name  string
+		user  gorsk.User
+		since time.Time
+		want  int
+	}{
+		{
+			name: "First failure",
+			user: gorsk.User{Base: gorsk.Base{ID: 1}, Username: "first", Email: "first@mail.com"},
+			want: 1,
+		},
+		{
+			name: "Consecutive failure",
+			user: gorsk.User{Base: gorsk.Base{ID: 2}, Username: "consecutive", Email: "consecutive@mail.com",
+				FailedLogins: 2, LastFailedLogin: now.Add(-time.Second)},
+			since: now.Add(-time.Minute),
+			want:  3,
+		},
+		{
+			name: "Failures older than lockout are forgotten",
+			user: gorsk.User{Base: gorsk.Base{ID: 3}, Username: "forgotten", Email: "forgotten@mail.com",
+				FailedLogins: 5, LastFailedLogin: now.Add(-time.Hour)},
+			since: now.Add(-time.Minute),
+			want:  1,
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	udb := pgsql.User{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := db.Insert(&tt.user); err != nil {
+				t.Fatal(err)
+			}
+			failures, err := udb.LoginFailed(db, tt.user.ID, now, tt.since)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, failures)
+
+			user := gorsk.User{Base: gorsk.Base{ID: tt.user.ID}}
+			if err := db.Select(&user); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, user.FailedLogins)
+			assert.True(t, now.Equal(user.LastFailedLogin))
+			assert.Equal(t, tt.user.Username, user.Username)
+		})
+	}
+}
+
+func TestUseMFAStep(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := db.Insert(&gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Email: "johndoe@mail.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	udb := pgsql.User{}
+
+	cases := []struct {
+		name string
+		step int64
+		want bool
+	}{
+		{name: "First code", step: 100, want: true},
+		{name: "Replayed code", step: 100},
+		{name: "Earlier code", step: 99},
+		{name: "Later code", step: 101, want: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := udb.UseMFAStep(db, 1, tt.step)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := db.Insert(&gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Email: "johndoe@mail.com",
+		MFARecoveryCodes: []string{"hash1", "hash2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	udb := pgsql.User{}
+
+	cases := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{name: "Unknown code", hash: "hash3"},
+		{name: "Success", hash: "hash1", want: true},
+		{name: "Used code", hash: "hash1"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := udb.UseRecoveryCode(db, 1, tt.hash)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+
+	user := gorsk.User{Base: gorsk.Base{ID: 1}}
+	if err := db.Select(&user); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"hash2"}, user.MFARecoveryCodes)
+}
+
+func TestCreate(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	udb := pgsql.User{}
+
+	user, err := udb.Create(db, gorsk.User{
+		Email:      "johndoe@mail.com",
+		Username:   "johndoe@mail.com",
+		Active:     true,
+		RoleID:     200,
+		CompanyID:  1,
+		LocationID: 1,
+	})
+	assert.Nil(t, err)
+	assert.NotEqual(t, 0, user.ID)
+}
+
+func TestCreateTaken(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := mock.InsertMultiple(db, &gorsk.User{Email: "other@mail.com", Username: "JohnDoe@mail.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := pgsql.User{}.Create(db, gorsk.User{
+		Email:    "johndoe@mail.com",
+		Username: "johndoe@mail.com",
+	})
+	assert.Equal(t, gorsk.ErrAccountExists, err)
+}
@@ -1,63 +1,175 @@
 package auth
 
 import (
+	"context"
+	"time"
+
 	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/auth/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+	"github.com/ribice/gorsk/pkg/utl/oidc"
 )
 
 // New creates new iam service
-func New(db *pg.DB, udb UserDB, j TokenGenerator, sec Securer, rbac RBAC) Auth {
+func New(db *pg.DB, udb UserDB, sdb SessionDB, cdb CompanyDB, ldb LoginEventDB, j TokenGenerator, sec Securer, rbac RBAC, dl Denylist, lim Limiter, log gorsk.Logger, cfg Config) Auth {
 	return Auth{
 		db:   db,
 		udb:  udb,
+		sdb:  sdb,
+		cdb:  cdb,
+		ldb:  ldb,
 		tg:   j,
 		sec:  sec,
 		rbac: rbac,
+		dl:   dl,
+		lim:  lim,
+		log:  log,
+		cfg:  cfg,
 	}
 }
 
 // Initialize initializes auth application service
-func Initialize(db *pg.DB, j TokenGenerator, sec Securer, rbac RBAC) Auth {
-	return New(db, pgsql.User{}, j, sec, rbac)
+func Initialize(db *pg.DB, j TokenGenerator, sec Securer, rbac RBAC, dl Denylist, lim Limiter, log gorsk.Logger, cfg Config) Auth {
+	return New(db, pgsql.User{}, pgsql.Session{}, pgsql.Company{}, pgsql.LoginEvent{}, j, sec, rbac, dl, lim, log, cfg)
+}
+
+// Config holds auth service settings
+type Config struct {
+	// ClockSkew is the leeway allowed when validating jwt tokens, for which denylisted tokens are kept past their expiry.
+	ClockSkew time.Duration
+	// RefreshDuration is the lifetime of a single refresh token. Zero means no limit.
+	RefreshDuration time.Duration
+	// MaxRefresh is the lifetime of a session, counted from login. Zero means no limit.
+	MaxRefresh time.Duration
+	// Lockout is the brute-force protection policy applied to failed logins per user account.
+	Lockout lockout.Policy
+	// MFAIssuer is the issuer name shown in authenticator apps.
+	MFAIssuer string
+	// RequireVerifiedEmail blocks login of users whose email address is not verified.
+	RequireVerifiedEmail bool
+	// PasswordPolicy is the default password policy, overridable per company.
+	PasswordPolicy gorsk.PasswordPolicy
+	// Providers are OpenID Connect identity providers users can log in with, by name.
+	Providers map[string]Provider
+	// Notifier notifies users about logins from new devices or IP addresses. Nil disables notifications.
+	Notifier Notifier
+}
+
+// Provider holds OpenID Connect identity provider with settings for users it provisions
+type Provider struct {
+	IdP IdentityProvider
+	// Provision enables creating users not linked by email, with given role, company and location.
+	Provision  bool
+	RoleID     gorsk.AccessRole
+	CompanyID  int
+	LocationID int
 }
 
 // Service represents auth service interface
 type Service interface {
 	Authenticate(echo.Context, string, string) (gorsk.AuthToken, error)
-	Refresh(echo.Context, string) (string, error)
+	Refresh(echo.Context, string) (gorsk.AuthToken, error)
 	Me(echo.Context) (gorsk.User, error)
+	Logout(echo.Context) error
+	VerifyMFA(echo.Context, string, string) (gorsk.AuthToken, error)
+	EnrollMFA(echo.Context, string) (gorsk.MFAEnrollment, error)
+	ChangeExpiredPassword(echo.Context, string, string) (gorsk.AuthToken, error)
+	OIDCLogin(echo.Context, string) (string, error)
+	OIDCCallback(echo.Context, string, string, string) (gorsk.AuthToken, error)
+	JWKS(echo.Context) gorsk.JWKS
 }
 
 // Auth represents auth application service
 type Auth struct {
 	db   *pg.DB
 	udb  UserDB
+	sdb  SessionDB
+	cdb  CompanyDB
+	ldb  LoginEventDB
 	tg   TokenGenerator
 	sec  Securer
 	rbac RBAC
+	dl   Denylist
+	lim  Limiter
+	log  gorsk.Logger
+	cfg  Config
 }
 
 // UserDB represents user repository interface
 type UserDB interface {
 	View(orm.DB, int) (gorsk.User, error)
 	FindByUsername(orm.DB, string) (gorsk.User, error)
-	FindByToken(orm.DB, string) (gorsk.User, error)
+	FindByEmail(orm.DB, string) (gorsk.User, error)
+	Create(orm.DB, gorsk.User) (gorsk.User, error)
 	Update(orm.DB, gorsk.User) error
+	LoginFailed(orm.DB, int, time.Time, time.Time) (int, error)
+	UseMFAStep(orm.DB, int, int64) (bool, error)
+	UseRecoveryCode(orm.DB, int, string) (bool, error)
+}
+
+// SessionDB represents session repository interface
+type SessionDB interface {
+	Create(orm.DB, gorsk.Session) (gorsk.Session, error)
+	FindByToken(orm.DB, string) (gorsk.Session, error)
+	FindByFamily(orm.DB, string) (gorsk.Session, error)
+	Rotate(orm.DB, gorsk.Session, string) error
+	Delete(orm.DB, gorsk.Session) error
+}
+
+// CompanyDB represents company repository interface
+type CompanyDB interface {
+	View(orm.DB, int) (gorsk.Company, error)
+}
+
+// LoginEventDB represents login event repository interface
+type LoginEventDB interface {
+	Create(orm.DB, gorsk.LoginEvent) (gorsk.LoginEvent, error)
+	Origin(orm.DB, int, string, string) (gorsk.LoginOrigin, error)
 }
 
 // TokenGenerator represents token generator (jwt) interface
 type TokenGenerator interface {
-	GenerateToken(gorsk.User) (string, error)
+	GenerateToken(gorsk.User, int) (string, error)
+	GenerateChallenge(gorsk.User) (string, error)
+	ParseChallenge(string) (int, error)
+	GeneratePasswordChallenge(gorsk.User) (string, error)
+	ParsePasswordChallenge(string) (int, error)
+	JWKS() gorsk.JWKS
+}
+
+// Denylist represents storage of revoked token IDs
+type Denylist interface {
+	Add(string, time.Time) error
+}
+
+// Limiter represents tracker of failed login attempts per client IP address
+type Limiter interface {
+	Wait(string) time.Duration
+	Fail(string) int
+	Locked(string) bool
+}
+
+// Notifier represents notification delivery interface
+type Notifier interface {
+	NewLogin(gorsk.User, gorsk.LoginEvent) error
+}
+
+// IdentityProvider represents OpenID Connect identity provider interface
+type IdentityProvider interface {
+	AuthCodeURL(context.Context) (string, string, error)
+	Exchange(context.Context, string, string) (oidc.Identity, error)
 }
 
 // Securer represents security interface
 type Securer interface {
+	Hash(string) (string, error)
 	HashMatchesPassword(string, string) bool
-	Token(string) string
+	NeedsRehash(string) bool
+	Password(string, ...string) error
 }
 
 // RBAC represents role-based-access-control interface
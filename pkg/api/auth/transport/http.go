@@ -3,19 +3,23 @@ package transport
 import (
 	"net/http"
 
+	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/auth"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
 
 	"github.com/labstack/echo"
 )
 
 // HTTP represents auth http service
 type HTTP struct {
-	svc auth.Service
+	svc     auth.Service
+	cookies *cookie.Jar
 }
 
-// NewHTTP creates new auth http service
-func NewHTTP(svc auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
-	h := HTTP{svc}
+// NewHTTP creates new auth http service. Given a cookie jar, access and refresh tokens
+// are set as cookies instead of being returned in response body.
+func NewHTTP(svc auth.Service, e *echo.Echo, mw echo.MiddlewareFunc, cookies *cookie.Jar) {
+	h := HTTP{svc, cookies}
 	// swagger:route POST /login auth login
 	// Logs in user by username and password.
 	// responses:
@@ -26,26 +30,100 @@ func NewHTTP(svc auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
 	//  404: errMsg
 	//  500: err
 	e.POST("/login", h.login)
-	// swagger:operation GET /refresh/{token} auth refresh
+
+	// swagger:route POST /login/mfa auth loginMFA
+	// Exchanges two-factor authentication challenge token and code for auth tokens.
+	// The code is either a TOTP code or one of the recovery codes.
+	// responses:
+	//  200: loginResp
+	//  400: errMsg
+	//  401: errMsg
+	//  429: errMsg
+	//  500: err
+	e.POST("/login/mfa", h.loginMFA)
+
+	// swagger:route POST /login/mfa/enroll auth loginMFAEnroll
+	// Enrolls two-factor authentication for users whose company requires it.
+	// Enrollment is confirmed by logging in with the first code.
+	// responses:
+	//  200: mfaEnrollResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	e.POST("/login/mfa/enroll", h.enrollMFA)
+
+	// swagger:route POST /login/password auth loginPassword
+	// Changes expired password using the password change challenge token, and logs the user in.
+	// responses:
+	//  200: loginResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	e.POST("/login/password", h.loginPassword)
+
+	// swagger:operation GET /login/oidc/{provider} auth loginOIDC
 	// ---
-	// summary: Refreshes jwt token.
-	// description: Refreshes jwt token by checking at database whether refresh token exists.
+	// summary: Starts login with OpenID Connect identity provider.
+	// description: Redirects to identity provider's authorization endpoint, using authorization code flow with PKCE.
 	// parameters:
-	// - name: token
+	// - name: provider
 	//   in: path
-	//   description: refresh token
+	//   description: identity provider name
 	//   type: string
 	//   required: true
 	// responses:
-	//   "200":
-	//     "$ref": "#/responses/refreshResp"
-	//   "400":
+	//   "302":
+	//     description: Redirect to identity provider
+	//   "404":
 	//     "$ref": "#/responses/errMsg"
-	//   "401":
+	//   "500":
 	//     "$ref": "#/responses/err"
+	e.GET("/login/oidc/:provider", h.loginOIDC)
+
+	// swagger:operation GET /login/oidc/{provider}/callback auth loginOIDCCallback
+	// ---
+	// summary: Completes login with OpenID Connect identity provider.
+	// description: Exchanges authorization code for identity, linked to the user by verified email. Returns auth tokens, or a two-factor authentication challenge.
+	// parameters:
+	// - name: provider
+	//   in: path
+	//   description: identity provider name
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: state returned by identity provider
+	//   type: string
+	//   required: true
+	// - name: code
+	//   in: query
+	//   description: authorization code returned by identity provider
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/loginResp"
+	//   "401":
+	//     "$ref": "#/responses/errMsg"
+	//   "403":
+	//     "$ref": "#/responses/errMsg"
+	//   "404":
+	//     "$ref": "#/responses/errMsg"
 	//   "500":
 	//     "$ref": "#/responses/err"
-	e.GET("/refresh/:token", h.refresh)
+	e.GET("/login/oidc/:provider/callback", h.oidcCallback)
+
+	// swagger:route POST /refresh auth refresh
+	// Refreshes jwt token.
+	// Issues a new jwt token and rotates the refresh token. Each refresh token can be used only once, reusing it revokes all refresh tokens issued since login.
+	// In browser session mode the refresh token is read from its cookie instead of request body, requiring CSRF token in X-CSRF-Token header.
+	// responses:
+	//  200: refreshResp
+	//  400: errMsg
+	//  401: err
+	//  403: errMsg
+	//  500: err
+	e.POST(cookie.RefreshPath, h.refresh)
 
 	// swagger:route GET /me auth meReq
 	// Gets user's info from session.
@@ -53,6 +131,20 @@ func NewHTTP(svc auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
 	//  200: userResp
 	//  500: err
 	e.GET("/me", h.me, mw)
+
+	// swagger:route POST /logout auth logout
+	// Logs out user by revoking current session and jwt token.
+	// responses:
+	//  200: ok
+	//  401: err
+	//  500: err
+	e.POST("/logout", h.logout, mw)
+
+	// swagger:route GET /.well-known/jwks.json auth jwks
+	// Returns public keys used to verify jwt tokens.
+	// responses:
+	//  200: jwksResp
+	e.GET("/.well-known/jwks.json", h.jwks)
 }
 
 type credentials struct {
@@ -69,17 +161,110 @@ func (h *HTTP) login(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	return h.respond(c, r)
+}
+
+type mfaChallenge struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+type mfaCredentials struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+func (h *HTTP) loginMFA(c echo.Context) error {
+	cred := new(mfaCredentials)
+	if err := c.Bind(cred); err != nil {
+		return err
+	}
+	r, err := h.svc.VerifyMFA(c, cred.MFAToken, cred.Code)
+	if err != nil {
+		return err
+	}
+	return h.respond(c, r)
+}
+
+func (h *HTTP) enrollMFA(c echo.Context) error {
+	req := new(mfaChallenge)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	r, err := h.svc.EnrollMFA(c, req.MFAToken)
+	if err != nil {
+		return err
+	}
 	return c.JSON(http.StatusOK, r)
 }
 
+// Custom errors
+var (
+	ErrPasswordsNotMaching = echo.NewHTTPError(http.StatusBadRequest, "passwords do not match")
+)
+
+type passwordChange struct {
+	PasswordToken   string `json:"password_token" validate:"required"`
+	Password        string `json:"password" validate:"required,max=128"`
+	PasswordConfirm string `json:"password_confirm" validate:"required,max=128"`
+}
+
+func (h *HTTP) loginPassword(c echo.Context) error {
+	req := new(passwordChange)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	if req.Password != req.PasswordConfirm {
+		return ErrPasswordsNotMaching
+	}
+	r, err := h.svc.ChangeExpiredPassword(c, req.PasswordToken, req.Password)
+	if err != nil {
+		return err
+	}
+	return h.respond(c, r)
+}
+
+func (h *HTTP) loginOIDC(c echo.Context) error {
+	url, err := h.svc.OIDCLogin(c, c.Param("provider"))
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, url)
+}
+
+func (h *HTTP) oidcCallback(c echo.Context) error {
+	r, err := h.svc.OIDCCallback(c, c.Param("provider"), c.QueryParam("state"), c.QueryParam("code"))
+	if err != nil {
+		return err
+	}
+	return h.respond(c, r)
+}
+
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 func (h *HTTP) refresh(c echo.Context) error {
-	token, err := h.svc.Refresh(c, c.Param("token"))
+	token, err := h.refreshToken(c)
+	if err != nil {
+		return err
+	}
+	r, err := h.svc.Refresh(c, token)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, map[string]string{
-		"token": token,
-	})
+	return h.respond(c, r)
+}
+
+// refreshToken reads refresh token from its cookie in browser session mode, or from request body otherwise
+func (h *HTTP) refreshToken(c echo.Context) (string, error) {
+	if h.cookies != nil {
+		return h.cookies.RefreshToken(c)
+	}
+	req := new(refreshReq)
+	if err := c.Bind(req); err != nil {
+		return "", err
+	}
+	return req.RefreshToken, nil
 }
 
 func (h *HTTP) me(c echo.Context) error {
@@ -89,3 +274,27 @@ func (h *HTTP) me(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, user)
 }
+
+func (h *HTTP) logout(c echo.Context) error {
+	if err := h.svc.Logout(c); err != nil {
+		return err
+	}
+	if h.cookies != nil {
+		h.cookies.Clear(c)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *HTTP) jwks(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.svc.JWKS(c))
+}
+
+// respond returns auth tokens, moving access and refresh tokens to cookies in browser session mode
+func (h *HTTP) respond(c echo.Context, t gorsk.AuthToken) error {
+	if h.cookies != nil {
+		if err := h.cookies.SetTokens(c, &t); err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusOK, t)
+}
@@ -4,19 +4,29 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/auth"
 	"github.com/ribice/gorsk/pkg/api/auth/transport"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
+	"github.com/ribice/gorsk/pkg/utl/denylist"
 	"github.com/ribice/gorsk/pkg/utl/jwt"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
 	authMw "github.com/ribice/gorsk/pkg/utl/middleware/auth"
 	"github.com/ribice/gorsk/pkg/utl/mock"
 	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/oidc"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 	"github.com/ribice/gorsk/pkg/utl/server"
+	"github.com/ribice/gorsk/pkg/utl/totp"
 
 	"github.com/go-pg/pg/v9/orm"
 	"github.com/stretchr/testify/assert"
@@ -29,6 +39,7 @@ func TestLogin(t *testing.T) {
 		wantStatus int
 		wantResp   *gorsk.AuthToken
 		udb        *mockdb.User
+		sdb        *mockdb.Session
 		jwt        *mock.JWT
 		sec        *mock.Secure
 	}{
@@ -62,8 +73,13 @@ func TestLogin(t *testing.T) {
 					return nil
 				},
 			},
+			sdb: &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					return s, nil
+				},
+			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(gorsk.User) (string, error) {
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
 					return "jwttokenstring", nil
 				},
 			},
@@ -71,8 +87,8 @@ func TestLogin(t *testing.T) {
 				HashMatchesPasswordFn: func(string, string) bool {
 					return true
 				},
-				TokenFn: func(string) string {
-					return "refreshtoken"
+				NeedsRehashFn: func(string) bool {
+					return false
 				},
 			},
 			wantResp: &gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken"},
@@ -82,7 +98,7 @@ func TestLogin(t *testing.T) {
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			transport.NewHTTP(auth.New(nil, tt.udb, tt.jwt, tt.sec, nil), r, nil)
+			transport.NewHTTP(auth.New(nil, tt.udb, tt.sdb, nil, loginDB, tt.jwt, tt.sec, nil, nil, lockout.NewMemory(lockout.Policy{}), nil, auth.Config{}), r, nil, nil)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/login"
@@ -104,31 +120,391 @@ func TestLogin(t *testing.T) {
 	}
 }
 
+func TestLoginMFA(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.AuthToken
+		udb        *mockdb.User
+		sdb        *mockdb.Session
+		jwt        *mock.JWT
+		sec        *mock.Secure
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"mfa_token":"challenge"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on invalid challenge",
+			req:        `{"mfa_token":"challenge","code":"123456"}`,
+			wantStatus: http.StatusUnauthorized,
+			jwt: &mock.JWT{
+				ParseChallengeFn: func(string) (int, error) {
+					return 0, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:       "Success",
+			req:        `{"mfa_token":"challenge","code":"` + code + `"}`,
+			wantStatus: http.StatusOK,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{
+						Active:     true,
+						MFAEnabled: true,
+						MFASecret:  secret,
+					}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					return nil
+				},
+				UseMFAStepFn: func(orm.DB, int, int64) (bool, error) {
+					return true, nil
+				},
+			},
+			sdb: &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+					return s, nil
+				},
+			},
+			jwt: &mock.JWT{
+				ParseChallengeFn: func(string) (int, error) {
+					return 1, nil
+				},
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
+					return "jwttokenstring", nil
+				},
+			},
+			wantResp: &gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken.refreshtoken"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(nil, tt.udb, tt.sdb, nil, loginDB, tt.jwt, tt.sec, nil, nil, lockout.NewMemory(lockout.Policy{}), nil, auth.Config{}), r, nil, nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/login/mfa"
+			res, err := http.Post(path, "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.AuthToken)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				tt.wantResp.RefreshToken = response.RefreshToken
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestEnrollMFA(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+		jwt        *mock.JWT
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on enabled MFA",
+			req:        `{"mfa_token":"challenge"}`,
+			wantStatus: http.StatusBadRequest,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Active: true, MFAEnabled: true}, nil
+				},
+			},
+			jwt: &mock.JWT{
+				ParseChallengeFn: func(string) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+		{
+			name:       "Success",
+			req:        `{"mfa_token":"challenge"}`,
+			wantStatus: http.StatusOK,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Active: true, Username: "johndoe"}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					return nil
+				},
+			},
+			jwt: &mock.JWT{
+				ParseChallengeFn: func(string) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(nil, tt.udb, nil, nil, loginDB, tt.jwt, nil, nil, nil, nil, nil, auth.Config{MFAIssuer: "Gorsk"}), r, nil, nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/login/mfa/enroll"
+			res, err := http.Post(path, "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantStatus == http.StatusOK {
+				response := new(gorsk.MFAEnrollment)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.NotEmpty(t, response.Secret)
+				assert.Contains(t, response.URI, "Gorsk:johndoe")
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestLoginPassword(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"password_token":"challenge"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Different passwords",
+			req:        `{"password_token":"challenge","password":"Thranduil8822","password_confirm":"Thranduil8823"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on reused password",
+			req:        `{"password_token":"challenge","password":"oldpassword","password_confirm":"oldpassword"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"password_token":"challenge","password":"Thranduil8822","password_confirm":"Thranduil8822"}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(orm.DB, int) (gorsk.User, error) {
+			return gorsk.User{Base: gorsk.Base{ID: 1}, Password: "oldpassword", Active: true, MustChangePassword: true}, nil
+		},
+		UpdateFn: func(orm.DB, gorsk.User) error {
+			return nil
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+			return s, nil
+		},
+	}
+	cdb := &mockdb.Company{
+		ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+			return gorsk.Company{}, nil
+		},
+	}
+	jwt := &mock.JWT{
+		ParsePasswordChallengeFn: func(string) (int, error) {
+			return 1, nil
+		},
+		GenerateTokenFn: func(gorsk.User, int) (string, error) {
+			return "jwttokenstring", nil
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(string, ...string) error {
+			return nil
+		},
+		HashMatchesPasswordFn: func(hash, pass string) bool {
+			return hash == pass
+		},
+		NeedsRehashFn: func(string) bool {
+			return false
+		},
+		HashFn: func(pass string) (string, error) {
+			return pass, nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(nil, udb, sdb, cdb, loginDB, jwt, sec, nil, nil, nil, nil, auth.Config{}), r, nil, nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/login/password", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantStatus == http.StatusOK {
+				response := new(gorsk.AuthToken)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, "jwttokenstring", response.Token)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestLoginOIDC(t *testing.T) {
+	idp := mock.NewIdP(t, "gorsk", map[string]interface{}{
+		"email":          "johndoe@mail.com",
+		"email_verified": true,
+	})
+	defer idp.Close()
+
+	udb := &mockdb.User{
+		FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+			return gorsk.User{Base: gorsk.Base{ID: 1}, Active: true}, nil
+		},
+		UpdateFn: func(orm.DB, gorsk.User) error {
+			return nil
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (gorsk.Session, error) {
+			return s, nil
+		},
+	}
+	jwt := &mock.JWT{
+		GenerateTokenFn: func(gorsk.User, int) (string, error) {
+			return "jwttokenstring", nil
+		},
+	}
+	sec := &mock.Secure{}
+	log := &mock.Logger{
+		LogFn: func(echo.Context, string, string, error, map[string]interface{}) {},
+	}
+
+	r := server.New()
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	transport.NewHTTP(auth.New(nil, udb, sdb, nil, loginDB, jwt, sec, nil, nil, nil, log, auth.Config{
+		Providers: map[string]auth.Provider{
+			"corp": {IdP: oidc.New(oidc.Config{
+				Issuer:      idp.URL,
+				ClientID:    "gorsk",
+				RedirectURL: ts.URL + "/login/oidc/corp/callback",
+			}, nil)},
+		},
+	}), r, nil, nil)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(ts.URL + "/login/oidc/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = client.Get(ts.URL + "/login/oidc/corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+
+	state, code := idp.Authorize(t, res.Header.Get("Location"))
+	callback := ts.URL + "/login/oidc/corp/callback?state=" + url.QueryEscape(state) + "&code=" + url.QueryEscape(code)
+
+	res, err = http.Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = client.Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	response := new(gorsk.AuthToken)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "jwttokenstring", response.Token)
+}
+
 func TestRefresh(t *testing.T) {
 	cases := []struct {
 		name       string
 		req        string
 		wantStatus int
-		wantResp   *gorsk.RefreshToken
+		wantResp   *gorsk.AuthToken
 		udb        *mockdb.User
+		sdb        *mockdb.Session
 		jwt        *mock.JWT
+		sec        *mock.Secure
 	}{
+		{
+			name:       "Fail on missing refresh token",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:       "Fail on FindByToken",
-			req:        "refreshtoken",
+			req:        `{"refresh_token":"refreshtoken"}`,
 			wantStatus: http.StatusInternalServerError,
-			udb: &mockdb.User{
-				FindByTokenFn: func(orm.DB, string) (gorsk.User, error) {
-					return gorsk.User{}, gorsk.ErrGeneric
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(orm.DB, string) (gorsk.Session, error) {
+					return gorsk.Session{}, gorsk.ErrGeneric
 				},
 			},
 		},
 		{
 			name:       "Success",
-			req:        "refreshtoken",
+			req:        `{"refresh_token":"refreshtoken"}`,
 			wantStatus: http.StatusOK,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(orm.DB, string) (gorsk.Session, error) {
+					return gorsk.Session{UserID: 1, Family: "family"}, nil
+				},
+				RotateFn: func(orm.DB, gorsk.Session, string) error {
+					return nil
+				},
+			},
 			udb: &mockdb.User{
-				FindByTokenFn: func(orm.DB, string) (gorsk.User, error) {
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
 					return gorsk.User{
 						Username: "johndoe",
 						Active:   true,
@@ -136,31 +512,33 @@ func TestRefresh(t *testing.T) {
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(gorsk.User) (string, error) {
+				GenerateTokenFn: func(gorsk.User, int) (string, error) {
 					return "jwttokenstring", nil
 				},
 			},
-			wantResp: &gorsk.RefreshToken{Token: "jwttokenstring"},
+			wantResp: &gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "family."},
 		},
 	}
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			transport.NewHTTP(auth.New(nil, tt.udb, tt.jwt, nil, nil), r, nil)
+			transport.NewHTTP(auth.New(nil, tt.udb, tt.sdb, nil, loginDB, tt.jwt, tt.sec, nil, nil, nil, nil, auth.Config{}), r, nil, nil)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
-			path := ts.URL + "/refresh/" + tt.req
-			res, err := http.Get(path)
+			path := ts.URL + "/refresh"
+			res, err := http.Post(path, "application/json", bytes.NewBufferString(tt.req))
 			if err != nil {
 				t.Fatal(err)
 			}
 			defer res.Body.Close()
 			if tt.wantResp != nil {
-				response := new(gorsk.RefreshToken)
+				response := new(gorsk.AuthToken)
 				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
 					t.Fatal(err)
 				}
+				assert.True(t, strings.HasPrefix(response.RefreshToken, tt.wantResp.RefreshToken))
+				tt.wantResp.RefreshToken = response.RefreshToken
 				assert.Equal(t, tt.wantResp, response)
 			}
 			assert.Equal(t, tt.wantStatus, res.StatusCode)
@@ -168,6 +546,100 @@ func TestRefresh(t *testing.T) {
 	}
 }
 
+func TestRefreshCookie(t *testing.T) {
+	cases := []struct {
+		name        string
+		refresh     string
+		csrf        string
+		csrfHeader  string
+		wantStatus  int
+		wantRefresh string
+	}{
+		{
+			name:       "Fail on missing CSRF token",
+			refresh:    "family.token",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Fail on missing refresh token",
+			csrf:       "csrf",
+			csrfHeader: "csrf",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "Success",
+			refresh:     "family.token",
+			csrf:        "csrf",
+			csrfHeader:  "csrf",
+			wantStatus:  http.StatusOK,
+			wantRefresh: "family.",
+		},
+	}
+
+	sdb := &mockdb.Session{
+		FindByTokenFn: func(db orm.DB, token string) (gorsk.Session, error) {
+			assert.Equal(t, secure.HashToken("family.token"), token)
+			return gorsk.Session{UserID: 1, Family: "family"}, nil
+		},
+		RotateFn: func(orm.DB, gorsk.Session, string) error {
+			return nil
+		},
+	}
+	udb := &mockdb.User{
+		ViewFn: func(orm.DB, int) (gorsk.User, error) {
+			return gorsk.User{Username: "johndoe", Active: true}, nil
+		},
+	}
+	jwt := &mock.JWT{
+		GenerateTokenFn: func(gorsk.User, int) (string, error) {
+			return "jwttokenstring", nil
+		},
+	}
+	sec := &mock.Secure{}
+
+	r := server.New()
+	transport.NewHTTP(auth.New(nil, udb, sdb, nil, loginDB, jwt, sec, nil, nil, nil, nil, auth.Config{}), r, nil, cookie.New(cookie.Config{}))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", ts.URL+"/refresh", nil)
+			if tt.refresh != "" {
+				req.AddCookie(&http.Cookie{Name: cookie.RefreshCookie, Value: tt.refresh})
+			}
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: cookie.CSRFCookie, Value: tt.csrf})
+				req.Header.Set(cookie.CSRFHeader, tt.csrfHeader)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			response := new(gorsk.AuthToken)
+			if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, &gorsk.AuthToken{}, response)
+
+			got := make(map[string]string)
+			for _, c := range res.Cookies() {
+				got[c.Name] = c.Value
+			}
+			assert.Equal(t, "jwttokenstring", got[cookie.AccessCookie])
+			assert.True(t, strings.HasPrefix(got[cookie.RefreshCookie], tt.wantRefresh))
+			assert.NotEmpty(t, got[cookie.CSRFCookie])
+		})
+	}
+}
+
 func TestMe(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -229,7 +701,7 @@ func TestMe(t *testing.T) {
 	}
 
 	client := &http.Client{}
-	jwt, err := jwt.New("HS256", "jwtsecret123", 60, 4)
+	jwt, err := jwt.New("HS256", "jwtsecret123", 60, 4, jwt.Config{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -237,7 +709,7 @@ func TestMe(t *testing.T) {
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			transport.NewHTTP(auth.New(nil, tt.udb, nil, nil, tt.rbac), r, authMw.Middleware(jwt))
+			transport.NewHTTP(auth.New(nil, tt.udb, nil, nil, loginDB, nil, nil, tt.rbac, nil, nil, nil, auth.Config{}), r, authMw.Middleware(jwt, denylist.NewMemory(), nil), nil)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/me"
@@ -262,3 +734,105 @@ func TestMe(t *testing.T) {
 		})
 	}
 }
+
+func TestLogout(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		header     string
+		sdb        *mockdb.Session
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Unauthorized",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Fail on revoking session",
+			wantStatus: http.StatusInternalServerError,
+			header:     mock.HeaderValid(),
+			sdb: &mockdb.Session{
+				DeleteFn: func(orm.DB, gorsk.Session) error {
+					return gorsk.ErrGeneric
+				},
+			},
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, SessionID: 1, TokenID: "jti"}
+				},
+			},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			header:     mock.HeaderValid(),
+			sdb: &mockdb.Session{
+				DeleteFn: func(orm.DB, gorsk.Session) error {
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, SessionID: 1, TokenID: "jti"}
+				},
+			},
+		},
+	}
+
+	client := &http.Client{}
+	jwt, err := jwt.New("HS256", "jwtsecret123", 60, 4, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl := denylist.NewMemory()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(nil, nil, tt.sdb, nil, loginDB, nil, nil, tt.rbac, dl, nil, nil, auth.Config{}), r, authMw.Middleware(jwt, dl, nil), nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/logout", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", tt.header)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	jwks := gorsk.JWKS{Keys: []gorsk.JWK{{Kty: "OKP", Kid: "2020", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: "x"}}}
+	r := server.New()
+	transport.NewHTTP(auth.New(nil, nil, nil, nil, loginDB, mock.JWT{
+		JWKSFn: func() gorsk.JWKS {
+			return jwks
+		}}, nil, nil, nil, nil, nil, auth.Config{}), r, nil, nil)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/.well-known/jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	response := new(gorsk.JWKS)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &jwks, response)
+}
+
+var loginDB = &mockdb.LoginEvent{
+	CreateFn: func(db orm.DB, e gorsk.LoginEvent) (gorsk.LoginEvent, error) {
+		return e, nil
+	},
+}
@@ -20,11 +20,57 @@ type swaggLoginResp struct {
 	}
 }
 
+// Token refresh request
+// swagger:parameters refresh
+type swaggRefreshReq struct {
+	// in:body
+	Body refreshReq
+}
+
 // Token refresh response
 // swagger:response refreshResp
 type swaggRefreshResp struct {
 	// in:body
 	Body struct {
-		*gorsk.RefreshToken
+		*gorsk.AuthToken
+	}
+}
+
+// Two-factor authentication login request
+// swagger:parameters loginMFA
+type swaggLoginMFAReq struct {
+	// in:body
+	Body mfaCredentials
+}
+
+// Two-factor authentication enrollment request
+// swagger:parameters loginMFAEnroll
+type swaggMFAEnrollReq struct {
+	// in:body
+	Body mfaChallenge
+}
+
+// Expired password change request
+// swagger:parameters loginPassword
+type swaggLoginPasswordReq struct {
+	// in:body
+	Body passwordChange
+}
+
+// Two-factor authentication enrollment response
+// swagger:response mfaEnrollResp
+type swaggMFAEnrollResp struct {
+	// in:body
+	Body struct {
+		*gorsk.MFAEnrollment
+	}
+}
+
+// JSON Web Key Set response
+// swagger:response jwksResp
+type swaggJWKSResp struct {
+	// in:body
+	Body struct {
+		*gorsk.JWKS
 	}
 }
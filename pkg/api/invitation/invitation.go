@@ -0,0 +1,182 @@
+// Package invitation contains user invitation application services
+package invitation
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/query"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+)
+
+// Custom errors
+var (
+	ErrInvitationNotFound = echo.NewHTTPError(http.StatusNotFound, "Invitation does not exist")
+	ErrInvalidToken       = echo.NewHTTPError(http.StatusBadRequest, "Invalid or expired invitation")
+	ErrEmailTaken         = echo.NewHTTPError(http.StatusConflict, "Email already exists")
+)
+
+const inviteBody = `Hi,
+
+you have been invited to create an account. To accept the invitation and choose your password, visit:
+
+%s
+
+The invitation can be used only once and expires on %s.`
+
+// Create invites a person to create an account with invitation's email, role, company and location.
+// Inviting requires the same permissions as creating the account.
+func (i Invitation) Create(c echo.Context, req gorsk.Invitation) (gorsk.Invitation, error) {
+	if err := i.rbac.AccountCreate(c, req.RoleID, req.CompanyID, req.LocationID); err != nil {
+		return gorsk.Invitation{}, err
+	}
+
+	_, err := i.udb.FindByEmail(i.db, req.Email)
+	if err == nil {
+		return gorsk.Invitation{}, ErrEmailTaken
+	}
+	if err != pg.ErrNoRows {
+		return gorsk.Invitation{}, err
+	}
+
+	token, err := secure.NewToken()
+	if err != nil {
+		return gorsk.Invitation{}, err
+	}
+
+	req.InvitedBy = i.rbac.User(c).ID
+	req.Hash = secure.HashToken(token)
+	req.ExpiresAt = time.Now().Add(i.cfg.Duration)
+
+	inv, err := i.idb.Create(i.db, req)
+	if err != nil {
+		return gorsk.Invitation{}, err
+	}
+
+	return inv, i.send(inv, token)
+}
+
+// List returns pending invitations within scope of the requesting user's permission to create users.
+// Users granted the permission on their own resources only see invitations they sent.
+func (i Invitation) List(c echo.Context) ([]gorsk.Invitation, error) {
+	grant, err := i.rbac.Grant(c, gorsk.UsersCreate)
+	if err != nil {
+		return nil, err
+	}
+	au := i.rbac.User(c)
+	if grant.Scope == gorsk.ScopeOwn {
+		return i.idb.List(i.db, &gorsk.ListQuery{Query: "invited_by = ?", ID: au.ID})
+	}
+	q, err := query.List(au, grant)
+	if err != nil {
+		return nil, err
+	}
+	return i.idb.List(i.db, q)
+}
+
+// Resend emails the invitation again with a new token, extending its expiry
+func (i Invitation) Resend(c echo.Context, id int) error {
+	inv, err := i.view(c, id)
+	if err != nil {
+		return err
+	}
+
+	token, err := secure.NewToken()
+	if err != nil {
+		return err
+	}
+
+	inv.Hash = secure.HashToken(token)
+	inv.ExpiresAt = time.Now().Add(i.cfg.Duration)
+
+	if err := i.idb.Update(i.db, inv); err != nil {
+		return err
+	}
+
+	return i.send(inv, token)
+}
+
+// Revoke revokes a pending invitation
+func (i Invitation) Revoke(c echo.Context, id int) error {
+	inv, err := i.view(c, id)
+	if err != nil {
+		return err
+	}
+	return i.idb.Delete(i.db, inv)
+}
+
+// Accept creates the invited user's account with the password chosen by the invitee.
+// As the invitation was emailed, user's email is verified. Invitation can be accepted only once.
+func (i Invitation) Accept(c echo.Context, token string, u gorsk.User) (gorsk.User, error) {
+	inv, err := i.idb.FindByHash(i.db, secure.HashToken(token))
+	if err == pg.ErrNoRows {
+		return gorsk.User{}, ErrInvalidToken
+	}
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	if inv.Expired(time.Now()) {
+		return gorsk.User{}, ErrInvalidToken
+	}
+
+	if err := i.sec.Password(u.Password, u.FirstName, u.LastName, u.Username, inv.Email); err != nil {
+		return gorsk.User{}, err
+	}
+
+	hash, err := i.sec.Hash(u.Password)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	u.Password = hash
+	u.VerifyEmail(inv.Email, time.Now())
+	u.Active = true
+	u.RoleID = inv.RoleID
+	u.CompanyID = inv.CompanyID
+	u.LocationID = inv.LocationID
+
+	user, err := i.udb.Create(i.db, u)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+
+	return user, i.idb.Delete(i.db, inv)
+}
+
+// view returns invitation the current user is allowed to manage
+func (i Invitation) view(c echo.Context, id int) (gorsk.Invitation, error) {
+	inv, err := i.idb.View(i.db, id)
+	if err == pg.ErrNoRows {
+		return gorsk.Invitation{}, ErrInvitationNotFound
+	}
+	if err != nil {
+		return gorsk.Invitation{}, err
+	}
+	if err := i.rbac.AccountCreate(c, inv.RoleID, inv.CompanyID, inv.LocationID); err != nil {
+		return gorsk.Invitation{}, err
+	}
+	return inv, nil
+}
+
+// send emails the invitation link
+func (i Invitation) send(inv gorsk.Invitation, token string) error {
+	link, err := url.Parse(i.cfg.URL)
+	if err != nil {
+		return err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	return i.mailer.Send(mail.Message{
+		To:      inv.Email,
+		Subject: "You have been invited",
+		Body:    fmt.Sprintf(inviteBody, link, inv.ExpiresAt.Format(time.RFC1123)),
+	})
+}
@@ -0,0 +1,344 @@
+package invitation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/invitation"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var cfg = invitation.Config{Duration: 72 * time.Hour, URL: "https://gorsk.dev/invitations/accept"}
+
+func TestCreate(t *testing.T) {
+	req := gorsk.Invitation{Email: "johndoe@mail.com", RoleID: gorsk.UserRole, CompanyID: 1, LocationID: 2}
+	cases := []struct {
+		name     string
+		wantErr  error
+		wantSent bool
+		rbac     *mock.RBAC
+		udb      *mockdb.User
+		idb      *mockdb.Invitation
+	}{
+		{
+			name:    "Fail on AccountCreate",
+			wantErr: echo.ErrForbidden,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return echo.ErrForbidden
+				},
+			},
+		},
+		{
+			name:    "Fail on registered email",
+			wantErr: invitation.ErrEmailTaken,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 3}}, nil
+				},
+			},
+		},
+		{
+			name:     "Success",
+			wantSent: true,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(c echo.Context, role gorsk.AccessRole, companyID, locationID int) error {
+					assert.Equal(t, gorsk.UserRole, role)
+					assert.Equal(t, 1, companyID)
+					assert.Equal(t, 2, locationID)
+					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 5}
+				},
+			},
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+			idb: &mockdb.Invitation{
+				CreateFn: func(db orm.DB, inv gorsk.Invitation) (gorsk.Invitation, error) {
+					if inv.InvitedBy != 5 || len(inv.Hash) != 64 || inv.ExpiresAt.Before(time.Now().Add(71*time.Hour)) {
+						t.Errorf("unexpected invitation: %+v", inv)
+					}
+					inv.ID = 1
+					return inv, nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []mail.Message
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+			s := invitation.New(nil, tt.idb, tt.udb, tt.rbac, nil, mailer, cfg)
+			inv, err := s.Create(nil, req)
+			assert.Equal(t, tt.wantErr, err)
+			if !tt.wantSent {
+				assert.Empty(t, sent)
+				return
+			}
+			assert.Equal(t, 1, inv.ID)
+			if assert.Len(t, sent, 1) {
+				assert.Equal(t, "johndoe@mail.com", sent[0].To)
+				assert.Contains(t, sent[0].Body, "https://gorsk.dev/invitations/accept?token=")
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name      string
+		role      gorsk.AccessRole
+		grant     gorsk.RolePermission
+		wantErr   bool
+		wantQuery *gorsk.ListQuery
+	}{
+		{
+			name:    "Fail on user role",
+			role:    gorsk.UserRole,
+			wantErr: true,
+		},
+		{
+			name:      "Company admin",
+			role:      gorsk.CompanyAdminRole,
+			grant:     gorsk.RolePermission{Scope: gorsk.ScopeCompany},
+			wantQuery: &gorsk.ListQuery{Query: "company_id = ?", ID: 1},
+		},
+		{
+			name:      "Company admin granted roles",
+			role:      gorsk.CompanyAdminRole,
+			grant:     gorsk.RolePermission{Scope: gorsk.ScopeCompany, Roles: []gorsk.AccessRole{gorsk.LocationAdminRole, gorsk.UserRole}},
+			wantQuery: &gorsk.ListQuery{Query: "company_id = ?", ID: 1, Roles: []gorsk.AccessRole{gorsk.LocationAdminRole, gorsk.UserRole}},
+		},
+		{
+			name:      "Own invitations",
+			role:      gorsk.UserRole,
+			grant:     gorsk.RolePermission{Scope: gorsk.ScopeOwn, Roles: []gorsk.AccessRole{gorsk.UserRole}},
+			wantQuery: &gorsk.ListQuery{Query: "invited_by = ?", ID: 1},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rbac := &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, CompanyID: 1, LocationID: 2, Role: tt.role}
+				},
+				GrantFn: func(c echo.Context, p gorsk.Permission) (gorsk.RolePermission, error) {
+					assert.Equal(t, gorsk.UsersCreate, p)
+					return tt.grant, nil
+				},
+			}
+			idb := &mockdb.Invitation{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery) ([]gorsk.Invitation, error) {
+					assert.Equal(t, tt.wantQuery, q)
+					return []gorsk.Invitation{{Email: "johndoe@mail.com"}}, nil
+				},
+			}
+			s := invitation.New(nil, idb, nil, rbac, nil, nil, cfg)
+			invs, err := s.List(nil)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Len(t, invs, 1)
+			}
+		})
+	}
+}
+
+func TestResend(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  error
+		wantSent bool
+		rbac     *mock.RBAC
+		idb      *mockdb.Invitation
+	}{
+		{
+			name:    "Not found",
+			wantErr: invitation.ErrInvitationNotFound,
+			idb: &mockdb.Invitation{
+				ViewFn: func(orm.DB, int) (gorsk.Invitation, error) {
+					return gorsk.Invitation{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Fail on AccountCreate",
+			wantErr: echo.ErrForbidden,
+			idb: &mockdb.Invitation{
+				ViewFn: func(orm.DB, int) (gorsk.Invitation, error) {
+					return gorsk.Invitation{Email: "johndoe@mail.com", RoleID: gorsk.AdminRole}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return echo.ErrForbidden
+				},
+			},
+		},
+		{
+			name:     "Success",
+			wantSent: true,
+			idb: &mockdb.Invitation{
+				ViewFn: func(orm.DB, int) (gorsk.Invitation, error) {
+					return gorsk.Invitation{Email: "johndoe@mail.com", Hash: "old", ExpiresAt: time.Now().Add(-time.Hour)}, nil
+				},
+				UpdateFn: func(db orm.DB, inv gorsk.Invitation) error {
+					if inv.Hash == "old" || inv.Expired(time.Now()) {
+						t.Errorf("invitation was not renewed: %+v", inv)
+					}
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []mail.Message
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+			s := invitation.New(nil, tt.idb, nil, tt.rbac, nil, mailer, cfg)
+			err := s.Resend(nil, 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantSent, len(sent) == 1)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	var deleted bool
+	idb := &mockdb.Invitation{
+		ViewFn: func(orm.DB, int) (gorsk.Invitation, error) {
+			return gorsk.Invitation{Base: gorsk.Base{ID: 1}}, nil
+		},
+		DeleteFn: func(orm.DB, gorsk.Invitation) error {
+			deleted = true
+			return nil
+		},
+	}
+	rbac := &mock.RBAC{
+		AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+			return nil
+		},
+	}
+	s := invitation.New(nil, idb, nil, rbac, nil, nil, cfg)
+	assert.Nil(t, s.Revoke(nil, 1))
+	assert.True(t, deleted)
+}
+
+func TestAccept(t *testing.T) {
+	pending := gorsk.Invitation{
+		Base:       gorsk.Base{ID: 1},
+		Email:      "johndoe@mail.com",
+		RoleID:     gorsk.UserRole,
+		CompanyID:  1,
+		LocationID: 2,
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	cases := []struct {
+		name    string
+		wantErr error
+		idb     *mockdb.Invitation
+		udb     *mockdb.User
+		sec     *mock.Secure
+	}{
+		{
+			name:    "Unknown token",
+			wantErr: invitation.ErrInvalidToken,
+			idb: &mockdb.Invitation{
+				FindByHashFn: func(orm.DB, string) (gorsk.Invitation, error) {
+					return gorsk.Invitation{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Expired invitation",
+			wantErr: invitation.ErrInvalidToken,
+			idb: &mockdb.Invitation{
+				FindByHashFn: func(orm.DB, string) (gorsk.Invitation, error) {
+					return gorsk.Invitation{ExpiresAt: time.Now().Add(-time.Minute)}, nil
+				},
+			},
+		},
+		{
+			name:    "Insecure password",
+			wantErr: gorsk.ErrInsecurePassword,
+			idb: &mockdb.Invitation{
+				FindByHashFn: func(orm.DB, string) (gorsk.Invitation, error) {
+					return pending, nil
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return gorsk.ErrInsecurePassword
+				},
+			},
+		},
+		{
+			name: "Success",
+			idb: &mockdb.Invitation{
+				FindByHashFn: func(orm.DB, string) (gorsk.Invitation, error) {
+					return pending, nil
+				},
+				DeleteFn: func(db orm.DB, inv gorsk.Invitation) error {
+					assert.Equal(t, 1, inv.ID)
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+					if u.Email != "johndoe@mail.com" || !u.EmailVerified() || !u.Active || u.Password != "h4$h3d" ||
+						u.RoleID != gorsk.UserRole || u.CompanyID != 1 || u.LocationID != 2 {
+						t.Errorf("unexpected user: %+v", u)
+					}
+					u.ID = 7
+					return u, nil
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := invitation.New(nil, tt.idb, tt.udb, nil, tt.sec, nil, cfg)
+			u, err := s.Accept(nil, "token", gorsk.User{Username: "johndoe", Password: "Thranduil8822"})
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, 7, u.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package invitation
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/invitation"
+)
+
+// New creates new invitation logging service
+func New(svc invitation.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents invitation logging service
+type LogService struct {
+	invitation.Service
+	logger gorsk.Logger
+}
+
+const name = "invitation"
+
+// Create logging
+func (ls *LogService) Create(c echo.Context, req gorsk.Invitation) (resp gorsk.Invitation, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Create invitation request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Create(c, req)
+}
+
+// List logging
+func (ls *LogService) List(c echo.Context) (resp []gorsk.Invitation, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "List invitations request", err,
+			map[string]interface{}{
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.List(c)
+}
+
+// Resend logging
+func (ls *LogService) Resend(c echo.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Resend invitation request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Resend(c, req)
+}
+
+// Revoke logging
+func (ls *LogService) Revoke(c echo.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Revoke invitation request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Revoke(c, req)
+}
+
+// Accept logging
+func (ls *LogService) Accept(c echo.Context, token string, req gorsk.User) (resp gorsk.User, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Accept invitation request", err,
+			map[string]interface{}{
+				"req":  req.Username,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Accept(c, token, req)
+}
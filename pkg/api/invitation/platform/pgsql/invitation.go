@@ -0,0 +1,56 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Invitation represents the client for invitation table
+type Invitation struct{}
+
+// Create creates a new invitation
+func (i Invitation) Create(db orm.DB, inv gorsk.Invitation) (gorsk.Invitation, error) {
+	err := db.Insert(&inv)
+	return inv, err
+}
+
+// View returns single invitation by ID
+func (i Invitation) View(db orm.DB, id int) (gorsk.Invitation, error) {
+	inv := gorsk.Invitation{Base: gorsk.Base{ID: id}}
+	err := db.Model(&inv).WherePK().Select()
+	return inv, err
+}
+
+// List returns invitations, most recently created first
+func (i Invitation) List(db orm.DB, qp *gorsk.ListQuery) ([]gorsk.Invitation, error) {
+	var invs []gorsk.Invitation
+	q := db.Model(&invs).Order("created_at desc")
+	if qp != nil && qp.Query != "" {
+		q.Where(qp.Query, qp.ID)
+	}
+	if qp != nil && len(qp.Roles) > 0 {
+		q.Where("role_id IN (?)", pg.In(qp.Roles))
+	}
+	err := q.Select()
+	return invs, err
+}
+
+// FindByHash returns invitation by its token hash
+func (i Invitation) FindByHash(db orm.DB, hash string) (gorsk.Invitation, error) {
+	var inv gorsk.Invitation
+	err := db.Model(&inv).Where("hash = ?", hash).Select()
+	return inv, err
+}
+
+// Update updates invitation's token and expiry
+func (i Invitation) Update(db orm.DB, inv gorsk.Invitation) error {
+	_, err := db.Model(&inv).Column("hash", "expires_at", "updated_at").WherePK().Update()
+	return err
+}
+
+// Delete deletes an invitation
+func (i Invitation) Delete(db orm.DB, inv gorsk.Invitation) error {
+	return db.Delete(&inv)
+}
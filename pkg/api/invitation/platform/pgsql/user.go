@@ -0,0 +1,20 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/user/platform/pgsql"
+)
+
+// User represents the client for user table
+type User struct {
+	pgsql.User
+}
+
+// FindByEmail queries for single user by email, case insensitive
+func (u User) FindByEmail(db orm.DB, email string) (gorsk.User, error) {
+	var user gorsk.User
+	err := db.Model(&user).Where("lower(email) = lower(?)", email).Select()
+	return user, err
+}
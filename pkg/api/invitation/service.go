@@ -0,0 +1,85 @@
+package invitation
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/invitation/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+)
+
+// Service represents invitation application interface
+type Service interface {
+	Create(echo.Context, gorsk.Invitation) (gorsk.Invitation, error)
+	List(echo.Context) ([]gorsk.Invitation, error)
+	Resend(echo.Context, int) error
+	Revoke(echo.Context, int) error
+	Accept(echo.Context, string, gorsk.User) (gorsk.User, error)
+}
+
+// New creates new invitation application service
+func New(db *pg.DB, idb InvitationDB, udb UserDB, rbac RBAC, sec Securer, mailer Mailer, cfg Config) Invitation {
+	return Invitation{db: db, idb: idb, udb: udb, rbac: rbac, sec: sec, mailer: mailer, cfg: cfg}
+}
+
+// Initialize initalizes invitation application service with defaults
+func Initialize(db *pg.DB, rbac RBAC, sec Securer, mailer Mailer, cfg Config) Invitation {
+	return New(db, pgsql.Invitation{}, pgsql.User{}, rbac, sec, mailer, cfg)
+}
+
+// Invitation represents invitation application service
+type Invitation struct {
+	db     *pg.DB
+	idb    InvitationDB
+	udb    UserDB
+	rbac   RBAC
+	sec    Securer
+	mailer Mailer
+	cfg    Config
+}
+
+// Config holds invitation settings
+type Config struct {
+	// Duration is how long invitations stay valid
+	Duration time.Duration
+	// URL is the page invitees are sent to, with the invitation token appended as token query parameter
+	URL string
+}
+
+// InvitationDB represents invitation repository interface
+type InvitationDB interface {
+	Create(orm.DB, gorsk.Invitation) (gorsk.Invitation, error)
+	View(orm.DB, int) (gorsk.Invitation, error)
+	List(orm.DB, *gorsk.ListQuery) ([]gorsk.Invitation, error)
+	FindByHash(orm.DB, string) (gorsk.Invitation, error)
+	Update(orm.DB, gorsk.Invitation) error
+	Delete(orm.DB, gorsk.Invitation) error
+}
+
+// UserDB represents user repository interface
+type UserDB interface {
+	FindByEmail(orm.DB, string) (gorsk.User, error)
+	Create(orm.DB, gorsk.User) (gorsk.User, error)
+}
+
+// Securer represents security interface
+type Securer interface {
+	Hash(string) (string, error)
+	Password(string, ...string) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+	Grant(echo.Context, gorsk.Permission) (gorsk.RolePermission, error)
+	AccountCreate(echo.Context, gorsk.AccessRole, int, int) error
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
+}
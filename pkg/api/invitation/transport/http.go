@@ -0,0 +1,224 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/invitation"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents invitation http service
+type HTTP struct {
+	svc invitation.Service
+}
+
+// NewHTTP creates new invitation http service. Accepting invitations is registered on e,
+// as invitees do not have an account yet.
+func NewHTTP(svc invitation.Service, e *echo.Echo, r *echo.Group) {
+	h := HTTP{svc}
+	ir := r.Group("/invitations")
+
+	// swagger:route POST /v1/invitations invitations invitationCreate
+	// Invites a person to create an account with given email, role, company and location.
+	// responses:
+	//  200: invitationResp
+	//  400: errMsg
+	//  401: err
+	//  403: errMsg
+	//  409: errMsg
+	//  500: err
+	ir.POST("", h.create)
+
+	// swagger:route GET /v1/invitations invitations listInvitations
+	// Returns pending invitations. Depending on the user role requesting them, it may return all invitations for SuperAdmin/Admin users, all company/location invitations for Company/Location admins, and an error for non-admin users.
+	// responses:
+	//  200: invitationListResp
+	//  401: err
+	//  403: err
+	//  500: err
+	ir.GET("", h.list)
+
+	// swagger:operation POST /v1/invitations/{id}/resend invitations invitationResend
+	// ---
+	// summary: Resends an invitation.
+	// description: Emails the invitation again with a new link, extending its expiry. Links sent before stop working.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of invitation
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ir.POST("/:id/resend", h.resend)
+
+	// swagger:operation DELETE /v1/invitations/{id} invitations invitationRevoke
+	// ---
+	// summary: Revokes an invitation.
+	// description: Revokes a pending invitation. Its link stops working.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of invitation
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ir.DELETE("/:id", h.revoke)
+
+	// swagger:operation POST /invitations/accept invitations invitationAccept
+	// ---
+	// summary: Accepts an invitation.
+	// description: Creates the invited user's account with the password chosen by the invitee. Invitation can be accepted only once.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/invitationAccept"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/userResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/invitations/accept", h.accept)
+}
+
+// Custom errors
+var (
+	ErrPasswordsNotMaching = echo.NewHTTPError(http.StatusBadRequest, "passwords do not match")
+)
+
+// Invitation create request
+// swagger:model invitationCreate
+type createReq struct {
+	Email      string           `json:"email" validate:"required,email"`
+	CompanyID  int              `json:"company_id" validate:"required"`
+	LocationID int              `json:"location_id" validate:"required"`
+	RoleID     gorsk.AccessRole `json:"role_id" validate:"required"`
+}
+
+func (h HTTP) create(c echo.Context) error {
+	r := new(createReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if r.RoleID < gorsk.SuperAdminRole || r.RoleID > gorsk.UserRole {
+		return gorsk.ErrBadRequest
+	}
+
+	inv, err := h.svc.Create(c, gorsk.Invitation{
+		Email:      r.Email,
+		CompanyID:  r.CompanyID,
+		LocationID: r.LocationID,
+		RoleID:     r.RoleID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, inv)
+}
+
+type listResponse struct {
+	Invitations []gorsk.Invitation `json:"invitations"`
+}
+
+func (h HTTP) list(c echo.Context) error {
+	result, err := h.svc.List(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, listResponse{result})
+}
+
+func (h HTTP) resend(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Resend(c, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h HTTP) revoke(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Revoke(c, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Invitation accept request
+// swagger:model invitationAccept
+type acceptReq struct {
+	Token           string `json:"token" validate:"required"`
+	FirstName       string `json:"first_name" validate:"required"`
+	LastName        string `json:"last_name" validate:"required"`
+	Username        string `json:"username" validate:"required,min=3,alphanum"`
+	Password        string `json:"password" validate:"required,max=128"`
+	PasswordConfirm string `json:"password_confirm" validate:"required,max=128"`
+}
+
+func (h HTTP) accept(c echo.Context) error {
+	r := new(acceptReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if r.Password != r.PasswordConfirm {
+		return ErrPasswordsNotMaching
+	}
+
+	usr, err := h.svc.Accept(c, r.Token, gorsk.User{
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Username:  r.Username,
+		Password:  r.Password,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, usr)
+}
@@ -0,0 +1,250 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/invitation"
+	"github.com/ribice/gorsk/pkg/api/invitation/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		rbac       *mock.RBAC
+		udb        *mockdb.User
+		idb        *mockdb.Invitation
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"johndoe","company_id":1,"location_id":1,"role_id":200}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on invalid role",
+			req:        `{"email":"johndoe@mail.com","company_id":1,"location_id":1,"role_id":50}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `{"email":"johndoe@mail.com","company_id":1,"location_id":1,"role_id":200}`,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `{"email":"johndoe@mail.com","company_id":1,"location_id":1,"role_id":200}`,
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				},
+			},
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+			idb: &mockdb.Invitation{
+				CreateFn: func(db orm.DB, inv gorsk.Invitation) (gorsk.Invitation, error) {
+					inv.ID = 1
+					return inv, nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			mailer := &mock.Mailer{SendFn: func(mail.Message) error { return nil }}
+			transport.NewHTTP(invitation.New(nil, tt.idb, tt.udb, tt.rbac, nil, mailer, invitation.Config{}), r, r.Group(""))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/invitations", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus == http.StatusOK {
+				body, _ := ioutil.ReadAll(res.Body)
+				assert.NotContains(t, string(body), "hash")
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		idb        *mockdb.Invitation
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Not found",
+			id:   "1",
+			idb: &mockdb.Invitation{
+				ViewFn: func(orm.DB, int) (gorsk.Invitation, error) {
+					return gorsk.Invitation{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "Success",
+			id:   "1",
+			idb: &mockdb.Invitation{
+				ViewFn: func(orm.DB, int) (gorsk.Invitation, error) {
+					return gorsk.Invitation{Base: gorsk.Base{ID: 1}}, nil
+				},
+				DeleteFn: func(orm.DB, gorsk.Invitation) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := &http.Client{}
+	rbac := &mock.RBAC{
+		AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+			return nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(invitation.New(nil, tt.idb, nil, rbac, nil, nil, invitation.Config{}), r, r.Group(""))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("DELETE", ts.URL+"/invitations/"+tt.id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestAccept(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantData   gorsk.User
+		idb        *mockdb.Invitation
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"token":"token","first_name":"John","last_name":"Doe","username":"jd","password":"Thranduil8822","password_confirm":"Thranduil8822"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Different passwords",
+			req:        `{"token":"token","first_name":"John","last_name":"Doe","username":"johndoe","password":"Thranduil8822","password_confirm":"Thranduil8823"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid token",
+			req:  `{"token":"token","first_name":"John","last_name":"Doe","username":"johndoe","password":"Thranduil8822","password_confirm":"Thranduil8822"}`,
+			idb: &mockdb.Invitation{
+				FindByHashFn: func(orm.DB, string) (gorsk.Invitation, error) {
+					return gorsk.Invitation{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"token":"token","first_name":"John","last_name":"Doe","username":"johndoe","password":"Thranduil8822","password_confirm":"Thranduil8822"}`,
+			idb: &mockdb.Invitation{
+				FindByHashFn: func(orm.DB, string) (gorsk.Invitation, error) {
+					return gorsk.Invitation{Email: "johndoe@mail.com", RoleID: gorsk.UserRole, ExpiresAt: time.Now().Add(time.Hour)}, nil
+				},
+				DeleteFn: func(orm.DB, gorsk.Invitation) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+					u.ID = 1
+					return u, nil
+				},
+			},
+			wantData:   gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Email: "johndoe@mail.com"},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	sec := &mock.Secure{
+		PasswordFn: func(string, ...string) error {
+			return nil
+		},
+		HashFn: func(string) (string, error) {
+			return "h4$h3d", nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(invitation.New(nil, tt.idb, tt.udb, nil, sec, nil, invitation.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/invitations/accept", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantData.ID != 0 {
+				var u gorsk.User
+				if err := json.NewDecoder(res.Body).Decode(&u); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantData.ID, u.ID)
+				assert.Equal(t, tt.wantData.Username, u.Username)
+				assert.Equal(t, tt.wantData.Email, u.Email)
+			}
+		})
+	}
+}
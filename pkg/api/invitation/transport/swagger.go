@@ -0,0 +1,23 @@
+package transport
+
+import (
+	"github.com/ribice/gorsk"
+)
+
+// Invitation model response
+// swagger:response invitationResp
+type swaggInvitationResponse struct {
+	// in:body
+	Body struct {
+		*gorsk.Invitation
+	}
+}
+
+// Invitations model response
+// swagger:response invitationListResp
+type swaggInvitationListResponse struct {
+	// in:body
+	Body struct {
+		Invitations []gorsk.Invitation `json:"invitations"`
+	}
+}
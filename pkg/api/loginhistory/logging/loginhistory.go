@@ -0,0 +1,57 @@
+package loginhistory
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/loginhistory"
+)
+
+// New creates new login history logging service
+func New(svc loginhistory.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents login history logging service
+type LogService struct {
+	loginhistory.Service
+	logger gorsk.Logger
+}
+
+const name = "loginhistory"
+
+// List logging
+func (ls *LogService) List(c echo.Context, req gorsk.Pagination) (resp []gorsk.LoginEvent, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "List login history request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.List(c, req)
+}
+
+// ListUser logging
+func (ls *LogService) ListUser(c echo.Context, id int, req gorsk.Pagination) (resp []gorsk.LoginEvent, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "List user login history request", err,
+			map[string]interface{}{
+				"id":   id,
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.ListUser(c, id, req)
+}
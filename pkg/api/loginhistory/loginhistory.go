@@ -0,0 +1,26 @@
+// Package loginhistory contains services for viewing users' login attempts
+package loginhistory
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+)
+
+// List returns login attempts of currently logged user, most recent first
+func (l LoginHistory) List(c echo.Context, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+	return l.ldb.List(l.db, l.rbac.User(c).ID, p)
+}
+
+// ListUser returns login attempts of a user, most recent first. Admins can view
+// login history of users they are granted logins:read on.
+func (l LoginHistory) ListUser(c echo.Context, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+	u, err := l.udb.View(l.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.rbac.Enforce(c, gorsk.LoginsRead, u.Resource()); err != nil {
+		return nil, err
+	}
+	return l.ldb.List(l.db, u.ID, p)
+}
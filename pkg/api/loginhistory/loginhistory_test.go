@@ -0,0 +1,135 @@
+package loginhistory_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/loginhistory"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantData []gorsk.LoginEvent
+		wantErr  bool
+		ldb      *mockdb.LoginEvent
+	}{
+		{
+			name:    "Fail on List",
+			wantErr: true,
+			ldb: &mockdb.LoginEvent{
+				ListFn: func(orm.DB, int, gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			ldb: &mockdb.LoginEvent{
+				ListFn: func(db orm.DB, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+					assert.Equal(t, gorsk.Pagination{Limit: 10, Offset: 10}, p)
+					return []gorsk.LoginEvent{
+						{Base: gorsk.Base{ID: 2}, UserID: userID, Device: "iPhone", Success: true},
+						{Base: gorsk.Base{ID: 1}, UserID: userID, Device: "Mac", Reason: gorsk.LoginInvalidPassword},
+					}, nil
+				}},
+			wantData: []gorsk.LoginEvent{
+				{Base: gorsk.Base{ID: 2}, UserID: 1, Device: "iPhone", Success: true},
+				{Base: gorsk.Base{ID: 1}, UserID: 1, Device: "Mac", Reason: gorsk.LoginInvalidPassword},
+			},
+		},
+	}
+	rbac := &mock.RBAC{
+		UserFn: func(echo.Context) gorsk.AuthUser {
+			return gorsk.AuthUser{ID: 1}
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := loginhistory.New(nil, tt.ldb, nil, rbac)
+			resp, err := s.List(nil, gorsk.Pagination{Limit: 10, Offset: 10})
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestListUser(t *testing.T) {
+	cases := []struct {
+		name     string
+		id       int
+		wantData []gorsk.LoginEvent
+		wantErr  error
+		udb      *mockdb.User
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on ViewUser",
+			id:      2,
+			wantErr: pg.ErrNoRows,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:    "Fail on role",
+			id:      2,
+			wantErr: gorsk.ErrGeneric,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.AdminRole}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:    "Fail on scope",
+			id:      2,
+			wantErr: gorsk.ErrGeneric,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.UserRole, CompanyID: 2, LocationID: 3}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					assert.Equal(t, gorsk.LoginsRead, p)
+					assert.Equal(t, gorsk.Resource{UserID: 2, CompanyID: 2, LocationID: 3, RoleID: gorsk.UserRole}, r)
+					return gorsk.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			id:   2,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.UserRole}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				}},
+			wantData: []gorsk.LoginEvent{{Base: gorsk.Base{ID: 1}, UserID: 2, Success: true}},
+		},
+	}
+	ldb := &mockdb.LoginEvent{
+		ListFn: func(db orm.DB, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+			return []gorsk.LoginEvent{{Base: gorsk.Base{ID: 1}, UserID: userID, Success: true}}, nil
+		}}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := loginhistory.New(nil, ldb, tt.udb, tt.rbac)
+			resp, err := s.ListUser(nil, tt.id, gorsk.Pagination{Limit: 100})
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
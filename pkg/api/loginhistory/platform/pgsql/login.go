@@ -0,0 +1,18 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// LoginEvent represents the client for login_event table
+type LoginEvent struct{}
+
+// List returns user's login attempts, most recent first
+func (l LoginEvent) List(db orm.DB, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+	var events []gorsk.LoginEvent
+	err := db.Model(&events).Where("user_id = ?", userID).
+		Order("created_at desc").Limit(p.Limit).Offset(p.Offset).Select()
+	return events, err
+}
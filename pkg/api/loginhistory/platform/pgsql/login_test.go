@@ -0,0 +1,40 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/loginhistory/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	events := []gorsk.LoginEvent{
+		{Base: gorsk.Base{ID: 1}, UserID: 1, Device: "Mac", Success: true},
+		{Base: gorsk.Base{ID: 2}, UserID: 2, Device: "Android", Success: true},
+		{Base: gorsk.Base{ID: 3}, UserID: 1, Device: "iPhone", Reason: gorsk.LoginInvalidPassword},
+		{Base: gorsk.Base{ID: 4}, UserID: 1, Device: "iPhone", Success: true},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.LoginEvent{})
+
+	for i := range events {
+		if err := mock.InsertMultiple(db, &events[i]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	ldb := pgsql.LoginEvent{}
+	resp, err := ldb.List(db, 1, gorsk.Pagination{Limit: 2, Offset: 1})
+	assert.Nil(t, err)
+	if assert.Len(t, resp, 2) {
+		assert.Equal(t, 3, resp[0].ID)
+		assert.Equal(t, gorsk.LoginInvalidPassword, resp[0].Reason)
+		assert.Equal(t, 1, resp[1].ID)
+	}
+}
@@ -0,0 +1,50 @@
+package loginhistory
+
+import (
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/loginhistory/platform/pgsql"
+)
+
+// Service represents login history application interface
+type Service interface {
+	List(echo.Context, gorsk.Pagination) ([]gorsk.LoginEvent, error)
+	ListUser(echo.Context, int, gorsk.Pagination) ([]gorsk.LoginEvent, error)
+}
+
+// New creates new login history application service
+func New(db *pg.DB, ldb LDB, udb UDB, rbac RBAC) LoginHistory {
+	return LoginHistory{db: db, ldb: ldb, udb: udb, rbac: rbac}
+}
+
+// Initialize initalizes LoginHistory application service with defaults
+func Initialize(db *pg.DB, rbac RBAC) LoginHistory {
+	return New(db, pgsql.LoginEvent{}, pgsql.User{}, rbac)
+}
+
+// LoginHistory represents login history application service
+type LoginHistory struct {
+	db   *pg.DB
+	ldb  LDB
+	udb  UDB
+	rbac RBAC
+}
+
+// LDB represents login event repository interface
+type LDB interface {
+	List(orm.DB, int, gorsk.Pagination) ([]gorsk.LoginEvent, error)
+}
+
+// UDB represents user repository interface
+type UDB interface {
+	View(orm.DB, int) (gorsk.User, error)
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+	Enforce(echo.Context, gorsk.Permission, gorsk.Resource) error
+}
@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/loginhistory"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents login history http service
+type HTTP struct {
+	svc loginhistory.Service
+}
+
+// NewHTTP creates new login history http service
+func NewHTTP(svc loginhistory.Service, r *echo.Group) {
+	h := HTTP{svc}
+
+	// swagger:operation GET /v1/me/logins logins listLogins
+	// ---
+	// summary: Returns login history of currently logged user.
+	// description: Returns successful and failed login attempts of currently logged user, most recent first. Failed attempts include the reason they failed.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/loginListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	r.GET("/me/logins", h.list)
+
+	// swagger:operation GET /v1/users/{id}/logins logins listUserLogins
+	// ---
+	// summary: Returns login history of a user.
+	// description: Returns successful and failed login attempts of a user, most recent first. Admins can view login history of users their logins:read permission covers.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/loginListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	r.GET("/users/:id/logins", h.listUser)
+}
+
+type listResponse struct {
+	Logins []gorsk.LoginEvent `json:"logins"`
+	Page   int                `json:"page"`
+}
+
+func (h HTTP) list(c echo.Context) error {
+	var req gorsk.PaginationReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	result, err := h.svc.List(c, req.Transform())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, listResponse{result, req.Page})
+}
+
+func (h HTTP) listUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	var req gorsk.PaginationReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	result, err := h.svc.ListUser(c, id, req.Transform())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, listResponse{result, req.Page})
+}
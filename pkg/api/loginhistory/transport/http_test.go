@@ -0,0 +1,190 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/loginhistory"
+	"github.com/ribice/gorsk/pkg/api/loginhistory/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+type listResponse struct {
+	Logins []gorsk.LoginEvent `json:"logins"`
+	Page   int                `json:"page"`
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantResp   *listResponse
+		ldb        *mockdb.LoginEvent
+	}{
+		{
+			name:       "Invalid request",
+			query:      "?page=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on List",
+			wantStatus: http.StatusInternalServerError,
+			ldb: &mockdb.LoginEvent{
+				ListFn: func(orm.DB, int, gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:       "Success",
+			query:      "?limit=10&page=1",
+			wantStatus: http.StatusOK,
+			ldb: &mockdb.LoginEvent{
+				ListFn: func(db orm.DB, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+					assert.Equal(t, gorsk.Pagination{Limit: 10, Offset: 10}, p)
+					return []gorsk.LoginEvent{
+						{
+							Base:      gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2019), UpdatedAt: mock.TestTime(2019)},
+							UserID:    userID,
+							Device:    "iPhone",
+							UserAgent: "Mozilla/5.0 (iPhone)",
+							IP:        "10.0.0.1",
+							Reason:    gorsk.LoginInvalidPassword,
+						},
+					}, nil
+				}},
+			wantResp: &listResponse{
+				Logins: []gorsk.LoginEvent{
+					{
+						Base:      gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2019), UpdatedAt: mock.TestTime(2019)},
+						Device:    "iPhone",
+						UserAgent: "Mozilla/5.0 (iPhone)",
+						IP:        "10.0.0.1",
+						Reason:    gorsk.LoginInvalidPassword,
+					},
+				},
+				Page: 1,
+			},
+		},
+	}
+
+	rbac := &mock.RBAC{
+		UserFn: func(echo.Context) gorsk.AuthUser {
+			return gorsk.AuthUser{ID: 1}
+		}}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(loginhistory.New(nil, tt.ldb, nil, rbac), r.Group(""))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/me/logins" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestListUser(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		wantResp   *listResponse
+		udb        *mockdb.User
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on ViewUser",
+			id:         "2",
+			wantStatus: http.StatusInternalServerError,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:       "Fail on RBAC",
+			id:         "2",
+			wantStatus: http.StatusForbidden,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.AdminRole}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return echo.ErrForbidden
+				}},
+		},
+		{
+			name:       "Success",
+			id:         "2",
+			wantStatus: http.StatusOK,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.UserRole}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				}},
+			wantResp: &listResponse{
+				Logins: []gorsk.LoginEvent{{Base: gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2019), UpdatedAt: mock.TestTime(2019)}, Success: true}},
+			},
+		},
+	}
+
+	ldb := &mockdb.LoginEvent{
+		ListFn: func(db orm.DB, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+			return []gorsk.LoginEvent{{Base: gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2019), UpdatedAt: mock.TestTime(2019)}, UserID: userID, Success: true}}, nil
+		}}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(loginhistory.New(nil, ldb, tt.udb, tt.rbac), r.Group(""))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/users/" + tt.id + "/logins")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package transport
+
+import (
+	"github.com/ribice/gorsk"
+)
+
+// Login history model response
+// swagger:response loginListResp
+type swaggLoginListResponse struct {
+	// in:body
+	Body struct {
+		Logins []gorsk.LoginEvent `json:"logins"`
+		Page   int                `json:"page"`
+	}
+}
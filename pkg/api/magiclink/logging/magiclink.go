@@ -0,0 +1,71 @@
+package magiclink
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/magiclink"
+)
+
+// New creates new passwordless login logging service
+func New(svc magiclink.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents passwordless login logging service
+type LogService struct {
+	magiclink.Service
+	logger gorsk.Logger
+}
+
+const name = "magiclink"
+
+// Request logging
+func (ls *LogService) Request(c echo.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Request login link request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Request(c, email)
+}
+
+// Login logging
+func (ls *LogService) Login(c echo.Context, token string) (resp gorsk.AuthToken, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Login link login request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Login(c, token)
+}
+
+// Enable logging
+func (ls *LogService) Enable(c echo.Context, companyID int, enabled bool) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Enable login links request", err,
+			map[string]interface{}{
+				"req":     companyID,
+				"enabled": enabled,
+				"took":    time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Enable(c, companyID, enabled)
+}
@@ -0,0 +1,169 @@
+// Package magiclink contains passwordless login application services
+package magiclink
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+	"github.com/ribice/gorsk/pkg/utl/server"
+)
+
+// Custom errors
+var (
+	ErrInvalidLink     = echo.NewHTTPError(http.StatusUnauthorized, "Login link is invalid or expired")
+	ErrTooManyRequests = echo.NewHTTPError(http.StatusTooManyRequests, "Too many login link requests, try again later")
+)
+
+const linkBody = `Hi %s,
+
+log in to your account by visiting:
+
+%s
+
+The link can be used once and expires in %d minutes. If you did not request it, you can ignore this email.`
+
+// Request emails a single-use login link to the user with given email, if the user's company allows
+// passwordless login. Requests are rate limited per email and client IP address. To avoid disclosing
+// which emails are registered, users not getting the link are not reported.
+func (m MagicLink) Request(c echo.Context, email string) error {
+	keys := []string{"email:" + strings.ToLower(email)}
+	if _, ip := server.Client(c); ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	for _, key := range keys {
+		if m.lim.Wait(key) > 0 {
+			return ErrTooManyRequests
+		}
+	}
+	for _, key := range keys {
+		m.lim.Fail(key)
+	}
+
+	u, err := m.udb.FindByEmail(m.db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !u.Active {
+		return nil
+	}
+	enabled, err := m.enabled(u)
+	if err != nil || !enabled {
+		return err
+	}
+
+	token, err := secure.NewToken()
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(m.cfg.URL)
+	if err != nil {
+		return err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	if _, err := m.ldb.Create(m.db, gorsk.LoginLink{
+		UserID:    u.ID,
+		Hash:      secure.HashToken(token),
+		ExpiresAt: time.Now().Add(m.cfg.Duration),
+	}); err != nil {
+		return err
+	}
+
+	return m.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Your login link",
+		Body:    fmt.Sprintf(linkBody, u.FirstName, link, int(m.cfg.Duration.Minutes())),
+	})
+}
+
+// Login logs in the user the login link was sent to, returning the same tokens as logging in with password.
+// Redeeming a link consumes all of user's login links, and marks user's email as verified.
+func (m MagicLink) Login(c echo.Context, token string) (gorsk.AuthToken, error) {
+	l, err := m.ldb.FindByHash(m.db, secure.HashToken(token))
+	if err == pg.ErrNoRows {
+		return gorsk.AuthToken{}, ErrInvalidLink
+	}
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if l.Expired(time.Now()) {
+		return gorsk.AuthToken{}, ErrInvalidLink
+	}
+
+	if err := m.ldb.DeleteByUser(m.db, l.UserID); err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	u, err := m.udb.View(m.db, l.UserID)
+	if err == pg.ErrNoRows {
+		return gorsk.AuthToken{}, ErrInvalidLink
+	}
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if !u.Active {
+		return gorsk.AuthToken{}, gorsk.ErrUnauthorized
+	}
+	enabled, err := m.enabled(u)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+	if !enabled {
+		return gorsk.AuthToken{}, ErrInvalidLink
+	}
+
+	if !u.EmailVerified() {
+		u.VerifyEmail(u.Email, time.Now())
+		if err := m.udb.UpdateEmail(m.db, u); err != nil {
+			return gorsk.AuthToken{}, err
+		}
+	}
+
+	return m.auth.Authorize(c, u)
+}
+
+// Enable sets whether users of a company can log in with login links.
+// Admins can change it only for their own company.
+func (m MagicLink) Enable(c echo.Context, companyID int, enabled bool) error {
+	if err := m.rbac.Enforce(c, gorsk.CompaniesSecurity, gorsk.Resource{CompanyID: companyID}); err != nil {
+		return err
+	}
+
+	company, err := m.cdb.View(m.db, companyID)
+	if err != nil {
+		return err
+	}
+
+	company.MagicLink = enabled
+	return m.cdb.Update(m.db, company)
+}
+
+// enabled checks whether user's company allows passwordless login
+func (m MagicLink) enabled(u gorsk.User) (bool, error) {
+	if u.CompanyID == 0 {
+		return false, nil
+	}
+	company, err := m.cdb.View(m.db, u.CompanyID)
+	if err == pg.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return company.MagicLink, nil
+}
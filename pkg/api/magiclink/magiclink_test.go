@@ -0,0 +1,272 @@
+package magiclink_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/magiclink"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var cfg = magiclink.Config{Duration: 15 * time.Minute, URL: "https://gorsk.dev/login/link"}
+
+func company(enabled bool) *mockdb.Company {
+	return &mockdb.Company{
+		ViewFn: func(db orm.DB, id int) (gorsk.Company, error) {
+			return gorsk.Company{Base: gorsk.Base{ID: id}, MagicLink: enabled}, nil
+		},
+	}
+}
+
+func TestRequest(t *testing.T) {
+	active := &mockdb.User{
+		FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+			return gorsk.User{Base: gorsk.Base{ID: 1}, FirstName: "John", Email: "johndoe@mail.com", Active: true, CompanyID: 1}, nil
+		},
+	}
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantSent bool
+		udb      *mockdb.User
+		cdb      *mockdb.Company
+	}{
+		{
+			name: "Unknown email",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Fail on FindByEmail",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Inactive user",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, CompanyID: 1}, nil
+				},
+			},
+			cdb: company(true),
+		},
+		{
+			name: "Disabled for company",
+			udb:  active,
+			cdb:  company(false),
+		},
+		{
+			name:     "Success",
+			wantSent: true,
+			udb:      active,
+			cdb:      company(true),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []mail.Message
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+			ldb := &mockdb.LoginLink{
+				CreateFn: func(db orm.DB, l gorsk.LoginLink) (gorsk.LoginLink, error) {
+					if l.UserID != 1 || len(l.Hash) != 64 || l.ExpiresAt.After(time.Now().Add(15*time.Minute)) {
+						t.Errorf("unexpected login link: %+v", l)
+					}
+					return l, nil
+				},
+			}
+			lim := lockout.NewMemory(lockout.Policy{})
+			s := magiclink.New(nil, tt.udb, ldb, tt.cdb, nil, nil, lim, mailer, cfg)
+			err := s.Request(nil, "johndoe@mail.com")
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantSent {
+				assert.Empty(t, sent)
+				return
+			}
+			if assert.Len(t, sent, 1) {
+				assert.Equal(t, "johndoe@mail.com", sent[0].To)
+				assert.Contains(t, sent[0].Body, "https://gorsk.dev/login/link?token=")
+			}
+		})
+	}
+}
+
+func TestRequestRateLimit(t *testing.T) {
+	udb := &mockdb.User{
+		FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+			return gorsk.User{}, pg.ErrNoRows
+		},
+	}
+	lim := lockout.NewMemory(lockout.Policy{MaxAttempts: 2, Lockout: time.Hour})
+	s := magiclink.New(nil, udb, nil, nil, nil, nil, lim, nil, cfg)
+
+	assert.Nil(t, s.Request(nil, "johndoe@mail.com"))
+	assert.Nil(t, s.Request(nil, "JohnDoe@mail.com"))
+	assert.Equal(t, magiclink.ErrTooManyRequests, s.Request(nil, "johndoe@mail.com"), "limit applies to unregistered emails too")
+	assert.Nil(t, s.Request(nil, "janedoe@mail.com"))
+}
+
+func TestLogin(t *testing.T) {
+	valid := &mockdb.LoginLink{
+		FindByHashFn: func(orm.DB, string) (gorsk.LoginLink, error) {
+			return gorsk.LoginLink{UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+		DeleteByUserFn: func(db orm.DB, userID int) error {
+			assert.Equal(t, 1, userID)
+			return nil
+		},
+	}
+	user := func(u gorsk.User) *mockdb.User {
+		return &mockdb.User{
+			ViewFn: func(orm.DB, int) (gorsk.User, error) {
+				return u, nil
+			},
+		}
+	}
+	verified := gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", EmailVerifiedAt: time.Now(), Active: true, CompanyID: 1}
+	cases := []struct {
+		name         string
+		wantErr      error
+		wantData     gorsk.AuthToken
+		wantVerified bool
+		ldb          *mockdb.LoginLink
+		udb          *mockdb.User
+		cdb          *mockdb.Company
+	}{
+		{
+			name:    "Unknown token",
+			wantErr: magiclink.ErrInvalidLink,
+			ldb: &mockdb.LoginLink{
+				FindByHashFn: func(orm.DB, string) (gorsk.LoginLink, error) {
+					return gorsk.LoginLink{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Expired token",
+			wantErr: magiclink.ErrInvalidLink,
+			ldb: &mockdb.LoginLink{
+				FindByHashFn: func(orm.DB, string) (gorsk.LoginLink, error) {
+					return gorsk.LoginLink{UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}, nil
+				},
+			},
+		},
+		{
+			name:    "Inactive user",
+			wantErr: gorsk.ErrUnauthorized,
+			ldb:     valid,
+			udb:     user(gorsk.User{Base: gorsk.Base{ID: 1}, CompanyID: 1}),
+		},
+		{
+			name:    "Disabled for company",
+			wantErr: magiclink.ErrInvalidLink,
+			ldb:     valid,
+			udb:     user(verified),
+			cdb:     company(false),
+		},
+		{
+			name:     "Success",
+			wantData: gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken"},
+			ldb:      valid,
+			udb:      user(verified),
+			cdb:      company(true),
+		},
+		{
+			name:         "Success verifying email",
+			wantData:     gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken"},
+			wantVerified: true,
+			ldb:          valid,
+			udb:          user(gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true, CompanyID: 1}),
+			cdb:          company(true),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated bool
+			if tt.udb != nil {
+				tt.udb.UpdateEmailFn = func(db orm.DB, u gorsk.User) error {
+					updated = true
+					assert.True(t, u.EmailVerified())
+					return nil
+				}
+			}
+			auth := &mock.Authorizer{
+				AuthorizeFn: func(c echo.Context, u gorsk.User) (gorsk.AuthToken, error) {
+					assert.True(t, u.EmailVerified())
+					return gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken"}, nil
+				},
+			}
+			s := magiclink.New(nil, tt.udb, tt.ldb, tt.cdb, auth, nil, nil, nil, cfg)
+			token, err := s.Login(nil, "token")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, token)
+			assert.Equal(t, tt.wantVerified, updated)
+		})
+	}
+}
+
+func TestEnable(t *testing.T) {
+	cases := []struct {
+		name        string
+		companyID   int
+		forbidden   bool
+		wantErr     error
+		wantUpdated bool
+	}{
+		{
+			name:      "Fail on permission",
+			companyID: 2,
+			forbidden: true,
+			wantErr:   echo.ErrForbidden,
+		},
+		{
+			name:        "Success",
+			companyID:   1,
+			wantUpdated: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated bool
+			cdb := company(false)
+			cdb.UpdateFn = func(db orm.DB, c gorsk.Company) error {
+				assert.True(t, c.MagicLink)
+				updated = true
+				return nil
+			}
+			rbac := &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					assert.Equal(t, gorsk.CompaniesSecurity, p)
+					assert.Equal(t, tt.companyID, r.CompanyID)
+					if tt.forbidden {
+						return echo.ErrForbidden
+					}
+					return nil
+				},
+			}
+			s := magiclink.New(nil, nil, nil, cdb, nil, rbac, nil, nil, cfg)
+			err := s.Enable(nil, tt.companyID, true)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUpdated, updated)
+		})
+	}
+}
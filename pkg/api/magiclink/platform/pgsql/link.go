@@ -0,0 +1,29 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Link represents the client for login_link table
+type Link struct{}
+
+// Create creates a new login link
+func (l Link) Create(db orm.DB, link gorsk.LoginLink) (gorsk.LoginLink, error) {
+	err := db.Insert(&link)
+	return link, err
+}
+
+// FindByHash returns login link by its hash
+func (l Link) FindByHash(db orm.DB, hash string) (gorsk.LoginLink, error) {
+	var link gorsk.LoginLink
+	err := db.Model(&link).Where("hash = ?", hash).Select()
+	return link, err
+}
+
+// DeleteByUser deletes all of user's login links
+func (l Link) DeleteByUser(db orm.DB, userID int) error {
+	_, err := db.Model((*gorsk.LoginLink)(nil)).Where("user_id = ?", userID).Delete()
+	return err
+}
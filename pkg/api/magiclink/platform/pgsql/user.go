@@ -0,0 +1,33 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// User represents the client for user table
+type User struct{}
+
+// View returns single user by ID, with its role
+func (u User) View(db orm.DB, id int) (gorsk.User, error) {
+	var user gorsk.User
+	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
+	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
+	WHERE ("user"."id" = ? and deleted_at is null)`
+	_, err := db.QueryOne(&user, sql, id)
+	return user, err
+}
+
+// FindByEmail queries for single user by email, case insensitive
+func (u User) FindByEmail(db orm.DB, email string) (gorsk.User, error) {
+	var user gorsk.User
+	err := db.Model(&user).Where("lower(email) = lower(?)", email).Select()
+	return user, err
+}
+
+// UpdateEmail updates user's email and its verification time
+func (u User) UpdateEmail(db orm.DB, user gorsk.User) error {
+	_, err := db.Model(&user).Column("email", "email_verified_at", "updated_at").WherePK().Update()
+	return err
+}
@@ -0,0 +1,93 @@
+package magiclink
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/magiclink/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+)
+
+// Service represents passwordless login application interface
+type Service interface {
+	Request(echo.Context, string) error
+	Login(echo.Context, string) (gorsk.AuthToken, error)
+	Enable(echo.Context, int, bool) error
+}
+
+// New creates new passwordless login application service
+func New(db *pg.DB, udb UserDB, ldb LinkDB, cdb CompanyDB, auth Authorizer, rbac RBAC, lim Limiter, mailer Mailer, cfg Config) MagicLink {
+	return MagicLink{db: db, udb: udb, ldb: ldb, cdb: cdb, auth: auth, rbac: rbac, lim: lim, mailer: mailer, cfg: cfg}
+}
+
+// Initialize initalizes passwordless login application service with defaults
+func Initialize(db *pg.DB, auth Authorizer, rbac RBAC, lim Limiter, mailer Mailer, cfg Config) MagicLink {
+	return New(db, pgsql.User{}, pgsql.Link{}, pgsql.Company{}, auth, rbac, lim, mailer, cfg)
+}
+
+// MagicLink represents passwordless login application service
+type MagicLink struct {
+	db     *pg.DB
+	udb    UserDB
+	ldb    LinkDB
+	cdb    CompanyDB
+	auth   Authorizer
+	rbac   RBAC
+	lim    Limiter
+	mailer Mailer
+	cfg    Config
+}
+
+// Config holds passwordless login settings
+type Config struct {
+	// Duration is how long login links stay valid
+	Duration time.Duration
+	// URL is the page users are sent to, with the login token appended as token query parameter
+	URL string
+}
+
+// UserDB represents user repository interface
+type UserDB interface {
+	View(orm.DB, int) (gorsk.User, error)
+	FindByEmail(orm.DB, string) (gorsk.User, error)
+	UpdateEmail(orm.DB, gorsk.User) error
+}
+
+// LinkDB represents login link repository interface
+type LinkDB interface {
+	Create(orm.DB, gorsk.LoginLink) (gorsk.LoginLink, error)
+	FindByHash(orm.DB, string) (gorsk.LoginLink, error)
+	DeleteByUser(orm.DB, int) error
+}
+
+// CompanyDB represents company repository interface
+type CompanyDB interface {
+	View(orm.DB, int) (gorsk.Company, error)
+	Update(orm.DB, gorsk.Company) error
+}
+
+// Authorizer represents auth service issuing auth tokens to authenticated users
+type Authorizer interface {
+	Authorize(echo.Context, gorsk.User) (gorsk.AuthToken, error)
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+	Enforce(echo.Context, gorsk.Permission, gorsk.Resource) error
+}
+
+// Limiter represents tracker of login link requests per email and client IP address
+type Limiter interface {
+	Wait(string) time.Duration
+	Fail(string) int
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
+}
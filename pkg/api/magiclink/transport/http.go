@@ -0,0 +1,167 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/magiclink"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents passwordless login http service
+type HTTP struct {
+	svc     magiclink.Service
+	cookies *cookie.Jar
+}
+
+// NewHTTP creates new passwordless login http service. Login routes are registered on e,
+// as they are used by users who are not logged in. Given a cookie jar, access and refresh
+// tokens are set as cookies instead of being returned in response body.
+func NewHTTP(svc magiclink.Service, e *echo.Echo, r *echo.Group, cookies *cookie.Jar) {
+	h := HTTP{svc, cookies}
+
+	// swagger:operation POST /login/link auth loginLinkRequest
+	// ---
+	// summary: Requests login link email.
+	// description: Emails a single-use login link, if user's company allows passwordless login. The response does not reveal whether the email is registered.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/loginLinkRequest"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "429":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/login/link", h.request)
+
+	// swagger:operation POST /login/link/redeem auth loginLinkRedeem
+	// ---
+	// summary: Logs in with login link.
+	// description: Exchanges the token from login link email for auth tokens, same as logging in with password. The token can be used only once.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/loginLinkRedeem"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/loginResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/login/link/redeem", h.login)
+
+	// swagger:operation PUT /v1/companies/{id}/magic-link auth loginLinkEnable
+	// ---
+	// summary: Sets whether company users can log in with login links
+	// description: Enables passwordless login for all users of a company. Admins can change it only for their own company.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/loginLinkEnable"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	r.PUT("/companies/:id/magic-link", h.enable)
+}
+
+// Login link request
+// swagger:model loginLinkRequest
+type requestReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// Login link redeem request
+// swagger:model loginLinkRedeem
+type loginReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Login link enable request
+// swagger:model loginLinkEnable
+type enableReq struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (h HTTP) request(c echo.Context) error {
+	req := new(requestReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := h.svc.Request(c, req.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h HTTP) login(c echo.Context) error {
+	req := new(loginReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	r, err := h.svc.Login(c, req.Token)
+	if err != nil {
+		return err
+	}
+
+	if h.cookies != nil {
+		if err := h.cookies.SetTokens(c, &r); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, r)
+}
+
+func (h HTTP) enable(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	req := new(enableReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := h.svc.Enable(c, id, req.Enabled); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,209 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/magiclink"
+	"github.com/ribice/gorsk/pkg/api/magiclink/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"johndoe"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"email":"johndoe@mail.com"}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	udb := &mockdb.User{
+		FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+			return gorsk.User{}, pg.ErrNoRows
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			lim := lockout.NewMemory(lockout.Policy{})
+			transport.NewHTTP(magiclink.New(nil, udb, nil, nil, nil, nil, lim, nil, magiclink.Config{}), r, r.Group(""), nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/login/link", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.AuthToken
+		ldb        *mockdb.LoginLink
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid link",
+			req:  `{"token":"token"}`,
+			ldb: &mockdb.LoginLink{
+				FindByHashFn: func(orm.DB, string) (gorsk.LoginLink, error) {
+					return gorsk.LoginLink{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Success",
+			req:  `{"token":"token"}`,
+			ldb: &mockdb.LoginLink{
+				FindByHashFn: func(orm.DB, string) (gorsk.LoginLink, error) {
+					return gorsk.LoginLink{UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}, nil
+				},
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp:   &gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken"},
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(orm.DB, int) (gorsk.User, error) {
+			return gorsk.User{Base: gorsk.Base{ID: 1}, EmailVerifiedAt: time.Now(), Active: true, CompanyID: 1}, nil
+		},
+	}
+	cdb := &mockdb.Company{
+		ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+			return gorsk.Company{MagicLink: true}, nil
+		},
+	}
+	auth := &mock.Authorizer{
+		AuthorizeFn: func(echo.Context, gorsk.User) (gorsk.AuthToken, error) {
+			return gorsk.AuthToken{Token: "jwttokenstring", RefreshToken: "refreshtoken"}, nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(magiclink.New(nil, udb, tt.ldb, cdb, auth, nil, nil, nil, magiclink.Config{}), r, r.Group(""), nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/login/link/redeem", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantResp != nil {
+				response := new(gorsk.AuthToken)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+		})
+	}
+}
+
+func TestEnable(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			req:        `{"enabled":true}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			id:         "2",
+			req:        `{"enabled":true}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			req:        `{"enabled":true}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := &http.Client{}
+	cdb := &mockdb.Company{
+		ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+			return gorsk.Company{}, nil
+		},
+		UpdateFn: func(orm.DB, gorsk.Company) error {
+			return nil
+		},
+	}
+	rbac := &mock.RBAC{
+		EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+			if r.CompanyID != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(magiclink.New(nil, nil, nil, cdb, nil, rbac, nil, nil, magiclink.Config{}), r, r.Group(""), nil)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("PUT", ts.URL+"/companies/"+tt.id+"/magic-link", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package mfa
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa"
+)
+
+// New creates new mfa logging service
+func New(svc mfa.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents mfa logging service
+type LogService struct {
+	mfa.Service
+	logger gorsk.Logger
+}
+
+const name = "mfa"
+
+// Enroll logging
+func (ls *LogService) Enroll(c echo.Context) (resp gorsk.MFAEnrollment, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Enroll MFA request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Enroll(c)
+}
+
+// Confirm logging
+func (ls *LogService) Confirm(c echo.Context, code string) (resp []string, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Confirm MFA request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Confirm(c, code)
+}
+
+// Disable logging
+func (ls *LogService) Disable(c echo.Context, code string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Disable MFA request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Disable(c, code)
+}
+
+// Require logging
+func (ls *LogService) Require(c echo.Context, companyID int, required bool) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Require MFA request", err,
+			map[string]interface{}{
+				"req":      companyID,
+				"required": required,
+				"took":     time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Require(c, companyID, required)
+}
@@ -0,0 +1,161 @@
+// Package mfa contains services for managing two-factor authentication
+package mfa
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+	"github.com/ribice/gorsk/pkg/utl/server"
+	"github.com/ribice/gorsk/pkg/utl/totp"
+)
+
+// Custom errors
+var (
+	ErrMFAEnabled      = echo.NewHTTPError(http.StatusBadRequest, "Two-factor authentication is already enabled")
+	ErrMFANotEnrolled  = echo.NewHTTPError(http.StatusBadRequest, "Two-factor authentication is not enrolled")
+	ErrMFARequired     = echo.NewHTTPError(http.StatusForbidden, "Two-factor authentication is required by your company")
+	ErrInvalidCode     = echo.NewHTTPError(http.StatusBadRequest, "Two-factor authentication code is invalid")
+	ErrTooManyAttempts = echo.NewHTTPError(http.StatusTooManyRequests, "Too many invalid two-factor authentication codes, try again later")
+)
+
+// Enroll generates a new two-factor authentication secret for currently logged user.
+// Two-factor authentication is enabled once the secret is confirmed with a valid code.
+func (m MFA) Enroll(c echo.Context) (gorsk.MFAEnrollment, error) {
+	u, err := m.udb.View(m.db, m.rbac.User(c).ID)
+	if err != nil {
+		return gorsk.MFAEnrollment{}, err
+	}
+
+	if u.MFAEnabled {
+		return gorsk.MFAEnrollment{}, ErrMFAEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return gorsk.MFAEnrollment{}, err
+	}
+
+	u.EnrollMFA(secret)
+
+	if err := m.udb.UpdateMFA(m.db, u); err != nil {
+		return gorsk.MFAEnrollment{}, err
+	}
+
+	return gorsk.MFAEnrollment{Secret: secret, URI: totp.URI(m.issuer, u.Username, secret)}, nil
+}
+
+// Confirm enables two-factor authentication for currently logged user, returning one-time recovery codes
+func (m MFA) Confirm(c echo.Context, code string) ([]string, error) {
+	u, err := m.udb.View(m.db, m.rbac.User(c).ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.MFAEnabled {
+		return nil, ErrMFAEnabled
+	}
+
+	if u.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := m.verify(c, &u, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, err := totp.RecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = secure.HashToken(code)
+	}
+	u.EnableMFA(hashes)
+
+	if err := m.udb.UpdateMFA(m.db, u); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable disables two-factor authentication for currently logged user, unless required by user's company.
+// The code is either a TOTP code or one of the recovery codes.
+func (m MFA) Disable(c echo.Context, code string) error {
+	u, err := m.udb.View(m.db, m.rbac.User(c).ID)
+	if err != nil {
+		return err
+	}
+
+	if !u.MFAEnabled {
+		return ErrMFANotEnrolled
+	}
+
+	company, err := m.cdb.View(m.db, u.CompanyID)
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	if company.RequireMFA {
+		return ErrMFARequired
+	}
+
+	if err := m.verify(c, &u, code, true); err != nil {
+		return err
+	}
+
+	u.DisableMFA()
+	return m.udb.UpdateMFA(m.db, u)
+}
+
+// Require sets whether two-factor authentication is required for all users of a company.
+// Admins can change the requirement only for their own company.
+func (m MFA) Require(c echo.Context, companyID int, required bool) error {
+	if err := m.rbac.Enforce(c, gorsk.CompaniesSecurity, gorsk.Resource{CompanyID: companyID}); err != nil {
+		return err
+	}
+
+	company, err := m.cdb.View(m.db, companyID)
+	if err != nil {
+		return err
+	}
+
+	company.RequireMFA = required
+	return m.cdb.Update(m.db, company)
+}
+
+// verify checks user's TOTP code, or one of recovery codes if allowed, recording the code's time step so
+// it cannot be used again. Invalid codes count towards the same user and client IP address lockout as failed logins.
+func (m MFA) verify(c echo.Context, u *gorsk.User, code string, recovery bool) error {
+	_, ip := server.Client(c)
+	now := time.Now()
+	if m.lockout.Wait(u.FailedLogins, u.LastFailedLogin, now) > 0 || (ip != "" && m.lim.Wait(ip) > 0) {
+		return ErrTooManyAttempts
+	}
+
+	if step, ok := totp.Match(u.MFASecret, code, now, u.MFALastStep); ok {
+		used, err := m.udb.UseMFAStep(m.db, u.ID, step)
+		if err != nil {
+			return err
+		}
+		if used {
+			u.MFALastStep = step
+			return nil
+		}
+	} else if recovery && u.UseRecoveryCode(secure.HashToken(code)) {
+		return nil
+	}
+
+	if ip != "" {
+		m.lim.Fail(ip)
+	}
+	if _, err := m.udb.LoginFailed(m.db, u.ID, now, m.lockout.Since(now)); err != nil {
+		return err
+	}
+	return ErrInvalidCode
+}
@@ -0,0 +1,346 @@
+package mfa_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+	"github.com/ribice/gorsk/pkg/utl/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+var userRBAC = &mock.RBAC{
+	UserFn: func(echo.Context) gorsk.AuthUser {
+		return gorsk.AuthUser{ID: 1, CompanyID: 1, Role: gorsk.UserRole}
+	},
+}
+
+func TestEnroll(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr error
+		udb     *mockdb.User
+	}{
+		{
+			name:    "Fail on View",
+			wantErr: gorsk.ErrGeneric,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Fail on enabled MFA",
+			wantErr: mfa.ErrMFAEnabled,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFAEnabled: true}, nil
+				},
+			},
+		},
+		{
+			name: "Success",
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Username: "johndoe"}, nil
+				},
+				UpdateMFAFn: func(db orm.DB, u gorsk.User) error {
+					if u.MFASecret == "" || u.MFAEnabled {
+						t.Error("MFA secret was not enrolled")
+					}
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mfa.New(nil, tt.udb, nil, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk")
+			enrollment, err := s.Enroll(nil)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.NotEmpty(t, enrollment.Secret)
+				assert.Contains(t, enrollment.URI, "Gorsk:johndoe")
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		code    string
+		wantErr error
+		udb     *mockdb.User
+	}{
+		{
+			name:    "Fail on not enrolled",
+			code:    code,
+			wantErr: mfa.ErrMFANotEnrolled,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on enabled MFA",
+			code:    code,
+			wantErr: mfa.ErrMFAEnabled,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFAEnabled: true, MFASecret: secret}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on invalid code",
+			code:    "000000",
+			wantErr: mfa.ErrInvalidCode,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFASecret: secret}, nil
+				},
+				LoginFailedFn: func(orm.DB, int, time.Time, time.Time) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on replayed code",
+			code:    code,
+			wantErr: mfa.ErrInvalidCode,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFASecret: secret, MFALastStep: totp.Step(time.Now())}, nil
+				},
+				LoginFailedFn: func(orm.DB, int, time.Time, time.Time) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on code used concurrently",
+			code:    code,
+			wantErr: mfa.ErrInvalidCode,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFASecret: secret}, nil
+				},
+				UseMFAStepFn: func(orm.DB, int, int64) (bool, error) {
+					return false, nil
+				},
+				LoginFailedFn: func(orm.DB, int, time.Time, time.Time) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on locked out user",
+			code:    code,
+			wantErr: mfa.ErrTooManyAttempts,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFASecret: secret, FailedLogins: 5, LastFailedLogin: time.Now()}, nil
+				},
+			},
+		},
+		{
+			name: "Success",
+			code: code,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFASecret: secret}, nil
+				},
+				UseMFAStepFn: func(db orm.DB, id int, step int64) (bool, error) {
+					if step != totp.Step(time.Now()) {
+						t.Error("code time step was not recorded")
+					}
+					return true, nil
+				},
+				UpdateMFAFn: func(db orm.DB, u gorsk.User) error {
+					if !u.MFAEnabled || len(u.MFARecoveryCodes) == 0 || len(u.MFARecoveryCodes[0]) != 64 {
+						t.Error("MFA was not enabled")
+					}
+					if u.MFALastStep != totp.Step(time.Now()) {
+						t.Error("code time step was not recorded")
+					}
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mfa.New(nil, tt.udb, nil, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{MaxAttempts: 5, Lockout: time.Minute}, "Gorsk")
+			codes, err := s.Confirm(nil, tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, len(codes) > 0)
+		})
+	}
+}
+
+func TestDisable(t *testing.T) {
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled := func(orm.DB, int) (gorsk.User, error) {
+		return gorsk.User{MFAEnabled: true, MFASecret: secret, CompanyID: 1, MFARecoveryCodes: []string{secure.HashToken("abcd-efgh")}}, nil
+	}
+	optional := &mockdb.Company{
+		ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+			return gorsk.Company{}, nil
+		},
+	}
+	cases := []struct {
+		name    string
+		code    string
+		wantErr error
+		udb     *mockdb.User
+		cdb     *mockdb.Company
+	}{
+		{
+			name:    "Fail on not enabled",
+			code:    code,
+			wantErr: mfa.ErrMFANotEnrolled,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on required by company",
+			code:    code,
+			wantErr: mfa.ErrMFARequired,
+			udb:     &mockdb.User{ViewFn: enabled},
+			cdb: &mockdb.Company{
+				ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+					return gorsk.Company{RequireMFA: true}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on invalid code",
+			code:    "000000",
+			wantErr: mfa.ErrInvalidCode,
+			udb: &mockdb.User{
+				ViewFn: enabled,
+				LoginFailedFn: func(db orm.DB, id int, at, since time.Time) (int, error) {
+					if at.IsZero() {
+						t.Error("failed attempt was not recorded")
+					}
+					return 1, nil
+				},
+			},
+			cdb: optional,
+		},
+		{
+			name: "Success with TOTP code",
+			code: code,
+			udb: &mockdb.User{
+				ViewFn: enabled,
+				UseMFAStepFn: func(orm.DB, int, int64) (bool, error) {
+					return true, nil
+				},
+				UpdateMFAFn: func(db orm.DB, u gorsk.User) error {
+					if u.MFAEnabled || u.MFASecret != "" {
+						t.Error("MFA was not disabled")
+					}
+					return nil
+				},
+			},
+			cdb: optional,
+		},
+		{
+			name: "Success with recovery code",
+			code: "abcd-efgh",
+			udb: &mockdb.User{
+				ViewFn: enabled,
+				UpdateMFAFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+					return gorsk.Company{}, pg.ErrNoRows
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mfa.New(nil, tt.udb, tt.cdb, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk")
+			err := s.Disable(nil, tt.code)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	cases := []struct {
+		name      string
+		companyID int
+		wantErr   error
+		rbac      *mock.RBAC
+		cdb       *mockdb.Company
+	}{
+		{
+			name:      "Fail on permission",
+			companyID: 2,
+			wantErr:   echo.ErrForbidden,
+			rbac: &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					return echo.ErrForbidden
+				},
+			},
+		},
+		{
+			name:      "Success",
+			companyID: 2,
+			rbac: &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					assert.Equal(t, gorsk.CompaniesSecurity, p)
+					assert.Equal(t, gorsk.Resource{CompanyID: 2}, r)
+					return nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (gorsk.Company, error) {
+					return gorsk.Company{Base: gorsk.Base{ID: id}}, nil
+				},
+				UpdateFn: func(db orm.DB, c gorsk.Company) error {
+					if c.ID != 2 || !c.RequireMFA {
+						t.Error("MFA requirement was not set")
+					}
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mfa.New(nil, nil, tt.cdb, tt.rbac, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk")
+			err := s.Require(nil, tt.companyID, true)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyUpdate(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Company{
+		Base:   gorsk.Base{ID: 1},
+		Name:   "admin_company",
+		Active: true,
+	}); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.Company{}
+
+	err := cdb.Update(db, gorsk.Company{Base: gorsk.Base{ID: 1}, Name: "changed", RequireMFA: true})
+	assert.Nil(t, err)
+
+	company, err := cdb.View(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, company.RequireMFA)
+	assert.Equal(t, "admin_company", company.Name)
+}
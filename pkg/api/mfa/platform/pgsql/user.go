@@ -0,0 +1,49 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// User represents the client for user table
+type User struct{}
+
+// View returns single user by ID
+func (u User) View(db orm.DB, id int) (gorsk.User, error) {
+	user := gorsk.User{Base: gorsk.Base{ID: id}}
+	err := db.Model(&user).WherePK().Select()
+	return user, err
+}
+
+// UpdateMFA updates user's two-factor authentication settings
+func (u User) UpdateMFA(db orm.DB, user gorsk.User) error {
+	_, err := db.Model(&user).Column("mfa_enabled", "mfa_secret", "mfa_recovery_codes", "mfa_last_step", "updated_at").WherePK().Update()
+	return err
+}
+
+// UseMFAStep records the time step of TOTP code used by the user, unless the same or a later step was
+// already used. It returns false in that case, so each code is accepted only once, even by concurrent requests.
+func (u User) UseMFAStep(db orm.DB, id int, step int64) (bool, error) {
+	res, err := db.Exec(`UPDATE "users" SET "mfa_last_step" = ? 
+	WHERE ("id" = ?) AND ("mfa_last_step" IS NULL OR "mfa_last_step" < ?)`, step, id, step)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// LoginFailed atomically increments user's consecutive failed logins, starting over if the last failure
+// happened before since, and returns the new count
+func (u User) LoginFailed(db orm.DB, id int, at, since time.Time) (int, error) {
+	var failures int
+	sql := `UPDATE "users" SET 
+	"failed_logins" = CASE WHEN "last_failed_login" IS NULL OR "last_failed_login" < ? THEN 1 ELSE COALESCE("failed_logins", 0) + 1 END, 
+	"last_failed_login" = ? 
+	WHERE ("id" = ?) RETURNING "failed_logins"`
+	_, err := db.QueryOne(pg.Scan(&failures), sql, since, at, id)
+	return failures, err
+}
@@ -0,0 +1,70 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateMFA(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		usr      gorsk.User
+		wantData gorsk.User
+	}{
+		{
+			name: "Success",
+			usr: gorsk.User{
+				Base:             gorsk.Base{ID: 1},
+				FirstName:        "Changed",
+				MFAEnabled:       true,
+				MFASecret:        "SECRET",
+				MFARecoveryCodes: []string{"hash1", "hash2"},
+			},
+			wantData: gorsk.User{
+				Base:             gorsk.Base{ID: 1},
+				FirstName:        "John",
+				Username:         "johndoe",
+				Email:            "johndoe@mail.com",
+				MFAEnabled:       true,
+				MFASecret:        "SECRET",
+				MFARecoveryCodes: []string{"hash1", "hash2"},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.User{})
+
+	if err := mock.InsertMultiple(db, &gorsk.User{
+		Base:      gorsk.Base{ID: 1},
+		FirstName: "John",
+		Username:  "johndoe",
+		Email:     "johndoe@mail.com",
+	}); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.User{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := udb.UpdateMFA(db, tt.usr)
+			assert.Equal(t, tt.wantErr, err != nil)
+			user, err := udb.View(db, tt.usr.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.wantData.CreatedAt = user.CreatedAt
+			tt.wantData.UpdatedAt = user.UpdatedAt
+			assert.Equal(t, tt.wantData, user)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package mfa
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+)
+
+// Service represents two-factor authentication application interface
+type Service interface {
+	Enroll(echo.Context) (gorsk.MFAEnrollment, error)
+	Confirm(echo.Context, string) ([]string, error)
+	Disable(echo.Context, string) error
+	Require(echo.Context, int, bool) error
+}
+
+// New creates new two-factor authentication application service. Invalid codes are limited per client IP address
+// by lim, and per user by lockout policy, sharing failed attempts with login.
+func New(db *pg.DB, udb UDB, cdb CDB, rbac RBAC, lim Limiter, lockout lockout.Policy, issuer string) MFA {
+	return MFA{db: db, udb: udb, cdb: cdb, rbac: rbac, lim: lim, lockout: lockout, issuer: issuer}
+}
+
+// Initialize initalizes MFA application service with defaults
+func Initialize(db *pg.DB, rbac RBAC, lim Limiter, lockout lockout.Policy, issuer string) MFA {
+	return New(db, pgsql.User{}, pgsql.Company{}, rbac, lim, lockout, issuer)
+}
+
+// MFA represents two-factor authentication application service
+type MFA struct {
+	db      *pg.DB
+	udb     UDB
+	cdb     CDB
+	rbac    RBAC
+	lim     Limiter
+	lockout lockout.Policy
+	issuer  string
+}
+
+// UDB represents user repository interface
+type UDB interface {
+	View(orm.DB, int) (gorsk.User, error)
+	UpdateMFA(orm.DB, gorsk.User) error
+	UseMFAStep(orm.DB, int, int64) (bool, error)
+	LoginFailed(orm.DB, int, time.Time, time.Time) (int, error)
+}
+
+// CDB represents company repository interface
+type CDB interface {
+	View(orm.DB, int) (gorsk.Company, error)
+	Update(orm.DB, gorsk.Company) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+	Enforce(echo.Context, gorsk.Permission, gorsk.Resource) error
+}
+
+// Limiter represents failed attempts limiter interface
+type Limiter interface {
+	Wait(string) time.Duration
+	Fail(string) int
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents mfa http service
+type HTTP struct {
+	svc mfa.Service
+}
+
+// NewHTTP creates new mfa http service
+func NewHTTP(svc mfa.Service, r *echo.Group) {
+	h := HTTP{svc}
+	mr := r.Group("/me/mfa")
+
+	// swagger:route POST /v1/me/mfa mfa mfaEnroll
+	// Generates a new two-factor authentication secret for currently logged user.
+	// responses:
+	//  200: mfaEnrollResp
+	//  400: errMsg
+	//  401: err
+	//  500: err
+	mr.POST("", h.enroll)
+
+	// swagger:route POST /v1/me/mfa/confirm mfa mfaConfirm
+	// Enables two-factor authentication by confirming the enrolled secret with a code.
+	// responses:
+	//  200: mfaRecoveryResp
+	//  400: errMsg
+	//  401: err
+	//  429: errMsg
+	//  500: err
+	mr.POST("/confirm", h.confirm)
+
+	// swagger:route POST /v1/me/mfa/disable mfa mfaDisable
+	// Disables two-factor authentication, given a TOTP or recovery code.
+	// responses:
+	//  200: ok
+	//  400: errMsg
+	//  401: err
+	//  403: errMsg
+	//  429: errMsg
+	//  500: err
+	mr.POST("/disable", h.disable)
+
+	// swagger:operation PUT /v1/companies/{id}/mfa mfa mfaRequire
+	// ---
+	// summary: Sets two-factor authentication requirement for a company
+	// description: Requires all users of a company to use two-factor authentication. Admins can change it only for their own company.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/mfaRequire"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	r.PUT("/companies/:id/mfa", h.require)
+}
+
+// MFA code request
+// swagger:model mfaCode
+type codeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFA requirement request
+// swagger:model mfaRequire
+type requireReq struct {
+	Required bool `json:"required"`
+}
+
+type recoveryResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h HTTP) enroll(c echo.Context) error {
+	result, err := h.svc.Enroll(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h HTTP) confirm(c echo.Context) error {
+	req := new(codeReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	codes, err := h.svc.Confirm(c, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, recoveryResponse{codes})
+}
+
+func (h HTTP) disable(c echo.Context) error {
+	req := new(codeReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := h.svc.Disable(c, req.Code); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h HTTP) require(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	req := new(requireReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := h.svc.Require(c, id, req.Required); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,236 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/mfa"
+	"github.com/ribice/gorsk/pkg/api/mfa/transport"
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+var userRBAC = &mock.RBAC{
+	UserFn: func(echo.Context) gorsk.AuthUser {
+		return gorsk.AuthUser{ID: 1, CompanyID: 1, Role: gorsk.AdminRole}
+	},
+	EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+		if r.CompanyID != 1 {
+			return echo.ErrForbidden
+		}
+		return nil
+	},
+}
+
+func TestEnroll(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on enabled MFA",
+			wantStatus: http.StatusBadRequest,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFAEnabled: true}, nil
+				},
+			},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Username: "johndoe"}, nil
+				},
+				UpdateMFAFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(mfa.New(nil, tt.udb, nil, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk"), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/me/mfa", "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantStatus == http.StatusOK {
+				response := new(gorsk.MFAEnrollment)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.NotEmpty(t, response.Secret)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on invalid code",
+			req:        `{"code":"000000"}`,
+			wantStatus: http.StatusBadRequest,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFASecret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"}, nil
+				},
+				LoginFailedFn: func(orm.DB, int, time.Time, time.Time) (int, error) {
+					return 1, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(mfa.New(nil, tt.udb, nil, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk"), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/me/mfa/confirm", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDisable(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+		cdb        *mockdb.Company
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on required by company",
+			req:        `{"code":"123456"}`,
+			wantStatus: http.StatusForbidden,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{MFAEnabled: true}, nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+					return gorsk.Company{RequireMFA: true}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(mfa.New(nil, tt.udb, tt.cdb, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk"), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/me/mfa/disable", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		req        string
+		wantStatus int
+		cdb        *mockdb.Company
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			req:        `{"required":true}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on other company",
+			id:         `2`,
+			req:        `{"required":true}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			id:         `1`,
+			req:        `{"required":true}`,
+			wantStatus: http.StatusOK,
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (gorsk.Company, error) {
+					return gorsk.Company{Base: gorsk.Base{ID: id}}, nil
+				},
+				UpdateFn: func(orm.DB, gorsk.Company) error {
+					return nil
+				},
+			},
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(mfa.New(nil, nil, tt.cdb, userRBAC, lockout.NewMemory(lockout.Policy{}), lockout.Policy{}, "Gorsk"), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest("PUT", ts.URL+"/companies/"+tt.id+"/mfa", bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
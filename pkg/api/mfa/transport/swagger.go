@@ -0,0 +1,17 @@
+package transport
+
+// MFA code request
+// swagger:parameters mfaConfirm mfaDisable
+type swaggCodeReq struct {
+	// in:body
+	Body codeReq
+}
+
+// Recovery codes response
+// swagger:response mfaRecoveryResp
+type swaggRecoveryResp struct {
+	// in:body
+	Body struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/password"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 )
 
 // New creates new password logging service
@@ -39,3 +40,80 @@ func (ls *LogService) Change(c echo.Context, id int, oldPass, newPass string) (e
 	}(time.Now())
 	return ls.Service.Change(c, id, oldPass, newPass)
 }
+
+// Forgot logging
+func (ls *LogService) Forgot(c echo.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Forgot password request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Forgot(c, email)
+}
+
+// Reset logging
+func (ls *LogService) Reset(c echo.Context, token, newPass string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Reset password request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Reset(c, token, newPass)
+}
+
+// AdminReset logging
+func (ls *LogService) AdminReset(c echo.Context, userID int, tempPass string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Admin reset password request", err,
+			map[string]interface{}{
+				"req":       userID,
+				"temporary": tempPass != "",
+				"took":      time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.AdminReset(c, userID, tempPass)
+}
+
+// UpdatePolicy logging
+func (ls *LogService) UpdatePolicy(c echo.Context, companyID int, history, maxAgeDays *int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Update password policy request", err,
+			map[string]interface{}{
+				"req":          companyID,
+				"history":      history,
+				"max_age_days": maxAgeDays,
+				"took":         time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.UpdatePolicy(c, companyID, history, maxAgeDays)
+}
+
+// Check logging
+func (ls *LogService) Check(c echo.Context, pass string, u gorsk.User) (f secure.Feedback, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Check password request", err,
+			map[string]interface{}{
+				"valid": f.Valid,
+				"took":  time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Check(c, pass, u)
+}
@@ -1,23 +1,37 @@
 package password
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/go-pg/pg/v9"
 	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 )
 
 // Custom errors
 var (
 	ErrIncorrectPassword = echo.NewHTTPError(http.StatusBadRequest, "incorrect old password")
-	ErrInsecurePassword  = echo.NewHTTPError(http.StatusBadRequest, "insecure password")
+	ErrInvalidResetToken = echo.NewHTTPError(http.StatusBadRequest, "invalid or expired password reset token")
+	ErrPasswordReused    = echo.NewHTTPError(http.StatusBadRequest, "password was used recently")
+	ErrInvalidPolicy     = echo.NewHTTPError(http.StatusBadRequest, "password policy values cannot be negative")
 )
 
-// Change changes user's password
+// Change changes user's password. Passwords cannot be changed while impersonating.
 func (p Password) Change(c echo.Context, userID int, oldPass, newPass string) error {
-	if err := p.rbac.EnforceUser(c, userID); err != nil {
+	if err := p.rbac.EnforceUser(c, gorsk.UsersUpdate, userID); err != nil {
 		return err
 	}
 
+	if p.rbac.User(c).ActorID != 0 {
+		return gorsk.ErrImpersonation
+	}
+
 	u, err := p.udb.View(p.db, userID)
 	if err != nil {
 		return err
@@ -27,11 +41,217 @@ func (p Password) Change(c echo.Context, userID int, oldPass, newPass string) er
 		return ErrIncorrectPassword
 	}
 
-	if !p.sec.Password(newPass, u.FirstName, u.LastName, u.Username, u.Email) {
-		return ErrInsecurePassword
+	if err := p.setPassword(&u, newPass); err != nil {
+		return err
 	}
 
-	u.ChangePassword(p.sec.Hash(newPass))
-
 	return p.udb.Update(p.db, u)
 }
+
+const resetBody = `Hi %s,
+
+someone requested a password reset for your account. To choose a new password, visit:
+
+%s
+
+The link expires in %d minutes and can be used only once. If you did not request a password reset, you can ignore this email.`
+
+// Forgot emails a one-time password reset link to the user with given email.
+// To avoid disclosing which emails are registered, unknown and inactive users are not reported.
+func (p Password) Forgot(c echo.Context, email string) error {
+	u, err := p.udb.FindByEmail(p.db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !u.Active {
+		return nil
+	}
+
+	return p.sendReset(u)
+}
+
+// AdminReset resets password of a user managed by the requesting admin, and revokes user's sessions.
+// Temporary password, if given, is set and has to be changed at next login. Otherwise, the user is emailed
+// a password reset link. Passwords cannot be reset while impersonating.
+func (p Password) AdminReset(c echo.Context, userID int, tempPass string) error {
+	if p.rbac.User(c).ActorID != 0 {
+		return gorsk.ErrImpersonation
+	}
+
+	u, err := p.udb.View(p.db, userID)
+	if err != nil {
+		return err
+	}
+	if err := p.rbac.Enforce(c, gorsk.UsersCredentials, u.Resource()); err != nil {
+		return err
+	}
+
+	if tempPass != "" {
+		if err := p.setPassword(&u, tempPass); err != nil {
+			return err
+		}
+		u.MustChangePassword = true
+		if err := p.udb.Update(p.db, u); err != nil {
+			return err
+		}
+		if err := p.rdb.DeleteByUser(p.db, u.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := p.sdb.DeleteByUser(p.db, u.ID); err != nil {
+		return err
+	}
+
+	if tempPass == "" {
+		return p.sendReset(u)
+	}
+	return nil
+}
+
+// sendReset emails the user a one-time password reset link
+func (p Password) sendReset(u gorsk.User) error {
+	token, err := secure.NewToken()
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(p.cfg.ResetURL)
+	if err != nil {
+		return err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	if _, err := p.rdb.Create(p.db, gorsk.PasswordReset{
+		UserID:    u.ID,
+		Hash:      secure.HashToken(token),
+		ExpiresAt: time.Now().Add(p.cfg.ResetDuration),
+	}); err != nil {
+		return err
+	}
+
+	return p.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf(resetBody, u.FirstName, link, int(p.cfg.ResetDuration.Minutes())),
+	})
+}
+
+// Reset sets user's password using a token from the password reset email.
+// Reset consumes all of user's reset tokens and revokes user's sessions.
+func (p Password) Reset(c echo.Context, token, newPass string) error {
+	r, err := p.rdb.FindByHash(p.db, secure.HashToken(token))
+	if err == pg.ErrNoRows {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if r.Expired(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	u, err := p.udb.View(p.db, r.UserID)
+	if err == pg.ErrNoRows {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if !u.Active {
+		return ErrInvalidResetToken
+	}
+
+	if err := p.setPassword(&u, newPass); err != nil {
+		return err
+	}
+
+	if err := p.rdb.DeleteByUser(p.db, u.ID); err != nil {
+		return err
+	}
+
+	if err := p.udb.Update(p.db, u); err != nil {
+		return err
+	}
+
+	return p.sdb.DeleteByUser(p.db, u.ID)
+}
+
+// UpdatePolicy overrides password history and maximum password age, in days, for users of a company.
+// Nil values fall back to the default policy. Admins can change the policy only for their own company.
+func (p Password) UpdatePolicy(c echo.Context, companyID int, history, maxAgeDays *int) error {
+	if err := p.rbac.Enforce(c, gorsk.CompaniesSecurity, gorsk.Resource{CompanyID: companyID}); err != nil {
+		return err
+	}
+
+	if (history != nil && *history < 0) || (maxAgeDays != nil && *maxAgeDays < 0) {
+		return ErrInvalidPolicy
+	}
+
+	company, err := p.cdb.View(p.db, companyID)
+	if err != nil {
+		return err
+	}
+
+	company.PasswordHistory = history
+	company.PasswordMaxAgeDays = maxAgeDays
+	return p.cdb.Update(p.db, company)
+}
+
+// Check checks the password against password policy and breached passwords, without setting it,
+// returning feedback UIs show while users choose their password. User's attributes are optional.
+func (p Password) Check(c echo.Context, pass string, u gorsk.User) (secure.Feedback, error) {
+	f := p.sec.CheckPassword(pass, u.FirstName, u.LastName, u.Username, u.Email)
+	breached, err := p.sec.Breached(pass)
+	if err != nil {
+		return secure.Feedback{}, err
+	}
+	if breached {
+		f.Valid = false
+		f.Reasons = append(f.Reasons, secure.Reason{Rule: secure.RuleBreached, Message: "Password has appeared in a data breach"})
+	}
+	return f, nil
+}
+
+// setPassword checks the new password against strength requirements and user's password history, and sets it
+func (p Password) setPassword(u *gorsk.User, pass string) error {
+	if err := p.sec.Password(pass, u.FirstName, u.LastName, u.Username, u.Email); err != nil {
+		return err
+	}
+
+	policy, err := p.policy(*u)
+	if err != nil {
+		return err
+	}
+	if policy.Reused(*u, pass, p.sec.HashMatchesPassword) {
+		return ErrPasswordReused
+	}
+
+	hash, err := p.sec.Hash(pass)
+	if err != nil {
+		return err
+	}
+	u.RetirePassword(policy.History - 1)
+	u.ChangePassword(hash)
+	return nil
+}
+
+// policy returns password policy applying to the user, with overrides of user's company
+func (p Password) policy(u gorsk.User) (gorsk.PasswordPolicy, error) {
+	if u.CompanyID == 0 {
+		return p.cfg.Policy, nil
+	}
+	company, err := p.cdb.View(p.db, u.CompanyID)
+	if err == pg.ErrNoRows {
+		return p.cfg.Policy, nil
+	}
+	if err != nil {
+		return gorsk.PasswordPolicy{}, err
+	}
+	return p.cfg.Policy.ForCompany(company), nil
+}
@@ -1,16 +1,21 @@
 package password_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/password"
 
+	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 	"github.com/labstack/echo"
 
+	"github.com/ribice/gorsk/pkg/utl/mail"
 	"github.com/ribice/gorsk/pkg/utl/mock"
 	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -33,18 +38,33 @@ func TestChange(t *testing.T) {
 			name: "Fail on EnforceUser",
 			args: args{id: 1},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: true,
 		},
+		{
+			name: "Fail on impersonation",
+			args: args{id: 1},
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
+					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, ActorID: 2}
+				}},
+			wantErr: true,
+		},
 		{
 			name:    "Fail on ViewUser",
 			args:    args{id: 1},
 			wantErr: true,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
 				}},
 			udb: &mockdb.User{
 				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
@@ -59,8 +79,11 @@ func TestChange(t *testing.T) {
 			name: "Fail on PasswordMatch",
 			args: args{id: 1, oldpass: "hunter123"},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
 				}},
 			wantErr: true,
 			udb: &mockdb.User{
@@ -80,8 +103,11 @@ func TestChange(t *testing.T) {
 			name: "Fail on InsecurePassword",
 			args: args{id: 1, oldpass: "hunter123"},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
 				}},
 			wantErr: true,
 			udb: &mockdb.User{
@@ -95,8 +121,8 @@ func TestChange(t *testing.T) {
 				HashMatchesPasswordFn: func(string, string) bool {
 					return true
 				},
-				PasswordFn: func(string, ...string) bool {
-					return false
+				PasswordFn: func(string, ...string) error {
+					return gorsk.ErrInsecurePassword
 				},
 			},
 		},
@@ -104,8 +130,11 @@ func TestChange(t *testing.T) {
 			name: "Success",
 			args: args{id: 1, oldpass: "hunter123", newpass: "password"},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
 				}},
 			udb: &mockdb.User{
 				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
@@ -121,21 +150,614 @@ func TestChange(t *testing.T) {
 				HashMatchesPasswordFn: func(string, string) bool {
 					return true
 				},
-				PasswordFn: func(string, ...string) bool {
-					return true
+				PasswordFn: func(string, ...string) error {
+					return nil
 				},
-				HashFn: func(string) string {
-					return "hash3d"
+				HashFn: func(string) (string, error) {
+					return "hash3d", nil
 				},
 			},
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := password.New(nil, tt.udb, tt.rbac, tt.sec)
+			s := password.New(nil, tt.udb, nil, nil, nil, tt.rbac, tt.sec, nil, password.Config{})
 			err := s.Change(nil, tt.args.id, tt.args.oldpass, tt.args.newpass)
 			assert.Equal(t, tt.wantErr, err != nil)
 			// Check whether password was changed
 		})
 	}
 }
+
+func TestForgot(t *testing.T) {
+	cfg := password.Config{ResetDuration: 30 * time.Minute, ResetURL: "https://gorsk.dev/reset"}
+	cases := []struct {
+		name     string
+		email    string
+		wantErr  bool
+		wantSent bool
+		udb      *mockdb.User
+		rdb      *mockdb.PasswordReset
+		mailer   *mock.Mailer
+	}{
+		{
+			name:  "Unknown email",
+			email: "nobody@mail.com",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Fail on FindByEmail",
+			email:   "johndoe@mail.com",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:  "Inactive user",
+			email: "johndoe@mail.com",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on Create",
+			email:   "johndoe@mail.com",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true}, nil
+				},
+			},
+			rdb: &mockdb.PasswordReset{
+				CreateFn: func(orm.DB, gorsk.PasswordReset) (gorsk.PasswordReset, error) {
+					return gorsk.PasswordReset{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:     "Success",
+			email:    "johndoe@mail.com",
+			wantSent: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", FirstName: "John", Active: true}, nil
+				},
+			},
+			rdb: &mockdb.PasswordReset{
+				CreateFn: func(db orm.DB, r gorsk.PasswordReset) (gorsk.PasswordReset, error) {
+					if r.UserID != 1 || len(r.Hash) != 64 || r.ExpiresAt.Before(time.Now().Add(29*time.Minute)) {
+						t.Errorf("unexpected password reset: %+v", r)
+					}
+					return r, nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []mail.Message
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+			s := password.New(nil, tt.udb, tt.rdb, nil, nil, nil, nil, mailer, cfg)
+			err := s.Forgot(nil, tt.email)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantSent {
+				assert.Empty(t, sent)
+				return
+			}
+			assert.Len(t, sent, 1)
+			assert.Equal(t, tt.email, sent[0].To)
+			assert.Contains(t, sent[0].Body, "https://gorsk.dev/reset?token=")
+			assert.Contains(t, sent[0].Body, "30 minutes")
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	reset := gorsk.PasswordReset{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	user := gorsk.User{Base: gorsk.Base{ID: 1}, Active: true}
+	cases := []struct {
+		name    string
+		token   string
+		wantErr error
+		udb     *mockdb.User
+		rdb     *mockdb.PasswordReset
+		sdb     *mockdb.Session
+		sec     *mock.Secure
+	}{
+		{
+			name:    "Unknown token",
+			token:   "token",
+			wantErr: password.ErrInvalidResetToken,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return gorsk.PasswordReset{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Expired token",
+			token:   "token",
+			wantErr: password.ErrInvalidResetToken,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return gorsk.PasswordReset{UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}, nil
+				},
+			},
+		},
+		{
+			name:    "Inactive user",
+			token:   "token",
+			wantErr: password.ErrInvalidResetToken,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return reset, nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}}, nil
+				},
+			},
+		},
+		{
+			name:    "Insecure password",
+			token:   "token",
+			wantErr: gorsk.ErrInsecurePassword,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return reset, nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return user, nil
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return gorsk.ErrInsecurePassword
+				},
+			},
+		},
+		{
+			name:    "Breached password",
+			token:   "token",
+			wantErr: gorsk.ErrBreachedPassword,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return reset, nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return user, nil
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return gorsk.ErrBreachedPassword
+				},
+			},
+		},
+		{
+			name:    "Fail on revoking sessions",
+			token:   "token",
+			wantErr: gorsk.ErrGeneric,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return reset, nil
+				},
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return user, nil
+				},
+				UpdateFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteByUserFn: func(orm.DB, int) error {
+					return gorsk.ErrGeneric
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "hash3d", nil
+				},
+			},
+		},
+		{
+			name:  "Success",
+			token: "token",
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(db orm.DB, hash string) (gorsk.PasswordReset, error) {
+					if hash == "token" || len(hash) != 64 || strings.ToLower(hash) != hash {
+						t.Errorf("expected token's hex hash, received %s", hash)
+					}
+					return reset, nil
+				},
+				DeleteByUserFn: func(db orm.DB, id int) error {
+					assert.Equal(t, 1, id)
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return user, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					assert.Equal(t, "hash3d", u.Password)
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteByUserFn: func(db orm.DB, id int) error {
+					assert.Equal(t, 1, id)
+					return nil
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "hash3d", nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := password.New(nil, tt.udb, tt.rdb, tt.sdb, nil, nil, tt.sec, nil, password.Config{})
+			err := s.Reset(nil, tt.token, "newpassword")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestChangeHistory(t *testing.T) {
+	history := 3
+	cases := []struct {
+		name        string
+		newpass     string
+		cdb         *mockdb.Company
+		wantErr     error
+		wantHistory []string
+	}{
+		{
+			name:    "Fail on reused password",
+			newpass: "second",
+			wantErr: password.ErrPasswordReused,
+		},
+		{
+			name:        "Success with password older than history",
+			newpass:     "first",
+			wantHistory: []string{"third"},
+		},
+		{
+			name:    "Fail on reused password with company override",
+			newpass: "first",
+			cdb: &mockdb.Company{
+				ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+					return gorsk.Company{PasswordHistory: &history}, nil
+				},
+			},
+			wantErr: password.ErrPasswordReused,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated gorsk.User
+			companyID := 0
+			if tt.cdb != nil {
+				companyID = 1
+			}
+			udb := &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Password: "third", PasswordHistory: []string{"second", "first"}, CompanyID: companyID}, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					updated = u
+					return nil
+				},
+			}
+			rbac := &mock.RBAC{
+				EnforceUserFn: func(echo.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				},
+			}
+			sec := &mock.Secure{
+				HashMatchesPasswordFn: func(hash, pass string) bool {
+					return hash == pass || pass == "hunter123"
+				},
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(pass string) (string, error) {
+					return pass, nil
+				},
+			}
+			s := password.New(nil, udb, nil, nil, tt.cdb, rbac, sec, nil, password.Config{Policy: gorsk.PasswordPolicy{History: 2}})
+			err := s.Change(nil, 1, "hunter123", tt.newpass)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.newpass, updated.Password)
+				assert.Equal(t, tt.wantHistory, updated.PasswordHistory)
+			}
+		})
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	history, negative := 5, -1
+	cases := []struct {
+		name       string
+		companyID  int
+		history    *int
+		maxAgeDays *int
+		forbidden  bool
+		wantErr    error
+	}{
+		{
+			name:      "Fail on permission",
+			companyID: 2,
+			forbidden: true,
+			wantErr:   echo.ErrForbidden,
+		},
+		{
+			name:       "Fail on negative value",
+			companyID:  1,
+			maxAgeDays: &negative,
+			wantErr:    password.ErrInvalidPolicy,
+		},
+		{
+			name:      "Success",
+			companyID: 1,
+			history:   &history,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.Company
+			cdb := &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (gorsk.Company, error) {
+					return gorsk.Company{Base: gorsk.Base{ID: id}, PasswordMaxAgeDays: &history}, nil
+				},
+				UpdateFn: func(db orm.DB, c gorsk.Company) error {
+					updated = &c
+					return nil
+				},
+			}
+			rbac := &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					assert.Equal(t, gorsk.CompaniesSecurity, p)
+					assert.Equal(t, tt.companyID, r.CompanyID)
+					if tt.forbidden {
+						return echo.ErrForbidden
+					}
+					return nil
+				},
+			}
+			s := password.New(nil, nil, nil, nil, cdb, rbac, nil, nil, password.Config{})
+			err := s.UpdatePolicy(nil, tt.companyID, tt.history, tt.maxAgeDays)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, updated)
+				return
+			}
+			if assert.NotNil(t, updated) {
+				assert.Equal(t, tt.history, updated.PasswordHistory)
+				assert.Nil(t, updated.PasswordMaxAgeDays, "omitted values fall back to the default policy")
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	weak := secure.Feedback{
+		Score:       0,
+		Reasons:     []secure.Reason{{Rule: secure.RuleScore, Message: "Password is too easy to guess"}},
+		Suggestions: []string{"Add another word or two. Uncommon words are better."},
+	}
+	cases := []struct {
+		name     string
+		pass     string
+		wantErr  error
+		wantData secure.Feedback
+	}{
+		{
+			name:    "Fail on breached password lookup",
+			pass:    "unavailable",
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name:     "Weak password",
+			pass:     "johndoe",
+			wantData: weak,
+		},
+		{
+			name: "Breached password",
+			pass: "Thranduil8822",
+			wantData: secure.Feedback{
+				Score:   4,
+				Reasons: []secure.Reason{{Rule: secure.RuleBreached, Message: "Password has appeared in a data breach"}},
+			},
+		},
+		{
+			name:     "Strong password",
+			pass:     "callgophers-4-lunch",
+			wantData: secure.Feedback{Valid: true, Score: 4},
+		},
+	}
+	sec := &mock.Secure{
+		CheckPasswordFn: func(pass string, inputs ...string) secure.Feedback {
+			assert.Equal(t, []string{"John", "Doe", "johndoe", "johndoe@mail.com"}, inputs)
+			if pass == "johndoe" {
+				return weak
+			}
+			return secure.Feedback{Valid: true, Score: 4}
+		},
+		BreachedFn: func(pass string) (bool, error) {
+			if pass == "unavailable" {
+				return false, gorsk.ErrGeneric
+			}
+			return pass == "Thranduil8822", nil
+		},
+	}
+	s := password.New(nil, nil, nil, nil, nil, nil, sec, nil, password.Config{})
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := s.Check(nil, tt.pass, gorsk.User{FirstName: "John", LastName: "Doe", Username: "johndoe", Email: "johndoe@mail.com"})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, f)
+		})
+	}
+}
+
+func TestAdminReset(t *testing.T) {
+	user := gorsk.User{Base: gorsk.Base{ID: 2}, Email: "johndoe@mail.com", Password: "old", RoleID: gorsk.UserRole, CompanyID: 1, LocationID: 3, Active: true}
+	cases := []struct {
+		name        string
+		tempPass    string
+		actorID     int
+		inScope     bool
+		wantErr     error
+		wantUpdated bool
+		wantSent    bool
+	}{
+		{
+			name:     "Fail on impersonation",
+			tempPass: "Thranduil8822",
+			actorID:  1,
+			wantErr:  gorsk.ErrImpersonation,
+		},
+		{
+			name:     "Fail on scope",
+			tempPass: "Thranduil8822",
+			wantErr:  echo.ErrForbidden,
+		},
+		{
+			name:     "Fail on insecure temporary password",
+			tempPass: "weak",
+			inScope:  true,
+			wantErr:  gorsk.ErrInsecurePassword,
+		},
+		{
+			name:        "Success with temporary password",
+			tempPass:    "Thranduil8822",
+			inScope:     true,
+			wantUpdated: true,
+		},
+		{
+			name:     "Success with reset email",
+			inScope:  true,
+			wantSent: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				updated                        []gorsk.User
+				sent                           []mail.Message
+				resetsDeleted, sessionsDeleted bool
+			)
+			rbac := &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, ActorID: tt.actorID}
+				},
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					assert.Equal(t, gorsk.UsersCredentials, p)
+					assert.Equal(t, user.Resource(), r)
+					if !tt.inScope {
+						return echo.ErrForbidden
+					}
+					return nil
+				},
+			}
+			udb := &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return user, nil
+				},
+				UpdateFn: func(db orm.DB, u gorsk.User) error {
+					updated = append(updated, u)
+					return nil
+				},
+			}
+			rdb := &mockdb.PasswordReset{
+				CreateFn: func(db orm.DB, r gorsk.PasswordReset) (gorsk.PasswordReset, error) {
+					assert.Equal(t, 2, r.UserID)
+					return r, nil
+				},
+				DeleteByUserFn: func(db orm.DB, id int) error {
+					resetsDeleted = id == 2
+					return nil
+				},
+			}
+			sdb := &mockdb.Session{
+				DeleteByUserFn: func(db orm.DB, id int) error {
+					sessionsDeleted = id == 2
+					return nil
+				},
+			}
+			cdb := &mockdb.Company{
+				ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+					return gorsk.Company{}, pg.ErrNoRows
+				},
+			}
+			sec := &mock.Secure{
+				PasswordFn: func(pass string, _ ...string) error {
+					if pass == "weak" {
+						return gorsk.ErrInsecurePassword
+					}
+					return nil
+				},
+				HashMatchesPasswordFn: func(hash, pass string) bool {
+					return hash == pass
+				},
+				HashFn: func(string) (string, error) {
+					return "hash3d", nil
+				},
+			}
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+
+			s := password.New(nil, udb, rdb, sdb, cdb, rbac, sec, mailer, password.Config{ResetURL: "https://gorsk.dev/reset"})
+			err := s.AdminReset(nil, 2, tt.tempPass)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, sessionsDeleted)
+			assert.Equal(t, tt.wantUpdated, resetsDeleted)
+			if assert.Equal(t, tt.wantUpdated, len(updated) == 1) && tt.wantUpdated {
+				assert.Equal(t, "hash3d", updated[0].Password)
+				assert.True(t, updated[0].MustChangePassword)
+			}
+			if assert.Equal(t, tt.wantSent, len(sent) == 1) && tt.wantSent {
+				assert.Equal(t, "johndoe@mail.com", sent[0].To)
+				assert.Contains(t, sent[0].Body, "https://gorsk.dev/reset?token=")
+			}
+		})
+	}
+}
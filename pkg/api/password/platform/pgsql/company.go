@@ -0,0 +1,23 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Company represents the client for company table
+type Company struct{}
+
+// View returns single company by ID
+func (c Company) View(db orm.DB, id int) (gorsk.Company, error) {
+	company := gorsk.Company{Base: gorsk.Base{ID: id}}
+	err := db.Model(&company).WherePK().Select()
+	return company, err
+}
+
+// Update updates company's password policy overrides
+func (c Company) Update(db orm.DB, company gorsk.Company) error {
+	_, err := db.Model(&company).Column("password_history", "password_max_age_days", "updated_at").WherePK().Update()
+	return err
+}
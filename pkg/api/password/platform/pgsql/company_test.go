@@ -0,0 +1,42 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/password/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyUpdate(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	maxAge := 30
+	if err := mock.InsertMultiple(db, &gorsk.Company{
+		Base:               gorsk.Base{ID: 1},
+		Name:               "admin_company",
+		Active:             true,
+		PasswordMaxAgeDays: &maxAge,
+	}); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.Company{}
+
+	history := 0
+	err := cdb.Update(db, gorsk.Company{Base: gorsk.Base{ID: 1}, Name: "changed", PasswordHistory: &history})
+	assert.Nil(t, err)
+
+	company, err := cdb.View(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &history, company.PasswordHistory)
+	assert.Nil(t, company.PasswordMaxAgeDays)
+	assert.Equal(t, "admin_company", company.Name)
+}
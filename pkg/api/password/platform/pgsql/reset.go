@@ -0,0 +1,29 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Reset represents the client for password_reset table
+type Reset struct{}
+
+// Create creates a new password reset token
+func (r Reset) Create(db orm.DB, reset gorsk.PasswordReset) (gorsk.PasswordReset, error) {
+	err := db.Insert(&reset)
+	return reset, err
+}
+
+// FindByHash returns password reset token by its hash
+func (r Reset) FindByHash(db orm.DB, hash string) (gorsk.PasswordReset, error) {
+	var reset gorsk.PasswordReset
+	err := db.Model(&reset).Where("hash = ?", hash).Select()
+	return reset, err
+}
+
+// DeleteByUser deletes all of user's password reset tokens
+func (r Reset) DeleteByUser(db orm.DB, userID int) error {
+	_, err := db.Model((*gorsk.PasswordReset)(nil)).Where("user_id = ?", userID).Delete()
+	return err
+}
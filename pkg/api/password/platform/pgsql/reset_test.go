@@ -0,0 +1,44 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/password/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResetDeleteByUser(t *testing.T) {
+	resets := []gorsk.PasswordReset{
+		{Base: gorsk.Base{ID: 1}, UserID: 1, Hash: "a", ExpiresAt: time.Now().Add(time.Hour)},
+		{Base: gorsk.Base{ID: 2}, UserID: 1, Hash: "b", ExpiresAt: time.Now().Add(time.Hour)},
+		{Base: gorsk.Base{ID: 3}, UserID: 2, Hash: "c", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.PasswordReset{})
+
+	for i := range resets {
+		if err := mock.InsertMultiple(db, &resets[i]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	rdb := pgsql.Reset{}
+
+	assert.Nil(t, rdb.DeleteByUser(db, 1))
+
+	_, err := rdb.FindByHash(db, "a")
+	assert.NotNil(t, err)
+	_, err = rdb.FindByHash(db, "b")
+	assert.NotNil(t, err)
+
+	reset, err := rdb.FindByHash(db, "c")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, reset.UserID)
+}
@@ -20,3 +20,10 @@ func (u User) View(db orm.DB, id int) (gorsk.User, error) {
 func (u User) Update(db orm.DB, user gorsk.User) error {
 	return db.Update(&user)
 }
+
+// FindByEmail queries for single user by email, case insensitive
+func (u User) FindByEmail(db orm.DB, email string) (gorsk.User, error) {
+	var user gorsk.User
+	err := db.Model(&user).Where("lower(email) = lower(?)", email).Select()
+	return user, err
+}
@@ -1,56 +1,113 @@
 package password
 
 import (
+	"time"
+
 	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/password/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 )
 
 // Service represents password application interface
 type Service interface {
 	Change(echo.Context, int, string, string) error
+	Forgot(echo.Context, string) error
+	Reset(echo.Context, string, string) error
+	AdminReset(echo.Context, int, string) error
+	UpdatePolicy(echo.Context, int, *int, *int) error
+	Check(echo.Context, string, gorsk.User) (secure.Feedback, error)
 }
 
 // New creates new password application service
-func New(db *pg.DB, udb UserDB, rbac RBAC, sec Securer) Password {
+func New(db *pg.DB, udb UserDB, rdb ResetDB, sdb SessionDB, cdb CompanyDB, rbac RBAC, sec Securer, mailer Mailer, cfg Config) Password {
 	return Password{
-		db:   db,
-		udb:  udb,
-		rbac: rbac,
-		sec:  sec,
+		db:     db,
+		udb:    udb,
+		rdb:    rdb,
+		sdb:    sdb,
+		cdb:    cdb,
+		rbac:   rbac,
+		sec:    sec,
+		mailer: mailer,
+		cfg:    cfg,
 	}
 }
 
 // Initialize initalizes password application service with defaults
-func Initialize(db *pg.DB, rbac RBAC, sec Securer) Password {
-	return New(db, pgsql.User{}, rbac, sec)
+func Initialize(db *pg.DB, rbac RBAC, sec Securer, mailer Mailer, cfg Config) Password {
+	return New(db, pgsql.User{}, pgsql.Reset{}, pgsql.Session{}, pgsql.Company{}, rbac, sec, mailer, cfg)
 }
 
 // Password represents password application service
 type Password struct {
-	db   *pg.DB
-	udb  UserDB
-	rbac RBAC
-	sec  Securer
+	db     *pg.DB
+	udb    UserDB
+	rdb    ResetDB
+	sdb    SessionDB
+	cdb    CompanyDB
+	rbac   RBAC
+	sec    Securer
+	mailer Mailer
+	cfg    Config
+}
+
+// Config holds password reset and lifecycle settings
+type Config struct {
+	// ResetDuration is how long reset tokens stay valid
+	ResetDuration time.Duration
+	// ResetURL is the page users are sent to, with the reset token appended as token query parameter
+	ResetURL string
+	// Policy is the default password policy, overridable per company
+	Policy gorsk.PasswordPolicy
 }
 
 // UserDB represents user repository interface
 type UserDB interface {
 	View(orm.DB, int) (gorsk.User, error)
+	FindByEmail(orm.DB, string) (gorsk.User, error)
 	Update(orm.DB, gorsk.User) error
 }
 
+// ResetDB represents password reset token repository interface
+type ResetDB interface {
+	Create(orm.DB, gorsk.PasswordReset) (gorsk.PasswordReset, error)
+	FindByHash(orm.DB, string) (gorsk.PasswordReset, error)
+	DeleteByUser(orm.DB, int) error
+}
+
+// SessionDB represents session repository interface
+type SessionDB interface {
+	DeleteByUser(orm.DB, int) error
+}
+
+// CompanyDB represents company repository interface
+type CompanyDB interface {
+	View(orm.DB, int) (gorsk.Company, error)
+	Update(orm.DB, gorsk.Company) error
+}
+
 // Securer represents security interface
 type Securer interface {
-	Hash(string) string
+	Breached(string) (bool, error)
+	CheckPassword(string, ...string) secure.Feedback
+	Hash(string) (string, error)
 	HashMatchesPassword(string, string) bool
-	Password(string, ...string) bool
+	Password(string, ...string) error
 }
 
 // RBAC represents role-based-access-control interface
 type RBAC interface {
-	EnforceUser(echo.Context, int) error
+	User(echo.Context) gorsk.AuthUser
+	Enforce(echo.Context, gorsk.Permission, gorsk.Resource) error
+	EnforceUser(echo.Context, gorsk.Permission, int) error
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
 }
@@ -15,9 +15,71 @@ type HTTP struct {
 	svc password.Service
 }
 
-// NewHTTP creates new password http service
-func NewHTTP(svc password.Service, er *echo.Group) {
+// NewHTTP creates new password http service. Password reset routes are registered on e,
+// as they are used by users who cannot log in.
+func NewHTTP(svc password.Service, e *echo.Echo, er *echo.Group) {
 	h := HTTP{svc}
+
+	// swagger:operation POST /password/forgot password pwForgot
+	// ---
+	// summary: Requests password reset email.
+	// description: Emails a one-time password reset link to the user. The response does not reveal whether the email is registered.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwForgot"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/password/forgot", h.forgot)
+
+	// swagger:operation POST /password/reset password pwReset
+	// ---
+	// summary: Resets user's password.
+	// description: Sets new password using the token from password reset email. The token can be used only once, and all of user's sessions are revoked.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwReset"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/password/reset", h.reset)
+
+	// swagger:operation POST /password/check password pwCheck
+	// ---
+	// summary: Checks password strength.
+	// description: Checks the password against password policy and breached passwords without setting it, returning reasons it would be rejected and suggestions on improving it. User's name, username and email are optional, and make passwords containing them weaker.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwCheck"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/pwCheckResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/password/check", h.check)
+
 	pr := er.Group("/password")
 
 	// swagger:operation PATCH /v1/password/{id} password pwChange
@@ -48,6 +110,64 @@ func NewHTTP(svc password.Service, er *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	pr.PATCH("/:id", h.change)
+
+	// swagger:operation POST /v1/users/{id}/password-reset password pwAdminReset
+	// ---
+	// summary: Resets password of a managed user.
+	// description: Sets a temporary password the user has to change at next login, or emails the user a password reset link if it is omitted. Admins can reset passwords of users their users:credentials permission covers. All of user's sessions are revoked.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwAdminReset"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.POST("/users/:id/password-reset", h.adminReset)
+
+	// swagger:operation PUT /v1/companies/{id}/password-policy password pwPolicy
+	// ---
+	// summary: Sets company's password policy
+	// description: Overrides password history and maximum password age for all users of a company. Omitted or null values fall back to the default policy. Admins can change it only for their own company.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwPolicy"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.PUT("/companies/:id/password-policy", h.policy)
 }
 
 // Custom errors
@@ -59,9 +179,9 @@ var (
 // swagger:model pwChange
 type changeReq struct {
 	ID                 int    `json:"-"`
-	OldPassword        string `json:"old_password" validate:"required,min=8"`
-	NewPassword        string `json:"new_password" validate:"required,min=8"`
-	NewPasswordConfirm string `json:"new_password_confirm" validate:"required"`
+	OldPassword        string `json:"old_password" validate:"required,max=128"`
+	NewPassword        string `json:"new_password" validate:"required,max=128"`
+	NewPasswordConfirm string `json:"new_password_confirm" validate:"required,max=128"`
 }
 
 func (h *HTTP) change(c echo.Context) error {
@@ -85,3 +205,121 @@ func (h *HTTP) change(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+// Password reset email request
+// swagger:model pwForgot
+type forgotReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *HTTP) forgot(c echo.Context) error {
+	r := new(forgotReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.Forgot(c, r.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Password reset request
+// swagger:model pwReset
+type resetReq struct {
+	Token              string `json:"token" validate:"required"`
+	NewPassword        string `json:"new_password" validate:"required,max=128"`
+	NewPasswordConfirm string `json:"new_password_confirm" validate:"required,max=128"`
+}
+
+func (h *HTTP) reset(c echo.Context) error {
+	r := new(resetReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if r.NewPassword != r.NewPasswordConfirm {
+		return ErrPasswordsNotMaching
+	}
+
+	if err := h.svc.Reset(c, r.Token, r.NewPassword); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Admin password reset request
+// swagger:model pwAdminReset
+type adminResetReq struct {
+	// TemporaryPassword is omitted to email the user a password reset link instead
+	TemporaryPassword string `json:"temporary_password" validate:"omitempty,max=128"`
+}
+
+func (h *HTTP) adminReset(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	r := new(adminResetReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.AdminReset(c, id, r.TemporaryPassword); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Password check request
+// swagger:model pwCheck
+type checkReq struct {
+	Password  string `json:"password" validate:"required,max=128"`
+	FirstName string `json:"first_name" validate:"max=128"`
+	LastName  string `json:"last_name" validate:"max=128"`
+	Username  string `json:"username" validate:"max=128"`
+	Email     string `json:"email" validate:"max=254"`
+}
+
+func (h *HTTP) check(c echo.Context) error {
+	r := new(checkReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	f, err := h.svc.Check(c, r.Password, gorsk.User{FirstName: r.FirstName, LastName: r.LastName, Username: r.Username, Email: r.Email})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, f)
+}
+
+// Password policy request
+// swagger:model pwPolicy
+type policyReq struct {
+	History    *int `json:"history" validate:"omitempty,min=0"`
+	MaxAgeDays *int `json:"max_age_days" validate:"omitempty,min=0"`
+}
+
+func (h *HTTP) policy(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	r := new(policyReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.UpdatePolicy(c, id, r.History, r.MaxAgeDays); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -2,18 +2,24 @@ package transport_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/api/password"
 	"github.com/ribice/gorsk/pkg/api/password/transport"
 
+	"github.com/ribice/gorsk/pkg/utl/mail"
 	"github.com/ribice/gorsk/pkg/utl/mock"
 	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 	"github.com/ribice/gorsk/pkg/utl/server"
 
+	"github.com/go-pg/pg/v9"
 	"github.com/go-pg/pg/v9/orm"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
@@ -36,7 +42,7 @@ func TestChangePassword(t *testing.T) {
 		},
 		{
 			name:       "Fail on Bind",
-			req:        `{"new_password":"new","old_password":"my_old_password", "new_password_confirm":"new"}`,
+			req:        `{"old_password":"my_old_password", "new_password_confirm":"new"}`,
 			wantStatus: http.StatusBadRequest,
 			id:         "1",
 		},
@@ -50,7 +56,7 @@ func TestChangePassword(t *testing.T) {
 			name: "Fail on RBAC",
 			req:  `{"new_password":"newpassw","old_password":"oldpassw", "new_password_confirm":"newpassw"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -61,9 +67,12 @@ func TestChangePassword(t *testing.T) {
 			name: "Success",
 			req:  `{"new_password":"newpassw","old_password":"oldpassw", "new_password_confirm":"newpassw"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return nil
 				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				},
 			},
 			id: "1",
 			udb: &mockdb.User{
@@ -78,11 +87,11 @@ func TestChangePassword(t *testing.T) {
 				HashMatchesPasswordFn: func(string, string) bool {
 					return true
 				},
-				PasswordFn: func(string, ...string) bool {
-					return true
+				PasswordFn: func(string, ...string) error {
+					return nil
 				},
-				HashFn: func(string) string {
-					return "hashedPassword"
+				HashFn: func(string) (string, error) {
+					return "hashedPassword", nil
 				},
 			},
 			wantStatus: http.StatusOK,
@@ -95,7 +104,7 @@ func TestChangePassword(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(password.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(password.New(nil, tt.udb, nil, nil, nil, tt.rbac, tt.sec, nil, password.Config{}), r, rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/password/" + tt.id
@@ -113,3 +122,365 @@ func TestChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestForgot(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"johndoe"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown email",
+			req:  `{"email":"johndoe@mail.com"}`,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(password.New(nil, tt.udb, nil, nil, nil, nil, nil, nil, password.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/password/forgot", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+		rdb        *mockdb.PasswordReset
+		sdb        *mockdb.Session
+		sec        *mock.Secure
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"token":"token","new_password_confirm":"new"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Different passwords",
+			req:        `{"token":"token","new_password":"new_password","new_password_confirm":"new_password_cf"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid token",
+			req:  `{"token":"token","new_password":"newpassw","new_password_confirm":"newpassw"}`,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return gorsk.PasswordReset{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"token":"token","new_password":"newpassw","new_password_confirm":"newpassw"}`,
+			rdb: &mockdb.PasswordReset{
+				FindByHashFn: func(orm.DB, string) (gorsk.PasswordReset, error) {
+					return gorsk.PasswordReset{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+				},
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Active: true}, nil
+				},
+				UpdateFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "hashedPassword", nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(password.New(nil, tt.udb, tt.rdb, tt.sdb, nil, nil, tt.sec, nil, password.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/password/reset", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			req:        `{"history":5}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on validation",
+			id:         "1",
+			req:        `{"history":-1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on RBAC",
+			id:         "2",
+			req:        `{"history":5}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			req:        `{"history":5,"max_age_days":null}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := &http.Client{}
+	cdb := &mockdb.Company{
+		ViewFn: func(orm.DB, int) (gorsk.Company, error) {
+			return gorsk.Company{}, nil
+		},
+		UpdateFn: func(orm.DB, gorsk.Company) error {
+			return nil
+		},
+	}
+	rbac := &mock.RBAC{
+		EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+			if r.CompanyID != 1 {
+				return echo.ErrForbidden
+			}
+			return nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(password.New(nil, nil, nil, nil, cdb, rbac, nil, nil, password.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("PUT", ts.URL+"/v1/companies/"+tt.id+"/password-policy", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantData   *secure.Feedback
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"username":"johndoe"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on too long password",
+			req:        `{"password":"` + strings.Repeat("a", 129) + `","username":"johndoe"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Weak password",
+			req:        `{"password":"johndoe1","username":"johndoe"}`,
+			wantStatus: http.StatusOK,
+			wantData: &secure.Feedback{
+				Reasons:     []secure.Reason{{Rule: secure.RuleSimilarity, Message: "Password must not contain your name, username or email address"}},
+				Warning:     "Passwords containing your personal information are easy to guess",
+				Suggestions: []string{"Add another word or two. Uncommon words are better."},
+			},
+		},
+		{
+			name:       "Strong password",
+			req:        `{"password":"Thranduil8822","username":"johndoe"}`,
+			wantStatus: http.StatusOK,
+			wantData:   &secure.Feedback{Valid: true, Score: 4},
+		},
+	}
+
+	sec := &mock.Secure{
+		CheckPasswordFn: func(pass string, inputs ...string) secure.Feedback {
+			return secure.NewPolicy(secure.NotSimilar()).Check(pass, inputs...)
+		},
+		BreachedFn: func(string) (bool, error) {
+			return false, nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(password.New(nil, nil, nil, nil, nil, nil, sec, nil, password.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/password/check", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantData != nil {
+				f := new(secure.Feedback)
+				if err := json.NewDecoder(res.Body).Decode(f); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantData, f)
+			}
+		})
+	}
+}
+
+func TestAdminReset(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on scope",
+			id:         "3",
+			req:        `{}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success with temporary password",
+			id:         "2",
+			req:        `{"temporary_password":"Thranduil8822"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Success with reset email",
+			id:         "2",
+			req:        `{}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := &http.Client{}
+	udb := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+			return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.UserRole, LocationID: id}, nil
+		},
+		UpdateFn: func(orm.DB, gorsk.User) error {
+			return nil
+		},
+	}
+	rdb := &mockdb.PasswordReset{
+		CreateFn: func(db orm.DB, r gorsk.PasswordReset) (gorsk.PasswordReset, error) {
+			return r, nil
+		},
+		DeleteByUserFn: func(orm.DB, int) error {
+			return nil
+		},
+	}
+	sdb := &mockdb.Session{
+		DeleteByUserFn: func(orm.DB, int) error {
+			return nil
+		},
+	}
+	rbac := &mock.RBAC{
+		UserFn: func(echo.Context) gorsk.AuthUser {
+			return gorsk.AuthUser{ID: 1, Role: gorsk.LocationAdminRole, LocationID: 2}
+		},
+		EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+			if r.LocationID != 2 {
+				return echo.ErrForbidden
+			}
+			return nil
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(string, ...string) error {
+			return nil
+		},
+		HashMatchesPasswordFn: func(string, string) bool {
+			return false
+		},
+		HashFn: func(string) (string, error) {
+			return "hash3d", nil
+		},
+	}
+	mailer := &mock.Mailer{SendFn: func(mail.Message) error {
+		return nil
+	}}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(password.New(nil, udb, rdb, sdb, nil, rbac, sec, mailer, password.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/v1/users/"+tt.id+"/password-reset", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package transport
+
+import (
+	"github.com/ribice/gorsk/pkg/utl/secure"
+)
+
+// Password check response
+// swagger:response pwCheckResp
+type swaggPwCheckResponse struct {
+	// in:body
+	Body struct {
+		*secure.Feedback
+	}
+}
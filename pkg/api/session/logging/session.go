@@ -0,0 +1,56 @@
+package session
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/session"
+)
+
+// New creates new session logging service
+func New(svc session.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents session logging service
+type LogService struct {
+	session.Service
+	logger gorsk.Logger
+}
+
+const name = "session"
+
+// List logging
+func (ls *LogService) List(c echo.Context) (resp []gorsk.Session, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "List sessions request", err,
+			map[string]interface{}{
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.List(c)
+}
+
+// Revoke logging
+func (ls *LogService) Revoke(c echo.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Revoke session request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Revoke(c, req)
+}
@@ -0,0 +1,33 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Session represents the client for session table
+type Session struct{}
+
+// View returns single active session by ID
+func (s Session) View(db orm.DB, id int) (gorsk.Session, error) {
+	sess := gorsk.Session{Base: gorsk.Base{ID: id}}
+	err := db.Model(&sess).WherePK().Select()
+	return sess, err
+}
+
+// List returns user's active sessions, most recently used first.
+// Sessions whose refresh token or absolute lifetime expired are left out.
+func (s Session) List(db orm.DB, userID int) ([]gorsk.Session, error) {
+	var sessions []gorsk.Session
+	err := db.Model(&sessions).Where("user_id = ?", userID).
+		Where("expires_at IS NULL OR expires_at > now()").
+		Where("token_expires_at IS NULL OR token_expires_at > now()").
+		Order("last_used_at desc").Select()
+	return sessions, err
+}
+
+// Delete revokes a session
+func (s Session) Delete(db orm.DB, sess gorsk.Session) error {
+	return db.Delete(&sess)
+}
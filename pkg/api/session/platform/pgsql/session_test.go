@@ -0,0 +1,108 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/session/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		id       int
+		wantData gorsk.Session
+	}{
+		{
+			name:    "Session does not exist",
+			wantErr: true,
+			id:      1000,
+		},
+		{
+			name: "Success",
+			id:   1,
+			wantData: gorsk.Session{
+				Base:   gorsk.Base{ID: 1},
+				UserID: 1,
+				Device: "Mac",
+				Token:  "family.token",
+				Family: "family",
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	if err := mock.InsertMultiple(db, &cases[1].wantData); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := sdb.View(db, tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData.ID != 0 {
+				tt.wantData.CreatedAt = sess.CreatedAt
+				tt.wantData.UpdatedAt = sess.UpdatedAt
+				assert.Equal(t, tt.wantData, sess)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	sessions := []gorsk.Session{
+		{Base: gorsk.Base{ID: 1}, UserID: 1, Device: "Mac", Token: "a.a", Family: "a", LastUsedAt: mock.TestTime(2018)},
+		{Base: gorsk.Base{ID: 2}, UserID: 1, Device: "iPhone", Token: "b.b", Family: "b", LastUsedAt: mock.TestTime(2019)},
+		{Base: gorsk.Base{ID: 3}, UserID: 2, Device: "Android", Token: "c.c", Family: "c", LastUsedAt: mock.TestTime(2019)},
+		{Base: gorsk.Base{ID: 4}, UserID: 1, Device: "iPad", Token: "d.d", Family: "d", LastUsedAt: mock.TestTime(2019), ExpiresAt: mock.TestTime(2019)},
+		{Base: gorsk.Base{ID: 5}, UserID: 1, Device: "Linux", Token: "e.e", Family: "e", LastUsedAt: mock.TestTime(2019), TokenExpiresAt: mock.TestTime(2019)},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	for i := range sessions {
+		if err := mock.InsertMultiple(db, &sessions[i]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	sdb := pgsql.Session{}
+	resp, err := sdb.List(db, 1)
+	assert.Nil(t, err)
+	if assert.Len(t, resp, 2) {
+		assert.Equal(t, 2, resp[0].ID)
+		assert.Equal(t, 1, resp[1].ID)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	sess := &gorsk.Session{Base: gorsk.Base{ID: 1}, UserID: 1, Token: "a.a", Family: "a"}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	if err := mock.InsertMultiple(db, sess); err != nil {
+		t.Error(err)
+	}
+
+	sdb := pgsql.Session{}
+	assert.Nil(t, sdb.Delete(db, *sess))
+
+	_, err := sdb.View(db, sess.ID)
+	assert.NotNil(t, err)
+}
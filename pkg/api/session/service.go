@@ -0,0 +1,45 @@
+package session
+
+import (
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/session/platform/pgsql"
+)
+
+// Service represents session application interface
+type Service interface {
+	List(echo.Context) ([]gorsk.Session, error)
+	Revoke(echo.Context, int) error
+}
+
+// New creates new session application service
+func New(db *pg.DB, sdb SDB, rbac RBAC) Session {
+	return Session{db: db, sdb: sdb, rbac: rbac}
+}
+
+// Initialize initalizes Session application service with defaults
+func Initialize(db *pg.DB, rbac RBAC) Session {
+	return New(db, pgsql.Session{}, rbac)
+}
+
+// Session represents session application service
+type Session struct {
+	db   *pg.DB
+	sdb  SDB
+	rbac RBAC
+}
+
+// SDB represents session repository interface
+type SDB interface {
+	View(orm.DB, int) (gorsk.Session, error)
+	List(orm.DB, int) ([]gorsk.Session, error)
+	Delete(orm.DB, gorsk.Session) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+}
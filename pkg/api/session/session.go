@@ -0,0 +1,36 @@
+// Package session contains services for managing user's login sessions
+package session
+
+import (
+	"net/http"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+)
+
+// Custom errors
+var (
+	ErrSessionNotFound = echo.NewHTTPError(http.StatusNotFound, "session not found")
+)
+
+// List returns active sessions of currently logged user
+func (s Session) List(c echo.Context) ([]gorsk.Session, error) {
+	return s.sdb.List(s.db, s.rbac.User(c).ID)
+}
+
+// Revoke revokes one of currently logged user's sessions, logging out the device it belongs to
+func (s Session) Revoke(c echo.Context, id int) error {
+	sess, err := s.sdb.View(s.db, id)
+	if err == pg.ErrNoRows {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if sess.UserID != s.rbac.User(c).ID {
+		return ErrSessionNotFound
+	}
+	return s.sdb.Delete(s.db, sess)
+}
@@ -0,0 +1,134 @@
+package session_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/session"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantData []gorsk.Session
+		wantErr  bool
+		sdb      *mockdb.Session
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on List",
+			wantErr: true,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ListFn: func(orm.DB, int) ([]gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ListFn: func(db orm.DB, userID int) ([]gorsk.Session, error) {
+					return []gorsk.Session{
+						{Base: gorsk.Base{ID: 1}, UserID: userID, Device: "iPhone"},
+						{Base: gorsk.Base{ID: 2}, UserID: userID, Device: "Mac"},
+					}, nil
+				}},
+			wantData: []gorsk.Session{
+				{Base: gorsk.Base{ID: 1}, UserID: 1, Device: "iPhone"},
+				{Base: gorsk.Base{ID: 2}, UserID: 1, Device: "Mac"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := session.New(nil, tt.sdb, tt.rbac)
+			resp, err := s.List(nil)
+			assert.Equal(t, tt.wantData, resp)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      int
+		wantErr error
+		sdb     *mockdb.Session
+		rbac    *mock.RBAC
+	}{
+		{
+			name:    "Session does not exist",
+			id:      1,
+			wantErr: session.ErrSessionNotFound,
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (gorsk.Session, error) {
+					return gorsk.Session{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:    "Fail on View",
+			id:      1,
+			wantErr: gorsk.ErrGeneric,
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (gorsk.Session, error) {
+					return gorsk.Session{}, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:    "Session belongs to another user",
+			id:      1,
+			wantErr: session.ErrSessionNotFound,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (gorsk.Session, error) {
+					return gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 2}, nil
+				}},
+		},
+		{
+			name: "Success",
+			id:   1,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (gorsk.Session, error) {
+					return gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, gorsk.Session) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := session.New(nil, tt.sdb, tt.rbac)
+			err := s.Revoke(nil, tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	s := session.Initialize(nil, nil)
+	assert.NotNil(t, s)
+}
@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/session"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents session http service
+type HTTP struct {
+	svc session.Service
+}
+
+// NewHTTP creates new session http service
+func NewHTTP(svc session.Service, r *echo.Group) {
+	h := HTTP{svc}
+	sr := r.Group("/me/sessions")
+
+	// swagger:route GET /v1/me/sessions sessions listSessions
+	// Returns active sessions of currently logged user.
+	// responses:
+	//  200: sessionListResp
+	//  401: err
+	//  500: err
+	sr.GET("", h.list)
+
+	// swagger:operation DELETE /v1/me/sessions/{id} sessions sessionRevoke
+	// ---
+	// summary: Revokes a session
+	// description: Revokes one of currently logged user's sessions. The device it belongs to will not be able to refresh its token anymore.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of session
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	sr.DELETE("/:id", h.revoke)
+}
+
+type listResponse struct {
+	Sessions []gorsk.Session `json:"sessions"`
+}
+
+func (h HTTP) list(c echo.Context) error {
+	result, err := h.svc.List(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, listResponse{result})
+}
+
+func (h HTTP) revoke(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Revoke(c, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
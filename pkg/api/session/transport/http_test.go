@@ -0,0 +1,163 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/session"
+	"github.com/ribice/gorsk/pkg/api/session/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	type listResponse struct {
+		Sessions []gorsk.Session `json:"sessions"`
+	}
+	cases := []struct {
+		name       string
+		wantStatus int
+		wantResp   *listResponse
+		sdb        *mockdb.Session
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Fail on List",
+			wantStatus: http.StatusInternalServerError,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ListFn: func(orm.DB, int) ([]gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ListFn: func(db orm.DB, userID int) ([]gorsk.Session, error) {
+					return []gorsk.Session{
+						{
+							Base:       gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2018), UpdatedAt: mock.TestTime(2019)},
+							UserID:     userID,
+							Device:     "iPhone",
+							UserAgent:  "Mozilla/5.0 (iPhone)",
+							IP:         "10.0.0.1",
+							LastUsedAt: mock.TestTime(2019),
+							Token:      "family.token",
+						},
+					}, nil
+				}},
+			wantResp: &listResponse{
+				Sessions: []gorsk.Session{
+					{
+						Base:       gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2018), UpdatedAt: mock.TestTime(2019)},
+						Device:     "iPhone",
+						UserAgent:  "Mozilla/5.0 (iPhone)",
+						IP:         "10.0.0.1",
+						LastUsedAt: mock.TestTime(2019),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(session.New(nil, tt.sdb, tt.rbac), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/me/sessions")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		sdb        *mockdb.Session
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         "a",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Session not found",
+			id:         "1",
+			wantStatus: http.StatusNotFound,
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (gorsk.Session, error) {
+					return gorsk.Session{}, pg.ErrNoRows
+				}},
+		},
+		{
+			name:       "Success",
+			id:         "1",
+			wantStatus: http.StatusOK,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				}},
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (gorsk.Session, error) {
+					return gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, gorsk.Session) error {
+					return nil
+				}},
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(session.New(nil, tt.sdb, tt.rbac), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/me/sessions/"+tt.id, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
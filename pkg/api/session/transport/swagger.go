@@ -0,0 +1,14 @@
+package transport
+
+import (
+	"github.com/ribice/gorsk"
+)
+
+// Sessions model response
+// swagger:response sessionListResp
+type swaggSessionListResponse struct {
+	// in:body
+	Body struct {
+		Sessions []gorsk.Session `json:"sessions"`
+	}
+}
@@ -0,0 +1,43 @@
+package signup
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/signup"
+)
+
+// New creates new signup logging service
+func New(svc signup.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents signup logging service
+type LogService struct {
+	signup.Service
+	logger gorsk.Logger
+}
+
+const name = "signup"
+
+// Signup logging
+func (ls *LogService) Signup(c echo.Context, req gorsk.User, inviteCode string) (resp gorsk.User, err error) {
+	defer func(begin time.Time) {
+		req.Password = "xxx-redacted-xxx"
+		ls.logger.Log(
+			c,
+			name, "Signup request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Signup(c, req, inviteCode)
+}
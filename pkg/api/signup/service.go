@@ -0,0 +1,76 @@
+package signup
+
+import (
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/user/platform/pgsql"
+)
+
+// Service represents signup application interface
+type Service interface {
+	Signup(echo.Context, gorsk.User, string) (gorsk.User, error)
+}
+
+// New creates new signup application service
+func New(db *pg.DB, udb UserDB, sec Securer, ver Verifier, log gorsk.Logger, cfg Config) Signup {
+	return Signup{db: db, udb: udb, sec: sec, ver: ver, log: log, cfg: cfg}
+}
+
+// Initialize initalizes signup application service with defaults
+func Initialize(db *pg.DB, sec Securer, ver Verifier, log gorsk.Logger, cfg Config) Signup {
+	return New(db, pgsql.User{}, sec, ver, log, cfg)
+}
+
+// Signup represents signup application service
+type Signup struct {
+	db  *pg.DB
+	udb UserDB
+	sec Securer
+	ver Verifier
+	log gorsk.Logger
+	cfg Config
+}
+
+// Signup policies
+const (
+	// PolicyDisabled does not allow signing up, and is used for empty policy as well
+	PolicyDisabled = "disabled"
+	// PolicyDomain allows signing up with emails of listed domains
+	PolicyDomain = "domain"
+	// PolicyInvite allows signing up with listed invite codes
+	PolicyInvite = "invite"
+)
+
+// Config holds signup settings
+type Config struct {
+	Policy string
+	// Domains maps allowed email domains to the company and location users join
+	Domains map[string]Membership
+	// InviteCodes maps allowed invite codes to the company and location users join
+	InviteCodes map[string]Membership
+}
+
+// Membership represents company and location signed up users are assigned to
+type Membership struct {
+	CompanyID  int
+	LocationID int
+}
+
+// UserDB represents user repository interface
+type UserDB interface {
+	Create(orm.DB, gorsk.User) (gorsk.User, error)
+}
+
+// Securer represents security interface
+type Securer interface {
+	Hash(string) (string, error)
+	Password(string, ...string) error
+}
+
+// Verifier represents email verification interface
+type Verifier interface {
+	SendActivation(echo.Context, gorsk.User) error
+}
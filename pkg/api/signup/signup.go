@@ -0,0 +1,77 @@
+// Package signup contains self-service signup application services
+package signup
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+)
+
+// Custom errors
+var (
+	ErrSignupDisabled    = echo.NewHTTPError(http.StatusForbidden, "Signup is disabled")
+	ErrDomainNotAllowed  = echo.NewHTTPError(http.StatusForbidden, "Signup is not allowed for this email domain")
+	ErrInvalidInviteCode = echo.NewHTTPError(http.StatusForbidden, "Invalid invite code")
+)
+
+// Signup creates an inactive user account, emailing a link that verifies the email address and activates it.
+// Company and location are picked by signup policy, from user's email domain or invite code.
+// Failing to send the email does not fail the signup, as the link can be resent.
+func (s Signup) Signup(c echo.Context, req gorsk.User, inviteCode string) (gorsk.User, error) {
+	m, err := s.membership(req.Email, inviteCode)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	if err := s.sec.Password(req.Password, req.FirstName, req.LastName, req.Username, req.Email); err != nil {
+		return gorsk.User{}, err
+	}
+
+	hash, err := s.sec.Hash(req.Password)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	req.Password = hash
+	req.RoleID = gorsk.UserRole
+	req.CompanyID = m.CompanyID
+	req.LocationID = m.LocationID
+	req.Active = false
+	req.EmailVerifiedAt = time.Time{}
+
+	user, err := s.udb.Create(s.db, req)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+
+	if err := s.ver.SendActivation(c, user); err != nil {
+		s.log.Log(c, "signup", "Sending activation email failed", err, map[string]interface{}{
+			"user_id": user.ID,
+		})
+	}
+
+	return user, nil
+}
+
+// membership returns company and location the user joins under configured policy
+func (s Signup) membership(email, inviteCode string) (Membership, error) {
+	switch s.cfg.Policy {
+	case PolicyDomain:
+		domain := email[strings.LastIndex(email, "@")+1:]
+		m, ok := s.cfg.Domains[strings.ToLower(domain)]
+		if !ok {
+			return Membership{}, ErrDomainNotAllowed
+		}
+		return m, nil
+	case PolicyInvite:
+		m, ok := s.cfg.InviteCodes[inviteCode]
+		if !ok || inviteCode == "" {
+			return Membership{}, ErrInvalidInviteCode
+		}
+		return m, nil
+	default:
+		return Membership{}, ErrSignupDisabled
+	}
+}
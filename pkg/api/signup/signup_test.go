@@ -0,0 +1,167 @@
+package signup_test
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/signup"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignup(t *testing.T) {
+	domains := map[string]signup.Membership{"gorsk.dev": {CompanyID: 1, LocationID: 2}}
+	codes := map[string]signup.Membership{"WELCOME": {CompanyID: 3, LocationID: 4}}
+	cases := []struct {
+		name       string
+		cfg        signup.Config
+		email      string
+		inviteCode string
+		weak       bool
+		breached   bool
+		udb        *mockdb.User
+		wantErr    error
+		wantData   gorsk.User
+	}{
+		{
+			name:    "Fail on disabled signup",
+			cfg:     signup.Config{Policy: signup.PolicyDisabled, Domains: domains},
+			email:   "johndoe@gorsk.dev",
+			wantErr: signup.ErrSignupDisabled,
+		},
+		{
+			name:    "Fail on empty policy",
+			email:   "johndoe@gorsk.dev",
+			wantErr: signup.ErrSignupDisabled,
+		},
+		{
+			name:    "Fail on domain not allowed",
+			cfg:     signup.Config{Policy: signup.PolicyDomain, Domains: domains},
+			email:   "johndoe@gorsk.dev.evil.com",
+			wantErr: signup.ErrDomainNotAllowed,
+		},
+		{
+			name:       "Fail on invalid invite code",
+			cfg:        signup.Config{Policy: signup.PolicyInvite, Domains: domains, InviteCodes: codes},
+			email:      "johndoe@gorsk.dev",
+			inviteCode: "welcome",
+			wantErr:    signup.ErrInvalidInviteCode,
+		},
+		{
+			name:    "Fail on insecure password",
+			cfg:     signup.Config{Policy: signup.PolicyDomain, Domains: domains},
+			email:   "johndoe@gorsk.dev",
+			weak:    true,
+			wantErr: gorsk.ErrInsecurePassword,
+		},
+		{
+			name:     "Fail on breached password",
+			cfg:      signup.Config{Policy: signup.PolicyDomain, Domains: domains},
+			email:    "johndoe@gorsk.dev",
+			breached: true,
+			wantErr:  gorsk.ErrBreachedPassword,
+		},
+		{
+			name:  "Fail on taken username or email",
+			cfg:   signup.Config{Policy: signup.PolicyDomain, Domains: domains},
+			email: "johndoe@gorsk.dev",
+			udb: &mockdb.User{
+				CreateFn: func(orm.DB, gorsk.User) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrAccountExists
+				},
+			},
+			wantErr: gorsk.ErrAccountExists,
+		},
+		{
+			name:  "Success with email domain",
+			cfg:   signup.Config{Policy: signup.PolicyDomain, Domains: domains},
+			email: "JohnDoe@Gorsk.dev",
+			udb: &mockdb.User{
+				CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+					u.ID = 1
+					return u, nil
+				},
+			},
+			wantData: gorsk.User{
+				Base:       gorsk.Base{ID: 1},
+				FirstName:  "John",
+				LastName:   "Doe",
+				Username:   "johndoe",
+				Password:   "h4$h3d",
+				Email:      "JohnDoe@Gorsk.dev",
+				RoleID:     gorsk.UserRole,
+				CompanyID:  1,
+				LocationID: 2,
+			},
+		},
+		{
+			name:       "Success with invite code",
+			cfg:        signup.Config{Policy: signup.PolicyInvite, InviteCodes: codes},
+			email:      "johndoe@mail.com",
+			inviteCode: "WELCOME",
+			udb: &mockdb.User{
+				CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+					u.ID = 1
+					return u, nil
+				},
+			},
+			wantData: gorsk.User{
+				Base:       gorsk.Base{ID: 1},
+				FirstName:  "John",
+				LastName:   "Doe",
+				Username:   "johndoe",
+				Password:   "h4$h3d",
+				Email:      "johndoe@mail.com",
+				RoleID:     gorsk.UserRole,
+				CompanyID:  3,
+				LocationID: 4,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			sec := &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					if tt.weak {
+						return gorsk.ErrInsecurePassword
+					}
+					if tt.breached {
+						return gorsk.ErrBreachedPassword
+					}
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
+				},
+			}
+			var activated []gorsk.User
+			ver := &mock.Verifier{
+				SendActivationFn: func(c echo.Context, u gorsk.User) error {
+					activated = append(activated, u)
+					return gorsk.ErrGeneric
+				},
+			}
+			log := &mock.Logger{LogFn: func(echo.Context, string, string, error, map[string]interface{}) {}}
+			s := signup.New(nil, tt.udb, sec, ver, log, tt.cfg)
+			usr, err := s.Signup(nil, gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "johndoe",
+				Password:  "Thranduil8822",
+				Email:     tt.email,
+				RoleID:    gorsk.SuperAdminRole,
+				Active:    true,
+			}, tt.inviteCode)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, usr)
+			if tt.wantData.ID != 0 {
+				assert.Equal(t, []gorsk.User{tt.wantData}, activated, "failing to send activation email does not fail signup")
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/signup"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents signup http service
+type HTTP struct {
+	svc signup.Service
+}
+
+// NewHTTP creates new signup http service
+func NewHTTP(svc signup.Service, e *echo.Echo) {
+	h := HTTP{svc}
+
+	// swagger:operation POST /signup signup signup
+	// ---
+	// summary: Signs up a new user.
+	// description: Creates an inactive user account, allowed by signup policy. The account is activated once its email address is verified.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/signup"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/userResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "403":
+	//     "$ref": "#/responses/errMsg"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/signup", h.signup)
+}
+
+// Custom errors
+var (
+	ErrPasswordsNotMaching = echo.NewHTTPError(http.StatusBadRequest, "passwords do not match")
+)
+
+// Signup request
+// swagger:model signup
+type signupReq struct {
+	FirstName       string `json:"first_name" validate:"required"`
+	LastName        string `json:"last_name" validate:"required"`
+	Username        string `json:"username" validate:"required,min=3,alphanum"`
+	Password        string `json:"password" validate:"required,max=128"`
+	PasswordConfirm string `json:"password_confirm" validate:"required,max=128"`
+	Email           string `json:"email" validate:"required,email"`
+	InviteCode      string `json:"invite_code"`
+}
+
+func (h HTTP) signup(c echo.Context) error {
+	r := new(signupReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if r.Password != r.PasswordConfirm {
+		return ErrPasswordsNotMaching
+	}
+
+	usr, err := h.svc.Signup(c, gorsk.User{
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Username:  r.Username,
+		Password:  r.Password,
+		Email:     r.Email,
+	}, r.InviteCode)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, usr)
+}
@@ -0,0 +1,110 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/signup"
+	"github.com/ribice/gorsk/pkg/api/signup/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignup(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		policy     string
+		wantStatus int
+		wantData   gorsk.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"first_name":"John","last_name":"Doe","username":"jd","password":"Thranduil8822","password_confirm":"Thranduil8822","email":"johndoe@gorsk.dev"}`,
+			policy:     signup.PolicyDomain,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Different passwords",
+			req:        `{"first_name":"John","last_name":"Doe","username":"johndoe","password":"Thranduil8822","password_confirm":"Thranduil8823","email":"johndoe@gorsk.dev"}`,
+			policy:     signup.PolicyDomain,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Signup disabled",
+			req:        `{"first_name":"John","last_name":"Doe","username":"johndoe","password":"Thranduil8822","password_confirm":"Thranduil8822","email":"johndoe@gorsk.dev"}`,
+			policy:     signup.PolicyDisabled,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			req:        `{"first_name":"John","last_name":"Doe","username":"johndoe","password":"Thranduil8822","password_confirm":"Thranduil8822","email":"johndoe@gorsk.dev"}`,
+			policy:     signup.PolicyDomain,
+			wantStatus: http.StatusOK,
+			wantData: gorsk.User{
+				Base:      gorsk.Base{ID: 1},
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "johndoe",
+				Email:     "johndoe@gorsk.dev",
+				CompanyID: 1,
+			},
+		},
+	}
+
+	udb := &mockdb.User{
+		CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+			u.ID = 1
+			return u, nil
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(string, ...string) error {
+			return nil
+		},
+		HashFn: func(string) (string, error) {
+			return "h4$h3d", nil
+		},
+	}
+	ver := &mock.Verifier{
+		SendActivationFn: func(echo.Context, gorsk.User) error {
+			return nil
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			cfg := signup.Config{
+				Policy:  tt.policy,
+				Domains: map[string]signup.Membership{"gorsk.dev": {CompanyID: 1}},
+			}
+			transport.NewHTTP(signup.New(nil, udb, sec, ver, nil, cfg), r)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/signup", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantData.ID != 0 {
+				var u gorsk.User
+				if err := json.NewDecoder(res.Body).Decode(&u); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantData, u)
+			}
+		})
+	}
+}
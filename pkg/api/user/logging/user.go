@@ -104,3 +104,48 @@ func (ls *LogService) Update(c echo.Context, req user.Update) (resp gorsk.User,
 	}(time.Now())
 	return ls.Service.Update(c, req)
 }
+
+// Unlock logging
+func (ls *LogService) Unlock(c echo.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Unlock user request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Unlock(c, req)
+}
+
+// RequirePasswordChange logging
+func (ls *LogService) RequirePasswordChange(c echo.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Require password change request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.RequirePasswordChange(c, req)
+}
+
+// Impersonate logging
+func (ls *LogService) Impersonate(c echo.Context, req int) (resp gorsk.AuthToken, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Impersonate user request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Impersonate(c, req)
+}
@@ -0,0 +1,16 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Session represents the client for session table
+type Session struct{}
+
+// DeleteByUser deletes all of user's sessions, revoking their refresh tokens
+func (s Session) DeleteByUser(db orm.DB, userID int) error {
+	_, err := db.Model((*gorsk.Session)(nil)).Where("user_id = ?", userID).Delete()
+	return err
+}
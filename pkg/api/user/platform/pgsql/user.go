@@ -1,13 +1,11 @@
 package pgsql
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/go-pg/pg/v9"
 
 	"github.com/go-pg/pg/v9/orm"
-	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 )
@@ -15,18 +13,16 @@ import (
 // User represents the client for user table
 type User struct{}
 
-// Custom errors
-var (
-	ErrAlreadyExists = echo.NewHTTPError(http.StatusInternalServerError, "Username or email already exists.")
-)
-
-// Create creates a new user on database
+// Create creates a new user on database, unless the username or email is already taken
 func (u User) Create(db orm.DB, usr gorsk.User) (gorsk.User, error) {
 	var user = new(gorsk.User)
-	err := db.Model(user).Where("lower(username) = ? or lower(email) = ? and deleted_at is null",
-		strings.ToLower(usr.Username), strings.ToLower(usr.Email)).Select()
-	if err == nil || err != pg.ErrNoRows {
-		return gorsk.User{}, ErrAlreadyExists
+	err := db.Model(user).Where("(lower(username) = ? or lower(email) = ?) and deleted_at is null",
+		strings.ToLower(usr.Username), strings.ToLower(usr.Email)).First()
+	if err != pg.ErrNoRows {
+		if err == nil {
+			err = gorsk.ErrAccountExists
+		}
+		return gorsk.User{}, err
 	}
 
 	err = db.Insert(&usr)
@@ -53,9 +49,12 @@ func (u User) Update(db orm.DB, user gorsk.User) error {
 func (u User) List(db orm.DB, qp *gorsk.ListQuery, p gorsk.Pagination) ([]gorsk.User, error) {
 	var users []gorsk.User
 	q := db.Model(&users).Relation("Role").Limit(p.Limit).Offset(p.Offset).Where("deleted_at is null").Order("user.id desc")
-	if qp != nil {
+	if qp != nil && qp.Query != "" {
 		q.Where(qp.Query, qp.ID)
 	}
+	if qp != nil && len(qp.Roles) > 0 {
+		q.Where("role_id IN (?)", pg.In(qp.Roles))
+	}
 	err := q.Select()
 	return users, err
 }
@@ -64,3 +63,15 @@ func (u User) List(db orm.DB, qp *gorsk.ListQuery, p gorsk.Pagination) ([]gorsk.
 func (u User) Delete(db orm.DB, user gorsk.User) error {
 	return db.Delete(&user)
 }
+
+// Unlock clears user's failed login attempts
+func (u User) Unlock(db orm.DB, user gorsk.User) error {
+	_, err := db.Model(&user).Column("failed_logins", "last_failed_login").WherePK().Update()
+	return err
+}
+
+// RequirePasswordChange updates whether user has to change the password at next login
+func (u User) RequirePasswordChange(db orm.DB, user gorsk.User) error {
+	_, err := db.Model(&user).Column("must_change_password", "updated_at").WherePK().Update()
+	return err
+}
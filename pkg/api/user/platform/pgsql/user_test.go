@@ -73,6 +73,14 @@ func TestCreate(t *testing.T) {
 				Username: "newtomjones",
 			},
 		},
+		{
+			name:    "Email already exists in different case",
+			wantErr: true,
+			req: gorsk.User{
+				Email:    "NewTomJones@mail.com",
+				Username: "tomjones2",
+			},
+		},
 	}
 
 	dbCon := mock.NewPGContainer(t)
@@ -323,7 +331,38 @@ func TestList(t *testing.T) {
 						AccessLevel: 1,
 						Name:        "SUPER_ADMIN",
 					},
-					Token: "loginrefresh",
+				},
+			},
+		},
+		{
+			name: "Own user of granted role",
+			pg: gorsk.Pagination{
+				Limit:  100,
+				Offset: 0,
+			},
+			qp: &gorsk.ListQuery{
+				ID:    1,
+				Query: `"user"."id" = ?`,
+				Roles: []gorsk.AccessRole{gorsk.SuperAdminRole},
+			},
+			wantData: []gorsk.User{
+				{
+					Email:      "johndoe@mail.com",
+					FirstName:  "John",
+					LastName:   "Doe",
+					Username:   "johndoe",
+					RoleID:     1,
+					CompanyID:  1,
+					LocationID: 1,
+					Password:   "hunter2",
+					Base: gorsk.Base{
+						ID: 1,
+					},
+					Role: &gorsk.Role{
+						ID:          1,
+						AccessLevel: 1,
+						Name:        "SUPER_ADMIN",
+					},
 				},
 			},
 		},
@@ -413,3 +452,65 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUnlock(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		usr      gorsk.User
+		wantData gorsk.User
+	}{
+		{
+			name: "Success",
+			usr: gorsk.User{
+				Base: gorsk.Base{
+					ID: 2,
+				},
+			},
+			wantData: gorsk.User{
+				Email:           "tomjones@mail.com",
+				FirstName:       "Tom",
+				LastName:        "Jones",
+				Username:        "tomjones",
+				RoleID:          1,
+				CompanyID:       1,
+				LocationID:      1,
+				Password:        "newPass",
+				FailedLogins:    5,
+				LastFailedLogin: mock.TestTime(2018),
+				Base: gorsk.Base{
+					ID: 2,
+				},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Role{
+		ID:          1,
+		AccessLevel: 1,
+		Name:        "SUPER_ADMIN"}, &cases[0].wantData); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.User{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := udb.Unlock(db, tt.usr)
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			user, err := udb.View(db, tt.usr.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 0, user.FailedLogins)
+			assert.True(t, user.LastFailedLogin.IsZero())
+			assert.Equal(t, tt.wantData.Username, user.Username)
+		})
+	}
+}
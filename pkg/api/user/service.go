@@ -16,29 +16,47 @@ type Service interface {
 	View(echo.Context, int) (gorsk.User, error)
 	Delete(echo.Context, int) error
 	Update(echo.Context, Update) (gorsk.User, error)
+	Unlock(echo.Context, int) error
+	RequirePasswordChange(echo.Context, int) error
+	Impersonate(echo.Context, int) (gorsk.AuthToken, error)
 }
 
 // New creates new user application service
-func New(db *pg.DB, udb UDB, rbac RBAC, sec Securer) *User {
-	return &User{db: db, udb: udb, rbac: rbac, sec: sec}
+func New(db *pg.DB, udb UDB, sdb SessionDB, rbac RBAC, sec Securer, tg TokenGenerator, ver Verifier, log gorsk.Logger) *User {
+	return &User{db: db, udb: udb, sdb: sdb, rbac: rbac, sec: sec, tg: tg, ver: ver, log: log}
 }
 
 // Initialize initalizes User application service with defaults
-func Initialize(db *pg.DB, rbac RBAC, sec Securer) *User {
-	return New(db, pgsql.User{}, rbac, sec)
+func Initialize(db *pg.DB, rbac RBAC, sec Securer, tg TokenGenerator, ver Verifier, log gorsk.Logger) *User {
+	return New(db, pgsql.User{}, pgsql.Session{}, rbac, sec, tg, ver, log)
 }
 
 // User represents user application service
 type User struct {
 	db   *pg.DB
 	udb  UDB
+	sdb  SessionDB
 	rbac RBAC
 	sec  Securer
+	tg   TokenGenerator
+	ver  Verifier
+	log  gorsk.Logger
+}
+
+// TokenGenerator represents token generator (jwt) interface
+type TokenGenerator interface {
+	GenerateImpersonationToken(gorsk.User, int) (string, error)
+}
+
+// Verifier represents email verification interface
+type Verifier interface {
+	Send(echo.Context, gorsk.User, string) error
 }
 
 // Securer represents security interface
 type Securer interface {
-	Hash(string) string
+	Hash(string) (string, error)
+	Password(string, ...string) error
 }
 
 // UDB represents user repository interface
@@ -48,12 +66,21 @@ type UDB interface {
 	List(orm.DB, *gorsk.ListQuery, gorsk.Pagination) ([]gorsk.User, error)
 	Update(orm.DB, gorsk.User) error
 	Delete(orm.DB, gorsk.User) error
+	Unlock(orm.DB, gorsk.User) error
+	RequirePasswordChange(orm.DB, gorsk.User) error
+}
+
+// SessionDB represents session repository interface
+type SessionDB interface {
+	DeleteByUser(orm.DB, int) error
 }
 
 // RBAC represents role-based-access-control interface
 type RBAC interface {
 	User(echo.Context) gorsk.AuthUser
-	EnforceUser(echo.Context, int) error
+	Enforce(echo.Context, gorsk.Permission, gorsk.Resource) error
+	Grant(echo.Context, gorsk.Permission) (gorsk.RolePermission, error)
+	EnforceUser(echo.Context, gorsk.Permission, int) error
 	AccountCreate(echo.Context, gorsk.AccessRole, int, int) error
-	IsLowerRole(echo.Context, gorsk.AccessRole) error
+	Invalidate(int)
 }
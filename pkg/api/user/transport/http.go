@@ -133,6 +133,75 @@ func NewHTTP(svc user.Service, r *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.DELETE("/:id", h.delete)
+
+	// swagger:operation POST /v1/users/{id}/unlock users userUnlock
+	// ---
+	// summary: Unlocks a user account
+	// description: Clears failed login attempts of a user with requested ID, lifting account lockout.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/unlock", h.unlock)
+
+	// swagger:operation POST /v1/users/{id}/require-password-change users userRequirePasswordChange
+	// ---
+	// summary: Requires user to change password
+	// description: Makes user with requested ID change the password at next login.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/require-password-change", h.requirePasswordChange)
+
+	// swagger:operation POST /v1/users/{id}/impersonate users userImpersonate
+	// ---
+	// summary: Impersonates a user
+	// description: Issues a short-lived token letting super admin act as user with requested ID. Passwords cannot be changed and users cannot be deleted with the token.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/impersonateResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/impersonate", h.impersonate)
 }
 
 // Custom errors
@@ -146,8 +215,8 @@ type createReq struct {
 	FirstName       string `json:"first_name" validate:"required"`
 	LastName        string `json:"last_name" validate:"required"`
 	Username        string `json:"username" validate:"required,min=3,alphanum"`
-	Password        string `json:"password" validate:"required,min=8"`
-	PasswordConfirm string `json:"password_confirm" validate:"required"`
+	Password        string `json:"password" validate:"required,max=128"`
+	PasswordConfirm string `json:"password_confirm" validate:"required,max=128"`
 	Email           string `json:"email" validate:"required,email"`
 
 	CompanyID  int              `json:"company_id" validate:"required"`
@@ -273,3 +342,43 @@ func (h HTTP) delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+func (h HTTP) unlock(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Unlock(c, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h HTTP) requirePasswordChange(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.RequirePasswordChange(c, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h HTTP) impersonate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	result, err := h.svc.Impersonate(c, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
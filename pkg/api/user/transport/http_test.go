@@ -78,8 +78,11 @@ func TestCreate(t *testing.T) {
 				},
 			},
 			sec: &mock.Secure{
-				HashFn: func(string) string {
-					return "h4$h3d"
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
 				},
 			},
 			wantResp: &gorsk.User{
@@ -103,7 +106,8 @@ func TestCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			ver := &mock.Verifier{SendFn: func(echo.Context, gorsk.User, string) error { return nil }}
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, tt.sec, nil, ver, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users"
@@ -147,6 +151,9 @@ func TestList(t *testing.T) {
 			name: "Fail on query list",
 			req:  `?limit=100&page=1`,
 			rbac: &mock.RBAC{
+				GrantFn: func(echo.Context, gorsk.Permission) (gorsk.RolePermission, error) {
+					return gorsk.RolePermission{}, nil
+				},
 				UserFn: func(c echo.Context) gorsk.AuthUser {
 					return gorsk.AuthUser{
 						ID:         1,
@@ -162,6 +169,9 @@ func TestList(t *testing.T) {
 			name: "Success",
 			req:  `?limit=100&page=1`,
 			rbac: &mock.RBAC{
+				GrantFn: func(echo.Context, gorsk.Permission) (gorsk.RolePermission, error) {
+					return gorsk.RolePermission{Scope: gorsk.ScopeAny}, nil
+				},
 				UserFn: func(c echo.Context) gorsk.AuthUser {
 					return gorsk.AuthUser{
 						ID:         1,
@@ -259,7 +269,7 @@ func TestList(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, tt.sec, nil, nil, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users" + tt.req
@@ -299,7 +309,7 @@ func TestView(t *testing.T) {
 			name: "Fail on RBAC",
 			req:  `1`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(echo.Context, gorsk.Permission, int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -309,7 +319,7 @@ func TestView(t *testing.T) {
 			name: "Success",
 			req:  `1`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(echo.Context, gorsk.Permission, int) error {
 					return nil
 				},
 			},
@@ -345,7 +355,7 @@ func TestView(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, tt.sec, nil, nil, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users/" + tt.req
@@ -393,7 +403,7 @@ func TestUpdate(t *testing.T) {
 			id:   `1`,
 			req:  `{"first_name":"jj","last_name":"okocha","mobile":"123456","phone":"321321","address":"home"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(echo.Context, gorsk.Permission, int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -404,9 +414,10 @@ func TestUpdate(t *testing.T) {
 			id:   `1`,
 			req:  `{"first_name":"jj","last_name":"okocha","phone":"321321","address":"home"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(echo.Context, gorsk.Permission, int) error {
 					return nil
 				},
+				InvalidateFn: func(int) {},
 			},
 			udb: &mockdb.User{
 				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
@@ -453,7 +464,7 @@ func TestUpdate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, tt.sec, nil, nil, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users/" + tt.id
@@ -503,7 +514,7 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -525,9 +536,13 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
 					return nil
 				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 2}
+				},
+				InvalidateFn: func(int) {},
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -539,7 +554,7 @@ func TestDelete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, tt.sec, nil, nil, nil), rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users/" + tt.id
@@ -553,3 +568,169 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUnlock(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		udb        *mockdb.User
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   `1`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Role: &gorsk.Role{
+							AccessLevel: gorsk.CompanyAdminRole,
+						},
+					}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Role: &gorsk.Role{
+							AccessLevel: gorsk.UserRole,
+						},
+					}, nil
+				},
+				UnlockFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, nil, nil, nil, nil), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/users/" + tt.id + "/unlock"
+			req, _ := http.NewRequest("POST", path, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		wantResp   *gorsk.AuthToken
+		udb        *mockdb.User
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   `2`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.SuperAdminRole}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return echo.ErrForbidden
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, Role: gorsk.SuperAdminRole}
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			id:   `2`,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, Role: gorsk.SuperAdminRole}
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp:   &gorsk.AuthToken{Token: "impersonationtoken"},
+		},
+	}
+
+	tg := mock.JWT{
+		GenerateImpersonationTokenFn: func(gorsk.User, int) (string, error) {
+			return "impersonationtoken", nil
+		},
+	}
+	log := &mock.Logger{
+		LogFn: func(echo.Context, string, string, error, map[string]interface{}) {},
+	}
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(user.New(nil, tt.udb, nil, tt.rbac, nil, tg, nil, log), rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/users/" + tt.id + "/impersonate"
+			req, _ := http.NewRequest("POST", path, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.AuthToken)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -22,3 +22,12 @@ type swaggUserListResponse struct {
 		Page  int          `json:"page"`
 	}
 }
+
+// Impersonation token response
+// swagger:response impersonateResp
+type swaggImpersonateResponse struct {
+	// in:body
+	Body struct {
+		*gorsk.AuthToken
+	}
+}
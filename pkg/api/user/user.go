@@ -2,25 +2,57 @@
 package user
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/utl/query"
 )
 
-// Create creates a new user account
+// Custom errors
+var (
+	ErrImpersonateSelf = echo.NewHTTPError(http.StatusBadRequest, "Cannot impersonate yourself")
+)
+
+// Create creates a new user account, emailing a link to verify its email address.
+// Failing to send the email does not fail the creation, as the link can be resent.
 func (u User) Create(c echo.Context, req gorsk.User) (gorsk.User, error) {
 	if err := u.rbac.AccountCreate(c, req.RoleID, req.CompanyID, req.LocationID); err != nil {
 		return gorsk.User{}, err
 	}
-	req.Password = u.sec.Hash(req.Password)
-	return u.udb.Create(u.db, req)
+	if err := u.sec.Password(req.Password, req.FirstName, req.LastName, req.Username, req.Email); err != nil {
+		return gorsk.User{}, err
+	}
+	hash, err := u.sec.Hash(req.Password)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+	req.Password = hash
+	req.EmailVerifiedAt = time.Time{}
+
+	user, err := u.udb.Create(u.db, req)
+	if err != nil {
+		return gorsk.User{}, err
+	}
+
+	if err := u.ver.Send(c, user, user.Email); err != nil {
+		u.log.Log(c, "user", "Sending verification email failed", err, map[string]interface{}{
+			"user_id": user.ID,
+		})
+	}
+
+	return user, nil
 }
 
 // List returns list of users
 func (u User) List(c echo.Context, p gorsk.Pagination) ([]gorsk.User, error) {
-	au := u.rbac.User(c)
-	q, err := query.List(au)
+	grant, err := u.rbac.Grant(c, gorsk.UsersRead)
+	if err != nil {
+		return nil, err
+	}
+	q, err := query.List(u.rbac.User(c), grant)
 	if err != nil {
 		return nil, err
 	}
@@ -29,22 +61,29 @@ func (u User) List(c echo.Context, p gorsk.Pagination) ([]gorsk.User, error) {
 
 // View returns single user
 func (u User) View(c echo.Context, id int) (gorsk.User, error) {
-	if err := u.rbac.EnforceUser(c, id); err != nil {
+	if err := u.rbac.EnforceUser(c, gorsk.UsersRead, id); err != nil {
 		return gorsk.User{}, err
 	}
 	return u.udb.View(u.db, id)
 }
 
-// Delete deletes a user
+// Delete deletes a user. Users cannot be deleted while impersonating.
 func (u User) Delete(c echo.Context, id int) error {
 	user, err := u.udb.View(u.db, id)
 	if err != nil {
 		return err
 	}
-	if err := u.rbac.IsLowerRole(c, user.Role.AccessLevel); err != nil {
+	if err := u.rbac.Enforce(c, gorsk.UsersDelete, user.Resource()); err != nil {
+		return err
+	}
+	if u.rbac.User(c).ActorID != 0 {
+		return gorsk.ErrImpersonation
+	}
+	if err := u.udb.Delete(u.db, user); err != nil {
 		return err
 	}
-	return u.udb.Delete(u.db, user)
+	u.rbac.Invalidate(id)
+	return nil
 }
 
 // Update contains user's information used for updating
@@ -59,7 +98,7 @@ type Update struct {
 
 // Update updates user's contact information
 func (u User) Update(c echo.Context, r Update) (gorsk.User, error) {
-	if err := u.rbac.EnforceUser(c, r.ID); err != nil {
+	if err := u.rbac.EnforceUser(c, gorsk.UsersUpdate, r.ID); err != nil {
 		return gorsk.User{}, err
 	}
 
@@ -72,6 +111,71 @@ func (u User) Update(c echo.Context, r Update) (gorsk.User, error) {
 	}); err != nil {
 		return gorsk.User{}, err
 	}
+	u.rbac.Invalidate(r.ID)
 
 	return u.udb.View(u.db, r.ID)
 }
+
+// Unlock clears user's failed login attempts, lifting account lockout
+func (u User) Unlock(c echo.Context, id int) error {
+	user, err := u.udb.View(u.db, id)
+	if err != nil {
+		return err
+	}
+	if err := u.rbac.Enforce(c, gorsk.UsersCredentials, user.Resource()); err != nil {
+		return err
+	}
+	user.Unlock()
+	return u.udb.Unlock(u.db, user)
+}
+
+// RequirePasswordChange makes user change the password at next login, revoking user's sessions
+// so that the user cannot skip logging in by refreshing them
+func (u User) RequirePasswordChange(c echo.Context, id int) error {
+	user, err := u.udb.View(u.db, id)
+	if err != nil {
+		return err
+	}
+	if err := u.rbac.Enforce(c, gorsk.UsersCredentials, user.Resource()); err != nil {
+		return err
+	}
+	user.MustChangePassword = true
+	if err := u.udb.RequirePasswordChange(u.db, user); err != nil {
+		return err
+	}
+	return u.sdb.DeleteByUser(u.db, user.ID)
+}
+
+// Impersonate issues a short-lived token letting super admin act as another user,
+// limited to users of roles the impersonation is granted on.
+// Impersonation cannot be nested, and its start is recorded with the acting super admin.
+func (u User) Impersonate(c echo.Context, id int) (gorsk.AuthToken, error) {
+	au := u.rbac.User(c)
+	if au.ActorID != 0 {
+		return gorsk.AuthToken{}, gorsk.ErrImpersonation
+	}
+	if au.ID == id {
+		return gorsk.AuthToken{}, ErrImpersonateSelf
+	}
+
+	user, err := u.udb.View(u.db, id)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	if err := u.rbac.Enforce(c, gorsk.UsersImpersonate, user.Resource()); err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	token, err := u.tg.GenerateImpersonationToken(user, au.ID)
+	if err != nil {
+		return gorsk.AuthToken{}, err
+	}
+
+	u.log.Log(c, "user", "Impersonation started", nil, map[string]interface{}{
+		"user_id":  user.ID,
+		"actor_id": au.ID,
+	})
+
+	return gorsk.AuthToken{Token: token}, nil
+}
@@ -27,6 +27,7 @@ func TestCreate(t *testing.T) {
 		udb      *mockdb.User
 		rbac     *mock.RBAC
 		sec      *mock.Secure
+		ver      *mock.Verifier
 	}{{
 		name: "Fail on is lower role",
 		rbac: &mock.RBAC{
@@ -42,6 +43,46 @@ func TestCreate(t *testing.T) {
 			Password:  "Thranduil8822",
 		}},
 	},
+		{
+			name:    "Fail on insecure password",
+			wantErr: true,
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "johndoe",
+			}},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return nil
+				}},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return gorsk.ErrInsecurePassword
+				},
+			},
+		},
+		{
+			name:    "Fail on breached password",
+			wantErr: true,
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "Thranduil8822",
+			}},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return nil
+				}},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return gorsk.ErrBreachedPassword
+				},
+			},
+		},
 		{
 			name: "Success",
 			args: args{req: gorsk.User{
@@ -64,8 +105,63 @@ func TestCreate(t *testing.T) {
 					return nil
 				}},
 			sec: &mock.Secure{
-				HashFn: func(string) string {
-					return "h4$h3d"
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
+				},
+			},
+			ver: &mock.Verifier{
+				SendFn: func(c echo.Context, u gorsk.User, email string) error {
+					assert.Equal(t, 1, u.ID)
+					return nil
+				},
+			},
+			wantData: gorsk.User{
+				Base: gorsk.Base{
+					ID:        1,
+					CreatedAt: mock.TestTime(2000),
+					UpdatedAt: mock.TestTime(2000),
+				},
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "h4$h3d",
+			}},
+		{
+			name: "Success despite failed verification email",
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "Thranduil8822",
+			}},
+			udb: &mockdb.User{
+				CreateFn: func(db orm.DB, u gorsk.User) (gorsk.User, error) {
+					u.CreatedAt = mock.TestTime(2000)
+					u.UpdatedAt = mock.TestTime(2000)
+					u.Base.ID = 1
+					return u, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+					return nil
+				}},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) error {
+					return nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
+				},
+			},
+			ver: &mock.Verifier{
+				SendFn: func(c echo.Context, u gorsk.User, email string) error {
+					return gorsk.ErrGeneric
 				},
 			},
 			wantData: gorsk.User{
@@ -82,7 +178,8 @@ func TestCreate(t *testing.T) {
 			}}}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, tt.sec)
+			log := &mock.Logger{LogFn: func(echo.Context, string, string, error, map[string]interface{}) {}}
+			s := user.New(nil, tt.udb, nil, tt.rbac, tt.sec, nil, tt.ver, log)
 			usr, err := s.Create(tt.args.c, tt.args.req)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.wantData, usr)
@@ -107,7 +204,7 @@ func TestView(t *testing.T) {
 			name: "Fail on RBAC",
 			args: args{id: 5},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -126,7 +223,8 @@ func TestView(t *testing.T) {
 				Username:  "JohnDoe",
 			},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
+					assert.Equal(t, gorsk.UsersRead, p)
 					return nil
 				}},
 			udb: &mockdb.User{
@@ -149,7 +247,7 @@ func TestView(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
+			s := user.New(nil, tt.udb, nil, tt.rbac, nil, nil, nil, nil)
 			usr, err := s.View(tt.args.c, tt.args.id)
 			assert.Equal(t, tt.wantData, usr)
 			assert.Equal(t, tt.wantErr, err)
@@ -178,6 +276,9 @@ func TestList(t *testing.T) {
 			}},
 			wantErr: true,
 			rbac: &mock.RBAC{
+				GrantFn: func(c echo.Context, p gorsk.Permission) (gorsk.RolePermission, error) {
+					return gorsk.RolePermission{}, nil
+				},
 				UserFn: func(c echo.Context) gorsk.AuthUser {
 					return gorsk.AuthUser{
 						ID:         1,
@@ -193,6 +294,10 @@ func TestList(t *testing.T) {
 				Offset: 200,
 			}},
 			rbac: &mock.RBAC{
+				GrantFn: func(c echo.Context, p gorsk.Permission) (gorsk.RolePermission, error) {
+					assert.Equal(t, gorsk.UsersRead, p)
+					return gorsk.RolePermission{Scope: gorsk.ScopeAny}, nil
+				},
 				UserFn: func(c echo.Context) gorsk.AuthUser {
 					return gorsk.AuthUser{
 						ID:         1,
@@ -255,7 +360,7 @@ func TestList(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
+			s := user.New(nil, tt.udb, nil, tt.rbac, nil, nil, nil, nil)
 			usrs, err := s.List(tt.args.c, tt.args.pgn)
 			assert.Equal(t, tt.wantData, usrs)
 			assert.Equal(t, tt.wantErr, err != nil)
@@ -309,11 +414,52 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: gorsk.ErrGeneric,
 		},
+		{
+			name: "Fail on permission",
+			args: args{id: 1},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Base:       gorsk.Base{ID: id},
+						CompanyID:  2,
+						LocationID: 3,
+						Role:       &gorsk.Role{AccessLevel: gorsk.UserRole},
+					}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					assert.Equal(t, gorsk.UsersDelete, p)
+					assert.Equal(t, gorsk.Resource{UserID: 1, CompanyID: 2, LocationID: 3}, r)
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on impersonation",
+			args: args{id: 1},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Base: gorsk.Base{ID: id},
+						Role: &gorsk.Role{AccessLevel: gorsk.UserRole},
+					}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 2, ActorID: 3}
+				}},
+			wantErr: gorsk.ErrImpersonation,
+		},
 		{
 			name: "Success",
 			args: args{id: 1},
@@ -339,14 +485,20 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
 					return nil
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 2}
+				},
+				InvalidateFn: func(id int) {
+					assert.Equal(t, 1, id)
 				}},
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
+			s := user.New(nil, tt.udb, nil, tt.rbac, nil, nil, nil, nil)
 			err := s.Delete(tt.args.c, tt.args.id)
 			if err != tt.wantErr {
 				t.Errorf("Expected error %v, received %v", tt.wantErr, err)
@@ -374,7 +526,7 @@ func TestUpdate(t *testing.T) {
 				ID: 1,
 			}},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -385,7 +537,7 @@ func TestUpdate(t *testing.T) {
 				ID: 1,
 			}},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
 					return nil
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -423,8 +575,12 @@ func TestUpdate(t *testing.T) {
 				Phone:     "234567",
 			}},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c echo.Context, p gorsk.Permission, id int) error {
+					assert.Equal(t, gorsk.UsersUpdate, p)
 					return nil
+				},
+				InvalidateFn: func(id int) {
+					assert.Equal(t, 1, id)
 				}},
 			wantData: gorsk.User{
 				Base: gorsk.Base{
@@ -470,7 +626,7 @@ func TestUpdate(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
+			s := user.New(nil, tt.udb, nil, tt.rbac, nil, nil, nil, nil)
 			usr, err := s.Update(tt.args.c, tt.args.upd)
 			assert.Equal(t, tt.wantData, usr)
 			assert.Equal(t, tt.wantErr, err)
@@ -479,8 +635,245 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestInitialize(t *testing.T) {
-	u := user.Initialize(nil, nil, nil)
+	u := user.Initialize(nil, nil, nil, nil, nil, nil)
 	if u == nil {
 		t.Error("User service not initialized")
 	}
 }
+
+func TestUnlock(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      int
+		wantErr error
+		udb     *mockdb.User
+		rbac    *mock.RBAC
+	}{
+		{
+			name:    "Fail on ViewUser",
+			id:      1,
+			wantErr: gorsk.ErrGeneric,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Fail on RBAC",
+			id:   1,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Base: gorsk.Base{ID: id},
+						Role: &gorsk.Role{
+							AccessLevel: gorsk.AdminRole,
+						},
+					}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Success",
+			id:   1,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{
+						Base:            gorsk.Base{ID: id},
+						FailedLogins:    5,
+						LastFailedLogin: mock.TestTime(2018),
+						Role: &gorsk.Role{
+							AccessLevel: gorsk.UserRole,
+						},
+					}, nil
+				},
+				UnlockFn: func(db orm.DB, usr gorsk.User) error {
+					if usr.FailedLogins != 0 || !usr.LastFailedLogin.IsZero() {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := user.New(nil, tt.udb, nil, tt.rbac, nil, nil, nil, nil)
+			err := s.Unlock(nil, tt.id)
+			if err != tt.wantErr {
+				t.Errorf("Expected error %v, received %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRequirePasswordChange(t *testing.T) {
+	cases := []struct {
+		name    string
+		wantErr error
+		rbac    *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var required, revoked bool
+			udb := &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}}, nil
+				},
+				RequirePasswordChangeFn: func(db orm.DB, usr gorsk.User) error {
+					required = usr.MustChangePassword
+					return nil
+				},
+			}
+			sdb := &mockdb.Session{
+				DeleteByUserFn: func(db orm.DB, id int) error {
+					revoked = id == 1
+					return nil
+				},
+			}
+			s := user.New(nil, udb, sdb, tt.rbac, nil, nil, nil, nil)
+			err := s.RequirePasswordChange(nil, 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, required)
+			assert.Equal(t, tt.wantErr == nil, revoked)
+		})
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	superAdmin := &mock.RBAC{
+		EnforceFn: func(echo.Context, gorsk.Permission, gorsk.Resource) error {
+			return nil
+		},
+		UserFn: func(echo.Context) gorsk.AuthUser {
+			return gorsk.AuthUser{ID: 1, Role: gorsk.SuperAdminRole}
+		}}
+	cases := []struct {
+		name     string
+		id       int
+		wantErr  error
+		wantData gorsk.AuthToken
+		wantLog  bool
+		udb      *mockdb.User
+		rbac     *mock.RBAC
+		tg       mock.JWT
+	}{
+		{
+			name:    "Fail on nested impersonation",
+			id:      2,
+			wantErr: gorsk.ErrImpersonation,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 3, ActorID: 1}
+				}},
+		},
+		{
+			name:    "Fail on impersonating self",
+			id:      1,
+			wantErr: user.ErrImpersonateSelf,
+			rbac:    superAdmin,
+		},
+		{
+			name:    "Fail on ViewUser",
+			id:      2,
+			wantErr: gorsk.ErrGeneric,
+			rbac:    superAdmin,
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:    "Fail on RBAC",
+			id:      2,
+			wantErr: gorsk.ErrGeneric,
+			rbac: &mock.RBAC{
+				EnforceFn: func(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+					if r.UserID != 2 || r.RoleID != gorsk.SuperAdminRole {
+						t.Error("impersonation was not enforced on the impersonated user")
+					}
+					return gorsk.ErrGeneric
+				},
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, Role: gorsk.SuperAdminRole}
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.SuperAdminRole}, nil
+				}},
+		},
+		{
+			name:    "Fail on GenerateImpersonationToken",
+			id:      2,
+			wantErr: gorsk.ErrGeneric,
+			rbac:    superAdmin,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}}, nil
+				}},
+			tg: mock.JWT{
+				GenerateImpersonationTokenFn: func(gorsk.User, int) (string, error) {
+					return "", gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:    "Success",
+			id:      2,
+			rbac:    superAdmin,
+			wantLog: true,
+			udb: &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: id}}, nil
+				}},
+			tg: mock.JWT{
+				GenerateImpersonationTokenFn: func(u gorsk.User, actorID int) (string, error) {
+					if u.ID != 2 || actorID != 1 {
+						return "", gorsk.ErrGeneric
+					}
+					return "impersonationtoken", nil
+				}},
+			wantData: gorsk.AuthToken{Token: "impersonationtoken"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged bool
+			log := &mock.Logger{
+				LogFn: func(c echo.Context, source, msg string, err error, params map[string]interface{}) {
+					logged = true
+					assert.Equal(t, "Impersonation started", msg)
+					assert.Equal(t, map[string]interface{}{"user_id": 2, "actor_id": 1}, params)
+				}}
+			s := user.New(nil, tt.udb, nil, tt.rbac, nil, tt.tg, nil, log)
+			token, err := s.Impersonate(nil, tt.id)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, token)
+			assert.Equal(t, tt.wantLog, logged)
+		})
+	}
+}
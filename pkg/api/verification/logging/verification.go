@@ -0,0 +1,70 @@
+package verification
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/verification"
+)
+
+// New creates new email verification logging service
+func New(svc verification.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents email verification logging service
+type LogService struct {
+	verification.Service
+	logger gorsk.Logger
+}
+
+const name = "verification"
+
+// Resend logging
+func (ls *LogService) Resend(c echo.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Resend email verification request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Resend(c, email)
+}
+
+// Verify logging
+func (ls *LogService) Verify(c echo.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Verify email request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Verify(c, token)
+}
+
+// Change logging
+func (ls *LogService) Change(c echo.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			c,
+			name, "Change email request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Change(c, email)
+}
@@ -0,0 +1,30 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// User represents the client for user table
+type User struct{}
+
+// View returns single user by ID
+func (u User) View(db orm.DB, id int) (gorsk.User, error) {
+	user := gorsk.User{Base: gorsk.Base{ID: id}}
+	err := db.Select(&user)
+	return user, err
+}
+
+// FindByEmail queries for single user by email, case insensitive
+func (u User) FindByEmail(db orm.DB, email string) (gorsk.User, error) {
+	var user gorsk.User
+	err := db.Model(&user).Where("lower(email) = lower(?)", email).Select()
+	return user, err
+}
+
+// UpdateEmail updates user's email, its verification time and whether the user is active
+func (u User) UpdateEmail(db orm.DB, user gorsk.User) error {
+	_, err := db.Model(&user).Column("email", "email_verified_at", "active", "updated_at").WherePK().Update()
+	return err
+}
@@ -0,0 +1,47 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/verification/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+)
+
+func TestUpdateEmail(t *testing.T) {
+	user := gorsk.User{
+		Base:      gorsk.Base{ID: 2},
+		FirstName: "Tom",
+		LastName:  "Jones",
+		Username:  "tomjones",
+		Email:     "tomjones@mail.com",
+		RoleID:    1,
+		CompanyID: 1,
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Role{
+		ID:          1,
+		AccessLevel: 1,
+		Name:        "SUPER_ADMIN"}, &user); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.User{}
+
+	user.VerifyEmail("tom@mail.com", time.Now())
+	user.FirstName = "Thomas"
+	assert.Nil(t, udb.UpdateEmail(db, user))
+
+	updated, err := udb.FindByEmail(db, "TOM@mail.com")
+	assert.Nil(t, err)
+	assert.True(t, updated.EmailVerified())
+	assert.Equal(t, "Tom", updated.FirstName, "only email columns are updated")
+}
@@ -0,0 +1,36 @@
+package pgsql
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Verification represents the client for email_verification table
+type Verification struct{}
+
+// Create creates a new email verification token
+func (v Verification) Create(db orm.DB, ver gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+	err := db.Insert(&ver)
+	return ver, err
+}
+
+// FindByHash returns email verification token by its hash
+func (v Verification) FindByHash(db orm.DB, hash string) (gorsk.EmailVerification, error) {
+	var ver gorsk.EmailVerification
+	err := db.Model(&ver).Where("hash = ?", hash).Select()
+	return ver, err
+}
+
+// FindActivation returns latest activation token of the user, including expired ones
+func (v Verification) FindActivation(db orm.DB, userID int) (gorsk.EmailVerification, error) {
+	var ver gorsk.EmailVerification
+	err := db.Model(&ver).Where("user_id = ? and activate", userID).Order("id desc").First()
+	return ver, err
+}
+
+// DeleteByUser deletes all of user's email verification tokens
+func (v Verification) DeleteByUser(db orm.DB, userID int) error {
+	_, err := db.Model((*gorsk.EmailVerification)(nil)).Where("user_id = ?", userID).Delete()
+	return err
+}
@@ -0,0 +1,37 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/verification/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+)
+
+func TestFindActivation(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.EmailVerification{})
+
+	expired := time.Now().Add(-time.Hour)
+	if err := mock.InsertMultiple(db,
+		&gorsk.EmailVerification{UserID: 1, Email: "tom@mail.com", Hash: "change", ExpiresAt: expired},
+		&gorsk.EmailVerification{UserID: 2, Email: "jane@mail.com", Hash: "activate", Activate: true, ExpiresAt: expired},
+	); err != nil {
+		t.Error(err)
+	}
+
+	vdb := pgsql.Verification{}
+
+	_, err := vdb.FindActivation(db, 1)
+	assert.Equal(t, pg.ErrNoRows, err)
+
+	ver, err := vdb.FindActivation(db, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "activate", ver.Hash, "expired activation tokens are found")
+}
@@ -0,0 +1,75 @@
+package verification
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/verification/platform/pgsql"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+)
+
+// Service represents email verification application interface
+type Service interface {
+	Send(echo.Context, gorsk.User, string) error
+	SendActivation(echo.Context, gorsk.User) error
+	Resend(echo.Context, string) error
+	Verify(echo.Context, string) error
+	Change(echo.Context, string) error
+}
+
+// New creates new email verification application service
+func New(db *pg.DB, udb UserDB, vdb VerificationDB, rbac RBAC, mailer Mailer, cfg Config) Verification {
+	return Verification{db: db, udb: udb, vdb: vdb, rbac: rbac, mailer: mailer, cfg: cfg}
+}
+
+// Initialize initalizes email verification application service with defaults
+func Initialize(db *pg.DB, rbac RBAC, mailer Mailer, cfg Config) Verification {
+	return New(db, pgsql.User{}, pgsql.Verification{}, rbac, mailer, cfg)
+}
+
+// Verification represents email verification application service
+type Verification struct {
+	db     *pg.DB
+	udb    UserDB
+	vdb    VerificationDB
+	rbac   RBAC
+	mailer Mailer
+	cfg    Config
+}
+
+// Config holds email verification settings
+type Config struct {
+	// Duration is how long verification tokens stay valid
+	Duration time.Duration
+	// URL is the page users are sent to, with the verification token appended as token query parameter
+	URL string
+}
+
+// UserDB represents user repository interface
+type UserDB interface {
+	View(orm.DB, int) (gorsk.User, error)
+	FindByEmail(orm.DB, string) (gorsk.User, error)
+	UpdateEmail(orm.DB, gorsk.User) error
+}
+
+// VerificationDB represents email verification token repository interface
+type VerificationDB interface {
+	Create(orm.DB, gorsk.EmailVerification) (gorsk.EmailVerification, error)
+	FindByHash(orm.DB, string) (gorsk.EmailVerification, error)
+	FindActivation(orm.DB, int) (gorsk.EmailVerification, error)
+	DeleteByUser(orm.DB, int) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(echo.Context) gorsk.AuthUser
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
+}
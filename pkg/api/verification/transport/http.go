@@ -0,0 +1,145 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/ribice/gorsk/pkg/api/verification"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents email verification http service
+type HTTP struct {
+	svc verification.Service
+}
+
+// NewHTTP creates new email verification http service. Verification routes are registered on e,
+// as users may have to verify their email before they can log in.
+func NewHTTP(svc verification.Service, e *echo.Echo, r *echo.Group) {
+	h := HTTP{svc}
+
+	// swagger:operation POST /email/verify email emailVerify
+	// ---
+	// summary: Verifies email address.
+	// description: Confirms the email address using the token from verification email. If the user requested an email change, the new address takes effect.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/emailVerify"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/email/verify", h.verify)
+
+	// swagger:operation POST /email/resend email emailResend
+	// ---
+	// summary: Resends verification email.
+	// description: Emails a new verification link to the user with unverified email. The response does not reveal whether the email is registered.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/emailResend"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/email/resend", h.resend)
+
+	// swagger:operation PUT /v1/me/email email emailChange
+	// ---
+	// summary: Changes email address.
+	// description: Emails a verification link to the new address. Currently logged user's email is changed once the new address is verified.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/emailChange"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/errMsg"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	r.PUT("/me/email", h.change)
+}
+
+// Email verification request
+// swagger:model emailVerify
+type verifyReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (h *HTTP) verify(c echo.Context) error {
+	r := new(verifyReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.Verify(c, r.Token); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Verification email resend request
+// swagger:model emailResend
+type resendReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *HTTP) resend(c echo.Context) error {
+	r := new(resendReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.Resend(c, r.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Email change request
+// swagger:model emailChange
+type changeReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *HTTP) change(c echo.Context) error {
+	r := new(changeReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.Change(c, r.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
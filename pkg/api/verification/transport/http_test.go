@@ -0,0 +1,197 @@
+package transport_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/verification"
+	"github.com/ribice/gorsk/pkg/api/verification/transport"
+
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+	"github.com/ribice/gorsk/pkg/utl/server"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+		vdb        *mockdb.EmailVerification
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid token",
+			req:  `{"token":"token"}`,
+			vdb: &mockdb.EmailVerification{
+				FindByHashFn: func(orm.DB, string) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"token":"token"}`,
+			vdb: &mockdb.EmailVerification{
+				FindByHashFn: func(orm.DB, string) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{UserID: 1, Email: "johndoe@mail.com", ExpiresAt: time.Now().Add(time.Hour)}, nil
+				},
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+				UpdateEmailFn: func(orm.DB, gorsk.User) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(verification.New(nil, tt.udb, tt.vdb, nil, nil, verification.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/email/verify", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestResend(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"johndoe"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown email",
+			req:  `{"email":"johndoe@mail.com"}`,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(verification.New(nil, tt.udb, nil, nil, nil, verification.Config{}), r, r.Group("/v1"))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/email/resend", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestChange(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		rbac       *mock.RBAC
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"john"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on impersonation",
+			req:  `{"email":"john@mail.com"}`,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, ActorID: 2}
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `{"email":"john@mail.com"}`,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1}
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			vdb := &mockdb.EmailVerification{
+				CreateFn: func(db orm.DB, v gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+					return v, nil
+				},
+			}
+			mailer := &mock.Mailer{SendFn: func(mail.Message) error { return nil }}
+			transport.NewHTTP(verification.New(nil, tt.udb, vdb, tt.rbac, mailer, verification.Config{}), r, r.Group(""))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("PUT", ts.URL+"/me/email", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
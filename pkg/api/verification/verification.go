@@ -0,0 +1,177 @@
+// Package verification contains email verification application services
+package verification
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+)
+
+// Custom errors
+var (
+	ErrInvalidToken   = echo.NewHTTPError(http.StatusBadRequest, "Invalid or expired email verification token")
+	ErrEmailUnchanged = echo.NewHTTPError(http.StatusBadRequest, "Email is the same as the current one")
+	ErrEmailTaken     = echo.NewHTTPError(http.StatusConflict, "Email already exists")
+)
+
+const verifyBody = `Hi %s,
+
+please confirm your email address by visiting:
+
+%s
+
+The link expires in %d minutes. If you did not request it, you can ignore this email.`
+
+// Send emails a verification link for the address to the user. Once verified, the address becomes user's email.
+func (v Verification) Send(c echo.Context, u gorsk.User, email string) error {
+	return v.send(u, email, false)
+}
+
+// SendActivation emails a verification link to an inactive user, activating the account once verified
+func (v Verification) SendActivation(c echo.Context, u gorsk.User) error {
+	return v.send(u, u.Email, true)
+}
+
+func (v Verification) send(u gorsk.User, email string, activate bool) error {
+	token, err := secure.NewToken()
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(v.cfg.URL)
+	if err != nil {
+		return err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	if _, err := v.vdb.Create(v.db, gorsk.EmailVerification{
+		UserID:    u.ID,
+		Email:     email,
+		Hash:      secure.HashToken(token),
+		Activate:  activate,
+		ExpiresAt: time.Now().Add(v.cfg.Duration),
+	}); err != nil {
+		return err
+	}
+
+	return v.mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body:    fmt.Sprintf(verifyBody, u.FirstName, link, int(v.cfg.Duration.Minutes())),
+	})
+}
+
+// Resend emails a new verification link to the user with given unverified email. Inactive users get one only
+// while awaiting activation. To avoid disclosing which emails are registered, skipped users are not reported.
+func (v Verification) Resend(c echo.Context, email string) error {
+	u, err := v.udb.FindByEmail(v.db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified() {
+		return nil
+	}
+	if u.Active {
+		return v.send(u, u.Email, false)
+	}
+
+	_, err = v.vdb.FindActivation(v.db, u.ID)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return v.send(u, u.Email, true)
+}
+
+// Verify confirms the email address the token was sent to, making it user's email.
+// Verification consumes all of user's verification tokens, and activates accounts awaiting activation.
+func (v Verification) Verify(c echo.Context, token string) error {
+	ver, err := v.vdb.FindByHash(v.db, secure.HashToken(token))
+	if err == pg.ErrNoRows {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if ver.Expired(time.Now()) {
+		return ErrInvalidToken
+	}
+
+	u, err := v.udb.View(v.db, ver.UserID)
+	if err == pg.ErrNoRows {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(u.Email, ver.Email) {
+		if err := v.available(u.ID, ver.Email); err != nil {
+			return err
+		}
+	}
+
+	if err := v.vdb.DeleteByUser(v.db, u.ID); err != nil {
+		return err
+	}
+
+	u.VerifyEmail(ver.Email, time.Now())
+	if ver.Activate {
+		u.Active = true
+	}
+	return v.udb.UpdateEmail(v.db, u)
+}
+
+// Change requests changing currently logged user's email. The change takes effect
+// once the new address is confirmed. Email cannot be changed while impersonating.
+func (v Verification) Change(c echo.Context, email string) error {
+	au := v.rbac.User(c)
+	if au.ActorID != 0 {
+		return gorsk.ErrImpersonation
+	}
+
+	u, err := v.udb.View(v.db, au.ID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(u.Email, email) {
+		return ErrEmailUnchanged
+	}
+
+	if err := v.available(u.ID, email); err != nil {
+		return err
+	}
+
+	return v.Send(c, u, email)
+}
+
+// available checks that no other user has the email
+func (v Verification) available(userID int, email string) error {
+	other, err := v.udb.FindByEmail(v.db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if other.ID != userID {
+		return ErrEmailTaken
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package verification_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/api/verification"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/mock/mockdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var cfg = verification.Config{Duration: 24 * time.Hour, URL: "https://gorsk.dev/email/verify"}
+
+func TestResend(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		wantSent bool
+		udb      *mockdb.User
+		vdb      *mockdb.EmailVerification
+	}{
+		{
+			name: "Unknown email",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Fail on FindByEmail",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Already verified",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true, EmailVerifiedAt: time.Now()}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on Create",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true}, nil
+				},
+			},
+			vdb: &mockdb.EmailVerification{
+				CreateFn: func(orm.DB, gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{}, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Inactive user",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+			vdb: &mockdb.EmailVerification{
+				FindActivationFn: func(orm.DB, int) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:     "Success awaiting activation",
+			wantSent: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+			vdb: &mockdb.EmailVerification{
+				FindActivationFn: func(orm.DB, int) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{UserID: 1, Activate: true, ExpiresAt: time.Now().Add(-time.Hour)}, nil
+				},
+				CreateFn: func(db orm.DB, v gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+					assert.True(t, v.Activate)
+					return v, nil
+				},
+			},
+		},
+		{
+			name:     "Success",
+			wantSent: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true}, nil
+				},
+			},
+			vdb: &mockdb.EmailVerification{
+				CreateFn: func(db orm.DB, v gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+					if v.UserID != 1 || v.Email != "johndoe@mail.com" || len(v.Hash) != 64 || v.Activate || v.ExpiresAt.Before(time.Now().Add(23*time.Hour)) {
+						t.Errorf("unexpected email verification: %+v", v)
+					}
+					return v, nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []mail.Message
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+			s := verification.New(nil, tt.udb, tt.vdb, nil, mailer, cfg)
+			err := s.Resend(nil, "johndoe@mail.com")
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantSent {
+				assert.Empty(t, sent)
+				return
+			}
+			if assert.Len(t, sent, 1) {
+				assert.Equal(t, "johndoe@mail.com", sent[0].To)
+				assert.Contains(t, sent[0].Body, "https://gorsk.dev/email/verify?token=")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	valid := func(email string) *mockdb.EmailVerification {
+		return &mockdb.EmailVerification{
+			FindByHashFn: func(orm.DB, string) (gorsk.EmailVerification, error) {
+				return gorsk.EmailVerification{UserID: 1, Email: email, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			DeleteByUserFn: func(orm.DB, int) error {
+				return nil
+			},
+		}
+	}
+	cases := []struct {
+		name       string
+		wantErr    error
+		wantEmail  string
+		wantActive bool
+		udb        *mockdb.User
+		vdb        *mockdb.EmailVerification
+	}{
+		{
+			name:    "Unknown token",
+			wantErr: verification.ErrInvalidToken,
+			vdb: &mockdb.EmailVerification{
+				FindByHashFn: func(orm.DB, string) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{}, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Expired token",
+			wantErr: verification.ErrInvalidToken,
+			vdb: &mockdb.EmailVerification{
+				FindByHashFn: func(orm.DB, string) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}, nil
+				},
+			},
+		},
+		{
+			name:    "New email taken",
+			wantErr: verification.ErrEmailTaken,
+			vdb:     valid("janedoe@mail.com"),
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 2}}, nil
+				},
+			},
+		},
+		{
+			name:      "Success",
+			wantEmail: "johndoe@mail.com",
+			vdb:       valid("johndoe@mail.com"),
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+		},
+		{
+			name:       "Success activating",
+			wantEmail:  "johndoe@mail.com",
+			wantActive: true,
+			vdb: &mockdb.EmailVerification{
+				FindByHashFn: func(orm.DB, string) (gorsk.EmailVerification, error) {
+					return gorsk.EmailVerification{UserID: 1, Email: "johndoe@mail.com", Activate: true, ExpiresAt: time.Now().Add(time.Hour)}, nil
+				},
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+		},
+		{
+			name:      "Success changing email",
+			wantEmail: "john@mail.com",
+			vdb:       valid("john@mail.com"),
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", EmailVerifiedAt: time.Now().Add(-time.Hour)}, nil
+				},
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated gorsk.User
+			if tt.udb != nil {
+				tt.udb.UpdateEmailFn = func(db orm.DB, u gorsk.User) error {
+					updated = u
+					return nil
+				}
+			}
+			s := verification.New(nil, tt.udb, tt.vdb, nil, nil, cfg)
+			err := s.Verify(nil, "token")
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantEmail != "" {
+				assert.Equal(t, tt.wantEmail, updated.Email)
+				assert.True(t, updated.EmailVerified())
+				assert.Equal(t, tt.wantActive, updated.Active)
+			}
+		})
+	}
+}
+
+func TestChange(t *testing.T) {
+	user := func(echo.Context) gorsk.AuthUser {
+		return gorsk.AuthUser{ID: 1}
+	}
+	cases := []struct {
+		name     string
+		email    string
+		wantErr  error
+		wantSent bool
+		rbac     *mock.RBAC
+		udb      *mockdb.User
+	}{
+		{
+			name:    "Fail on impersonation",
+			email:   "john@mail.com",
+			wantErr: gorsk.ErrImpersonation,
+			rbac: &mock.RBAC{
+				UserFn: func(echo.Context) gorsk.AuthUser {
+					return gorsk.AuthUser{ID: 1, ActorID: 2}
+				},
+			},
+		},
+		{
+			name:    "Same email",
+			email:   "JohnDoe@mail.com",
+			wantErr: verification.ErrEmailUnchanged,
+			rbac:    &mock.RBAC{UserFn: user},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+		},
+		{
+			name:    "Email taken",
+			email:   "janedoe@mail.com",
+			wantErr: verification.ErrEmailTaken,
+			rbac:    &mock.RBAC{UserFn: user},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 2}}, nil
+				},
+			},
+		},
+		{
+			name:     "Success",
+			email:    "john@mail.com",
+			wantSent: true,
+			rbac:     &mock.RBAC{UserFn: user},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (gorsk.User, error) {
+					return gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+				FindByEmailFn: func(orm.DB, string) (gorsk.User, error) {
+					return gorsk.User{}, pg.ErrNoRows
+				},
+				UpdateEmailFn: func(orm.DB, gorsk.User) error {
+					t.Error("email was changed before verification")
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []mail.Message
+			mailer := &mock.Mailer{SendFn: func(m mail.Message) error {
+				sent = append(sent, m)
+				return nil
+			}}
+			vdb := &mockdb.EmailVerification{
+				CreateFn: func(db orm.DB, v gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+					return v, nil
+				},
+			}
+			s := verification.New(nil, tt.udb, vdb, tt.rbac, mailer, cfg)
+			err := s.Change(nil, tt.email)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantSent && assert.Len(t, sent, 1) {
+				assert.Equal(t, tt.email, sent[0].To)
+			}
+		})
+	}
+}
@@ -26,6 +26,23 @@ type Configuration struct {
 	DB     *Database    `yaml:"database,omitempty"`
 	JWT    *JWT         `yaml:"jwt,omitempty"`
 	App    *Application `yaml:"application,omitempty"`
+	Mail   *Mail        `yaml:"mail,omitempty"`
+	Signup *Signup      `yaml:"signup,omitempty"`
+	// PasswordHashing defaults to argon2id with default parameters
+	PasswordHashing *PasswordHashing `yaml:"password_hashing,omitempty"`
+	// PasswordRules defaults to passwords of at least 8 characters with application.min_password_strength
+	PasswordRules *PasswordRules `yaml:"password_rules,omitempty"`
+	// BreachedPasswords disables screening of passwords known from data breaches if not set
+	BreachedPasswords *BreachedPasswords `yaml:"breached_passwords,omitempty"`
+	// Cookies enables browser session mode, keeping auth tokens in cookies, if set
+	Cookies *Cookies `yaml:"cookies,omitempty"`
+	// PolicyFile is the path to YAML authorization policy declaring permissions granted to roles.
+	// Permissions are loaded from role_permissions table, except those the policy declares,
+	// which are granted only as declared.
+	PolicyFile string `yaml:"policy_file,omitempty"`
+	// OIDC holds OpenID Connect identity providers by name. Client secret of a provider
+	// is read from OIDC_<NAME>_CLIENT_SECRET environment variable.
+	OIDC map[string]*OIDCProvider `yaml:"oidc,omitempty"`
 }
 
 // Database holds data necessary for database configuration
@@ -40,6 +57,13 @@ type Server struct {
 	Debug        bool   `yaml:"debug,omitempty"`
 	ReadTimeout  int    `yaml:"read_timeout_seconds,omitempty"`
 	WriteTimeout int    `yaml:"write_timeout_seconds,omitempty"`
+
+	// AllowOrigins are origins allowed to send requests with credentials, such as cookies of browser session mode
+	AllowOrigins []string `yaml:"allow_origins,omitempty"`
+
+	// TrustedProxies are IP addresses or CIDR ranges of reverse proxies, whose X-Forwarded-For and X-Real-IP headers
+	// are trusted to carry client IP address. Without them, client IP address is the one of the connection's peer.
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
 }
 
 // JWT holds data necessary for JWT configuration
@@ -49,10 +73,143 @@ type JWT struct {
 	RefreshDuration  int    `yaml:"refresh_duration_minutes,omitempty"`
 	MaxRefresh       int    `yaml:"max_refresh_minutes,omitempty"`
 	SigningAlgorithm string `yaml:"signing_algorithm,omitempty"`
+	DenylistStore    string `yaml:"denylist_store,omitempty"`
+	Issuer           string `yaml:"issuer,omitempty"`
+	Audience         string `yaml:"audience,omitempty"`
+	ClockSkew        int    `yaml:"clock_skew_seconds,omitempty"`
+
+	// SigningKeyID selects the key tokens are signed with. Without it, tokens are signed with JWT_SECRET.
+	SigningKeyID string    `yaml:"signing_key_id,omitempty"`
+	Keys         []*JWTKey `yaml:"keys,omitempty"`
+}
+
+// JWTKey holds data necessary for loading asymmetric JWT key.
+// Keys given only the public key file verify tokens signed before key rotation.
+type JWTKey struct {
+	ID             string `yaml:"id,omitempty"`
+	Algorithm      string `yaml:"algorithm,omitempty"`
+	PrivateKeyFile string `yaml:"private_key_file,omitempty"`
+	PublicKeyFile  string `yaml:"public_key_file,omitempty"`
 }
 
 // Application holds application configuration details
 type Application struct {
-	MinPasswordStr int    `yaml:"min_password_strength,omitempty"`
-	SwaggerUIPath  string `yaml:"swagger_ui_path,omitempty"`
+	MinPasswordStr     int    `yaml:"min_password_strength,omitempty"`
+	SwaggerUIPath      string `yaml:"swagger_ui_path,omitempty"`
+	MaxLoginAttempts   int    `yaml:"max_login_attempts,omitempty"`
+	MaxIPLoginAttempts int    `yaml:"max_ip_login_attempts,omitempty"`
+	LoginDelay         int    `yaml:"login_delay_seconds,omitempty"`
+	LockoutDuration    int    `yaml:"lockout_minutes,omitempty"`
+	MFAIssuer          string `yaml:"mfa_issuer,omitempty"`
+	PasswordResetTTL   int    `yaml:"password_reset_minutes,omitempty"`
+	PasswordResetURL   string `yaml:"password_reset_url,omitempty"`
+
+	// PasswordHistory is the number of most recent passwords users cannot reuse, and PasswordMaxAge
+	// the number of days after which passwords have to be changed. Companies can override both.
+	PasswordHistory int `yaml:"password_history,omitempty"`
+	PasswordMaxAge  int `yaml:"password_max_age_days,omitempty"`
+
+	// EmailVerification is the policy for users with unverified email: empty (no restrictions),
+	// login (cannot log in) or restrict (cannot access /v1 routes)
+	EmailVerification    string `yaml:"email_verification,omitempty"`
+	EmailVerificationTTL int    `yaml:"email_verification_minutes,omitempty"`
+	EmailVerificationURL string `yaml:"email_verification_url,omitempty"`
+
+	InvitationTTL int    `yaml:"invitation_hours,omitempty"`
+	InvitationURL string `yaml:"invitation_url,omitempty"`
+
+	// MagicLinkTTL and MagicLinkURL configure passwordless login links. MagicLinkMaxRequests limits
+	// login links requested per email and client IP address within MagicLinkWindow.
+	MagicLinkTTL         int    `yaml:"magic_link_minutes,omitempty"`
+	MagicLinkURL         string `yaml:"magic_link_url,omitempty"`
+	MagicLinkMaxRequests int    `yaml:"magic_link_max_requests,omitempty"`
+	MagicLinkWindow      int    `yaml:"magic_link_window_minutes,omitempty"`
+
+	// LoginNotifier notifies users about logins from new devices or IP addresses: empty (disabled) or mail
+	LoginNotifier string `yaml:"login_notifier,omitempty"`
+
+	// PermissionsCacheTTL is the number of seconds role permissions, and company, location and role of users
+	// they are checked on, are cached for. Zero caches them until restart.
+	PermissionsCacheTTL int `yaml:"permissions_cache_seconds,omitempty"`
+}
+
+// Mail holds data necessary for email delivery configuration.
+// SMTP password is read from SMTP_PASSWORD environment variable.
+type Mail struct {
+	// Driver is one of smtp, file or log, and has to be set. File and log drivers write emails to File and stdout instead of sending them.
+	Driver   string `yaml:"driver,omitempty"`
+	From     string `yaml:"from,omitempty"`
+	SMTPAddr string `yaml:"smtp_addr,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	File     string `yaml:"file,omitempty"`
+}
+
+// PasswordHashing holds password hashing algorithm and its parameters.
+// Hashes of other algorithms or parameters are upgraded when users log in.
+type PasswordHashing struct {
+	// Algorithm is one of argon2id (default) or bcrypt
+	Algorithm string `yaml:"algorithm,omitempty"`
+
+	// Memory (in KiB), Iterations and Parallelism are argon2id parameters
+	Memory      uint32 `yaml:"memory_kib,omitempty"`
+	Iterations  uint32 `yaml:"iterations,omitempty"`
+	Parallelism uint8  `yaml:"parallelism,omitempty"`
+
+	BcryptCost int `yaml:"bcrypt_cost,omitempty"`
+}
+
+// PasswordRules holds rules passwords have to satisfy, in addition to zxcvbn score of application.min_password_strength
+type PasswordRules struct {
+	// MinLength defaults to 8 characters
+	MinLength int `yaml:"min_length,omitempty"`
+	// CharacterClasses is number of character classes, out of lowercase and uppercase letters, digits and symbols,
+	// passwords have to contain
+	CharacterClasses int `yaml:"character_classes,omitempty"`
+	// NotSimilar rejects passwords containing user's name, username or email
+	NotSimilar bool `yaml:"not_similar,omitempty"`
+	// Dictionary holds words passwords must not contain, such as company or product names
+	Dictionary []string `yaml:"dictionary,omitempty"`
+}
+
+// BreachedPasswords holds local corpus of passwords known from data breaches
+type BreachedPasswords struct {
+	// Format is one of hibp, for Have I Been Pwned SHA-1 file ordered by hash, or index, for binary index built from it
+	Format string `yaml:"format,omitempty"`
+	File   string `yaml:"file,omitempty"`
+}
+
+// Cookies holds browser session mode configuration
+type Cookies struct {
+	Domain string `yaml:"domain,omitempty"`
+	// SameSite is one of strict (default), lax or none
+	SameSite string `yaml:"same_site,omitempty"`
+	// Insecure allows sending cookies over plain HTTP, for local development
+	Insecure bool `yaml:"insecure,omitempty"`
+}
+
+// Signup holds self-service signup configuration
+type Signup struct {
+	// Policy is one of disabled (default), domain or invite. Users sign up with email of one of Domains,
+	// or with one of InviteCodes, and join the company and location it maps to.
+	Policy      string                       `yaml:"policy,omitempty"`
+	Domains     map[string]*SignupMembership `yaml:"domains,omitempty"`
+	InviteCodes map[string]*SignupMembership `yaml:"invite_codes,omitempty"`
+}
+
+// SignupMembership holds company and location signed up users join
+type SignupMembership struct {
+	CompanyID  int `yaml:"company_id,omitempty"`
+	LocationID int `yaml:"location_id,omitempty"`
+}
+
+// OIDCProvider holds OpenID Connect identity provider configuration
+type OIDCProvider struct {
+	Issuer      string   `yaml:"issuer,omitempty"`
+	ClientID    string   `yaml:"client_id,omitempty"`
+	RedirectURL string   `yaml:"redirect_url,omitempty"`
+	Scopes      []string `yaml:"scopes,omitempty"`
+	Provision   bool     `yaml:"provision,omitempty"`
+	RoleID      int      `yaml:"role_id,omitempty"`
+	CompanyID   int      `yaml:"company_id,omitempty"`
+	LocationID  int      `yaml:"location_id,omitempty"`
 }
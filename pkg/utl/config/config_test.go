@@ -33,10 +33,12 @@ func TestLoad(t *testing.T) {
 					Timeout:    20,
 				},
 				Server: &config.Server{
-					Port:         ":8080",
-					Debug:        true,
-					ReadTimeout:  15,
-					WriteTimeout: 20,
+					Port:           ":8080",
+					Debug:          true,
+					ReadTimeout:    15,
+					WriteTimeout:   20,
+					AllowOrigins:   []string{"https://app.gorsk.dev"},
+					TrustedProxies: []string{"10.0.0.0/8"},
 				},
 				JWT: &config.JWT{
 					MinSecretLength:  128,
@@ -44,10 +46,88 @@ func TestLoad(t *testing.T) {
 					RefreshDuration:  10,
 					MaxRefresh:       144,
 					SigningAlgorithm: "HS384",
+					DenylistStore:    "postgres",
+					Issuer:           "gorsk",
+					Audience:         "gorsk-api",
+					ClockSkew:        30,
+					SigningKeyID:     "2020",
+					Keys: []*config.JWTKey{
+						{ID: "2020", Algorithm: "EdDSA", PrivateKeyFile: "keys/2020.pem"},
+						{ID: "2019", Algorithm: "RS256", PublicKeyFile: "keys/2019.pub.pem"},
+					},
 				},
 				App: &config.Application{
-					MinPasswordStr: 3,
-					SwaggerUIPath:  "assets/swagger",
+					MinPasswordStr:     3,
+					SwaggerUIPath:      "assets/swagger",
+					MaxLoginAttempts:   3,
+					MaxIPLoginAttempts: 10,
+					LoginDelay:         2,
+					LockoutDuration:    30,
+					MFAIssuer:          "Gorsk Test",
+					PasswordResetTTL:   30,
+					PasswordResetURL:   "https://gorsk.dev/password/reset",
+					PasswordHistory:    3,
+					PasswordMaxAge:     90,
+
+					EmailVerification:    "login",
+					EmailVerificationTTL: 1440,
+					EmailVerificationURL: "https://gorsk.dev/email/verify",
+
+					InvitationTTL: 168,
+					InvitationURL: "https://gorsk.dev/invitations/accept",
+
+					MagicLinkTTL:         15,
+					MagicLinkURL:         "https://gorsk.dev/login/link",
+					MagicLinkMaxRequests: 3,
+					MagicLinkWindow:      60,
+
+					LoginNotifier:       "mail",
+					PermissionsCacheTTL: 300,
+				},
+				Mail: &config.Mail{
+					Driver:   "smtp",
+					From:     "gorsk@mail.com",
+					SMTPAddr: "smtp.mail.com:587",
+					Username: "gorsk",
+				},
+				Signup: &config.Signup{
+					Policy: "domain",
+					Domains: map[string]*config.SignupMembership{
+						"gorsk.dev": {CompanyID: 1, LocationID: 1},
+					},
+				},
+				PasswordHashing: &config.PasswordHashing{
+					Algorithm:   "argon2id",
+					Memory:      19456,
+					Iterations:  2,
+					Parallelism: 1,
+				},
+				PasswordRules: &config.PasswordRules{
+					MinLength:        10,
+					CharacterClasses: 3,
+					NotSimilar:       true,
+					Dictionary:       []string{"gorsk"},
+				},
+				BreachedPasswords: &config.BreachedPasswords{
+					Format: "index",
+					File:   "/var/lib/gorsk/pwned-passwords.idx",
+				},
+				Cookies: &config.Cookies{
+					Domain:   "gorsk.dev",
+					SameSite: "lax",
+				},
+				PolicyFile: "/etc/gorsk/policy.yaml",
+				OIDC: map[string]*config.OIDCProvider{
+					"corp": {
+						Issuer:      "https://login.example.com",
+						ClientID:    "gorsk",
+						RedirectURL: "http://localhost:8080/login/oidc/corp/callback",
+						Scopes:      []string{"email", "profile"},
+						Provision:   true,
+						RoleID:      200,
+						CompanyID:   1,
+						LocationID:  1,
+					},
 				},
 			},
 		},
@@ -0,0 +1,144 @@
+// Package cookie keeps auth tokens of browser sessions in HttpOnly cookies,
+// protecting cookie authenticated requests with double-submit CSRF tokens
+package cookie
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+)
+
+// Cookie and header names
+const (
+	// AccessCookie holds the access (jwt) token
+	AccessCookie = "access_token"
+	// RefreshCookie holds the refresh token, and is only sent to RefreshPath
+	RefreshCookie = "refresh_token"
+	// CSRFCookie holds the CSRF token. It is readable by scripts, which send it back in CSRFHeader.
+	CSRFCookie = "csrf_token"
+	// CSRFHeader carries the CSRF token on requests with unsafe methods
+	CSRFHeader = "X-CSRF-Token"
+	// RefreshPath is the path refresh token cookie is sent to
+	RefreshPath = "/refresh"
+)
+
+// ErrInvalidCSRF is returned for cookie authenticated requests missing matching CSRF token
+var ErrInvalidCSRF = echo.NewHTTPError(http.StatusForbidden, "CSRF token is missing or invalid")
+
+// Config holds cookie settings
+type Config struct {
+	// Domain cookies are sent to. Empty means the host that set them.
+	Domain string
+	// SameSite restricts sending cookies along with cross-site requests
+	SameSite http.SameSite
+	// Insecure allows sending cookies over plain HTTP, for local development
+	Insecure bool
+	// AccessDuration and RefreshDuration are lifetimes of access and refresh token cookies.
+	// Zero makes them last until the browser is closed.
+	AccessDuration  time.Duration
+	RefreshDuration time.Duration
+}
+
+// New creates new cookie jar
+func New(cfg Config) *Jar {
+	return &Jar{cfg: cfg}
+}
+
+// Jar sets and clears auth token cookies
+type Jar struct {
+	cfg Config
+}
+
+// SetTokens moves access and refresh tokens, if present, from the auth token to cookies,
+// along with a new CSRF token
+func (j *Jar) SetTokens(c echo.Context, t *gorsk.AuthToken) error {
+	if t.Token == "" && t.RefreshToken == "" {
+		return nil
+	}
+
+	csrf, err := secure.NewToken()
+	if err != nil {
+		return err
+	}
+
+	if t.Token != "" {
+		c.SetCookie(j.cookie(AccessCookie, t.Token, "/", j.cfg.AccessDuration, true))
+		t.Token = ""
+	}
+	if t.RefreshToken != "" {
+		c.SetCookie(j.cookie(RefreshCookie, t.RefreshToken, RefreshPath, j.cfg.RefreshDuration, true))
+		t.RefreshToken = ""
+	}
+	c.SetCookie(j.cookie(CSRFCookie, csrf, "/", j.cfg.RefreshDuration, false))
+	return nil
+}
+
+// Clear expires all cookies set by SetTokens
+func (j *Jar) Clear(c echo.Context) {
+	for _, ck := range []*http.Cookie{
+		j.cookie(AccessCookie, "", "/", 0, true),
+		j.cookie(RefreshCookie, "", RefreshPath, 0, true),
+		j.cookie(CSRFCookie, "", "/", 0, false),
+	} {
+		ck.MaxAge = -1
+		c.SetCookie(ck)
+	}
+}
+
+// RefreshToken returns refresh token from the cookie, checking CSRF token as well
+func (j *Jar) RefreshToken(c echo.Context) (string, error) {
+	if err := CheckCSRF(c); err != nil {
+		return "", err
+	}
+	ck, err := c.Cookie(RefreshCookie)
+	if err != nil || ck.Value == "" {
+		return "", gorsk.ErrUnauthorized
+	}
+	return ck.Value, nil
+}
+
+// cookie creates a cookie with jar's domain and security settings
+func (j *Jar) cookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   j.cfg.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   !j.cfg.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: j.cfg.SameSite,
+	}
+}
+
+// AccessToken returns access token from the cookie, if any
+func AccessToken(c echo.Context) string {
+	ck, err := c.Cookie(AccessCookie)
+	if err != nil {
+		return ""
+	}
+	return ck.Value
+}
+
+// CheckCSRF requires requests with unsafe methods to carry CSRF token in CSRFHeader,
+// matching the one in CSRFCookie
+func CheckCSRF(c echo.Context) error {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	ck, err := c.Cookie(CSRFCookie)
+	if err != nil || ck.Value == "" {
+		return ErrInvalidCSRF
+	}
+	header := c.Request().Header.Get(CSRFHeader)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(ck.Value)) != 1 {
+		return ErrInvalidCSRF
+	}
+	return nil
+}
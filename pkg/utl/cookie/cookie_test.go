@@ -0,0 +1,144 @@
+package cookie_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
+)
+
+func cookies(rec *httptest.ResponseRecorder) map[string]*http.Cookie {
+	m := make(map[string]*http.Cookie)
+	for _, c := range (&http.Response{Header: rec.Header()}).Cookies() {
+		m[c.Name] = c
+	}
+	return m
+}
+
+func TestSetTokens(t *testing.T) {
+	jar := cookie.New(cookie.Config{
+		Domain:          "gorsk.dev",
+		SameSite:        http.SameSiteStrictMode,
+		AccessDuration:  15 * time.Minute,
+		RefreshDuration: time.Hour,
+	})
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest("POST", "/login", nil), rec)
+	token := gorsk.AuthToken{Token: "jwt", RefreshToken: "refresh"}
+	assert.Nil(t, jar.SetTokens(c, &token))
+	assert.Equal(t, gorsk.AuthToken{}, token)
+
+	got := cookies(rec)
+	if assert.Len(t, got, 3) {
+		access, refresh, csrf := got[cookie.AccessCookie], got[cookie.RefreshCookie], got[cookie.CSRFCookie]
+		assert.Equal(t, "jwt", access.Value)
+		assert.Equal(t, "/", access.Path)
+		assert.Equal(t, 900, access.MaxAge)
+		assert.True(t, access.HttpOnly && access.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+		assert.Equal(t, "gorsk.dev", access.Domain)
+
+		assert.Equal(t, "refresh", refresh.Value)
+		assert.Equal(t, cookie.RefreshPath, refresh.Path)
+		assert.Equal(t, 3600, refresh.MaxAge)
+		assert.True(t, refresh.HttpOnly && refresh.Secure)
+
+		assert.NotEmpty(t, csrf.Value)
+		assert.False(t, csrf.HttpOnly)
+		assert.True(t, csrf.Secure)
+	}
+
+	rec = httptest.NewRecorder()
+	c = echo.New().NewContext(httptest.NewRequest("POST", "/login", nil), rec)
+	token = gorsk.AuthToken{MFAToken: "challenge"}
+	assert.Nil(t, jar.SetTokens(c, &token))
+	assert.Equal(t, gorsk.AuthToken{MFAToken: "challenge"}, token)
+	assert.Empty(t, cookies(rec))
+}
+
+func TestClear(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest("POST", "/logout", nil), rec)
+	cookie.New(cookie.Config{Insecure: true}).Clear(c)
+
+	got := cookies(rec)
+	assert.Len(t, got, 3)
+	for name, ck := range got {
+		assert.Empty(t, ck.Value, name)
+		assert.Equal(t, -1, ck.MaxAge, name)
+		assert.False(t, ck.Secure, name)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	cases := []struct {
+		name      string
+		method    string
+		refresh   string
+		csrf      string
+		header    string
+		wantToken string
+		wantErr   error
+	}{
+		{
+			name:    "Fail on missing CSRF token",
+			method:  "POST",
+			refresh: "refresh",
+			wantErr: cookie.ErrInvalidCSRF,
+		},
+		{
+			name:    "Fail on invalid CSRF token",
+			method:  "POST",
+			refresh: "refresh",
+			csrf:    "csrf",
+			header:  "other",
+			wantErr: cookie.ErrInvalidCSRF,
+		},
+		{
+			name:    "Fail on missing refresh token",
+			method:  "POST",
+			csrf:    "csrf",
+			header:  "csrf",
+			wantErr: gorsk.ErrUnauthorized,
+		},
+		{
+			name:      "Safe methods do not need CSRF token",
+			method:    "GET",
+			refresh:   "refresh",
+			wantToken: "refresh",
+		},
+		{
+			name:      "Success",
+			method:    "POST",
+			refresh:   "refresh",
+			csrf:      "csrf",
+			header:    "csrf",
+			wantToken: "refresh",
+		},
+	}
+	jar := cookie.New(cookie.Config{})
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/refresh", nil)
+			if tt.refresh != "" {
+				req.AddCookie(&http.Cookie{Name: cookie.RefreshCookie, Value: tt.refresh})
+			}
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: cookie.CSRFCookie, Value: tt.csrf})
+			}
+			if tt.header != "" {
+				req.Header.Set(cookie.CSRFHeader, tt.header)
+			}
+			token, err := jar.RefreshToken(echo.New().NewContext(req, httptest.NewRecorder()))
+			assert.Equal(t, tt.wantToken, token)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
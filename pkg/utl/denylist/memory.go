@@ -0,0 +1,45 @@
+// Package denylist stores identifiers of access tokens revoked before their expiry
+package denylist
+
+import (
+	"sync"
+	"time"
+)
+
+// NewMemory creates new in-memory denylist
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]time.Time)}
+}
+
+// Memory is an in-memory denylist. Entries are not shared between
+// application instances, so it is suitable only for single instance deployments.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// Add adds token ID to denylist until its expiry, removing expired entries
+func (m *Memory) Add(id string, expiry time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, exp := range m.entries {
+		if now.After(exp) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[id] = expiry
+	return nil
+}
+
+// Contains checks whether token ID is denylisted
+func (m *Memory) Contains(id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exp, ok := m.entries[id]
+	if ok && time.Now().After(exp) {
+		delete(m.entries, id)
+		return false, nil
+	}
+	return ok, nil
+}
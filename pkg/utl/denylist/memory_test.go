@@ -0,0 +1,51 @@
+package denylist_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk/pkg/utl/denylist"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	cases := []struct {
+		name   string
+		id     string
+		expiry time.Time
+		lookup string
+		want   bool
+	}{
+		{
+			name:   "Not denylisted",
+			id:     "jti1",
+			expiry: time.Now().Add(time.Hour),
+			lookup: "jti2",
+			want:   false,
+		},
+		{
+			name:   "Denylisted",
+			id:     "jti1",
+			expiry: time.Now().Add(time.Hour),
+			lookup: "jti1",
+			want:   true,
+		},
+		{
+			name:   "Expired entry",
+			id:     "jti1",
+			expiry: time.Now().Add(-time.Second),
+			lookup: "jti1",
+			want:   false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			m := denylist.NewMemory()
+			assert.Nil(t, m.Add(tt.id, tt.expiry))
+			got, err := m.Contains(tt.lookup)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package denylist
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// NewPG creates new postgres backed denylist
+func NewPG(db orm.DB) *PG {
+	return &PG{db: db}
+}
+
+// PG is a postgres backed denylist, shared between all application instances.
+// Entries are stored in revoked_tokens table.
+type PG struct {
+	db orm.DB
+}
+
+// Add adds token ID to denylist until its expiry, removing expired entries
+func (p *PG) Add(id string, expiry time.Time) error {
+	if _, err := p.db.Model((*gorsk.RevokedToken)(nil)).Where("expires_at < ?", time.Now()).Delete(); err != nil {
+		return err
+	}
+	_, err := p.db.Model(&gorsk.RevokedToken{ID: id, ExpiresAt: expiry}).OnConflict("(id) DO NOTHING").Insert()
+	return err
+}
+
+// Contains checks whether token ID is denylisted
+func (p *PG) Contains(id string) (bool, error) {
+	return p.db.Model((*gorsk.RevokedToken)(nil)).Where("id = ? AND expires_at > ?", id, time.Now()).Exists()
+}
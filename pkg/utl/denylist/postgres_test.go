@@ -0,0 +1,57 @@
+package denylist_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/denylist"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPG(t *testing.T) {
+	cases := []struct {
+		name   string
+		lookup string
+		want   bool
+	}{
+		{
+			name:   "Not denylisted",
+			lookup: "notExists",
+			want:   false,
+		},
+		{
+			name:   "Denylisted",
+			lookup: "jti1",
+			want:   true,
+		},
+		{
+			name:   "Expired entry",
+			lookup: "jti2",
+			want:   false,
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.RevokedToken{})
+
+	dl := denylist.NewPG(db)
+	if err := dl.Add("jti2", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := dl.Add("jti1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dl.Contains(tt.lookup)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
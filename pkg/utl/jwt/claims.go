@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ribice/gorsk"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Custom errors
+var (
+	ErrMalformedClaims = errors.New("token claims are malformed")
+	ErrInvalidIssuer   = errors.New("token issuer is invalid")
+	ErrInvalidAudience = errors.New("token audience is invalid")
+	ErrExpired         = errors.New("token is expired")
+	ErrNotValidYet     = errors.New("token is not valid yet")
+	ErrInvalidType     = errors.New("token type is invalid")
+)
+
+// Config holds settings of registered claims
+type Config struct {
+	// Issuer and Audience are set on issued tokens and required on parsed ones, unless empty.
+	Issuer   string
+	Audience string
+
+	// ClockSkew is the leeway allowed when validating exp, nbf and iat claims.
+	ClockSkew time.Duration
+}
+
+// Claims represents claims of tokens issued by the service.
+// Subject holds user's ID.
+type Claims struct {
+	jwt.StandardClaims
+	Type       string           `json:"typ,omitempty"`
+	Username   string           `json:"u,omitempty"`
+	Email      string           `json:"e,omitempty"`
+	Role       gorsk.AccessRole `json:"r,omitempty"`
+	CompanyID  int              `json:"c,omitempty"`
+	LocationID int              `json:"l,omitempty"`
+	SessionID  int              `json:"sid,omitempty"`
+	Actor      *Actor           `json:"act,omitempty"`
+
+	EmailVerified bool `json:"ev,omitempty"`
+}
+
+// Actor represents the party acting on behalf of the token's subject (RFC 8693).
+// Subject holds the impersonating user's ID.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// UserID returns user's ID held in subject claim
+func (c *Claims) UserID() (int, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return 0, ErrMalformedClaims
+	}
+	return id, nil
+}
+
+// User returns authenticated user data held in the claims
+func (c *Claims) User() (gorsk.AuthUser, error) {
+	id, err := c.UserID()
+	if err != nil {
+		return gorsk.AuthUser{}, err
+	}
+	var actorID int
+	if c.Actor != nil {
+		if actorID, err = strconv.Atoi(c.Actor.Subject); err != nil || actorID <= 0 {
+			return gorsk.AuthUser{}, ErrMalformedClaims
+		}
+	}
+	return gorsk.AuthUser{
+		ID:         id,
+		CompanyID:  c.CompanyID,
+		LocationID: c.LocationID,
+		Username:   c.Username,
+		Email:      c.Email,
+		Role:       c.Role,
+		SessionID:  c.SessionID,
+		TokenID:    c.Id,
+		ActorID:    actorID,
+
+		TokenExpiresAt: time.Unix(c.ExpiresAt, 0),
+		EmailVerified:  c.EmailVerified,
+	}, nil
+}
+
+// validate checks registered claims, allowing for clock skew in time claims
+func (c *Claims) validate(cfg Config, now time.Time) error {
+	if c.Id == "" || c.ExpiresAt == 0 || c.IssuedAt == 0 {
+		return ErrMalformedClaims
+	}
+	if cfg.Issuer != "" && c.Issuer != cfg.Issuer {
+		return ErrInvalidIssuer
+	}
+	if cfg.Audience != "" && c.Audience != cfg.Audience {
+		return ErrInvalidAudience
+	}
+	if now.Add(-cfg.ClockSkew).Unix() > c.ExpiresAt {
+		return ErrExpired
+	}
+	if now.Add(cfg.ClockSkew).Unix() < c.NotBefore || now.Add(cfg.ClockSkew).Unix() < c.IssuedAt {
+		return ErrNotValidYet
+	}
+	return nil
+}
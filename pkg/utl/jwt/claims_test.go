@@ -0,0 +1,221 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/jwt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseToken(t *testing.T) {
+	secret := "g0r$kt3$t1ng"
+	now := time.Now()
+	valid := func() jwtgo.MapClaims {
+		return jwtgo.MapClaims{
+			"sub": "1",
+			"u":   "johndoe",
+			"e":   "johndoe@mail.com",
+			"r":   100,
+			"c":   2,
+			"l":   3,
+			"sid": 4,
+			"jti": "jti",
+			"iss": "gorsk",
+			"aud": "gorsk-api",
+			"iat": now.Unix(),
+			"nbf": now.Unix(),
+			"exp": now.Add(time.Minute).Unix(),
+		}
+	}
+	with := func(key string, value interface{}) jwtgo.MapClaims {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	cases := map[string]struct {
+		claims   jwtgo.MapClaims
+		header   string
+		wantErr  bool
+		wantData gorsk.AuthUser
+	}{
+		"missing bearer": {
+			header:  "token",
+			wantErr: true,
+		},
+		"not a jwt": {
+			header:  "Bearer token",
+			wantErr: true,
+		},
+		"subject of wrong type": {
+			claims:  with("sub", 1),
+			wantErr: true,
+		},
+		"subject is not a user ID": {
+			claims:  with("sub", "johndoe"),
+			wantErr: true,
+		},
+		"role of wrong type": {
+			claims:  with("r", "admin"),
+			wantErr: true,
+		},
+		"missing token ID": {
+			claims:  with("jti", nil),
+			wantErr: true,
+		},
+		"missing expiry": {
+			claims:  with("exp", nil),
+			wantErr: true,
+		},
+		"wrong issuer": {
+			claims:  with("iss", "other"),
+			wantErr: true,
+		},
+		"wrong audience": {
+			claims:  with("aud", "other"),
+			wantErr: true,
+		},
+		"expired beyond clock skew": {
+			claims:  with("exp", now.Add(-time.Minute).Unix()),
+			wantErr: true,
+		},
+		"not valid yet beyond clock skew": {
+			claims:  with("nbf", now.Add(time.Minute).Unix()),
+			wantErr: true,
+		},
+		"issued in the future beyond clock skew": {
+			claims:  with("iat", now.Add(time.Minute).Unix()),
+			wantErr: true,
+		},
+		"actor is not a user ID": {
+			claims:  with("act", map[string]string{"sub": "admin"}),
+			wantErr: true,
+		},
+		"typed token": {
+			claims:  with("typ", "mfa"),
+			wantErr: true,
+		},
+		"expired within clock skew": {
+			claims: with("exp", now.Add(-10*time.Second).Unix()),
+			wantData: gorsk.AuthUser{
+				ID:         1,
+				CompanyID:  2,
+				LocationID: 3,
+				Username:   "johndoe",
+				Email:      "johndoe@mail.com",
+				Role:       gorsk.SuperAdminRole,
+				SessionID:  4,
+				TokenID:    "jti",
+
+				TokenExpiresAt: time.Unix(now.Add(-10*time.Second).Unix(), 0),
+			},
+		},
+		"success": {
+			claims: valid(),
+			wantData: gorsk.AuthUser{
+				ID:         1,
+				CompanyID:  2,
+				LocationID: 3,
+				Username:   "johndoe",
+				Email:      "johndoe@mail.com",
+				Role:       gorsk.SuperAdminRole,
+				SessionID:  4,
+				TokenID:    "jti",
+
+				TokenExpiresAt: time.Unix(now.Add(time.Minute).Unix(), 0),
+			},
+		},
+	}
+
+	jwtSvc, err := jwt.New("HS256", secret, 60, 1, jwt.Config{Issuer: "gorsk", Audience: "gorsk-api", ClockSkew: 30 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			header := tt.header
+			if tt.claims != nil {
+				token, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, tt.claims).SignedString([]byte(secret))
+				if err != nil {
+					t.Fatal(err)
+				}
+				header = "Bearer " + token
+			}
+			au, err := jwtSvc.ParseToken(header)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantData, au)
+		})
+	}
+}
+
+func TestRegisteredClaims(t *testing.T) {
+	cfg := jwt.Config{Issuer: "gorsk", Audience: "gorsk-api"}
+	issuer, err := jwt.New("HS256", "g0r$kt3$t1ng", 60, 1, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.GenerateToken(gorsk.User{Base: gorsk.Base{ID: 7}, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}, EmailVerifiedAt: time.Now()}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := new(jwt.Claims)
+	if _, _, err := new(jwtgo.Parser).ParseUnverified(token, claims); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "7", claims.Subject)
+	assert.Equal(t, "gorsk", claims.Issuer)
+	assert.Equal(t, "gorsk-api", claims.Audience)
+	assert.NotEmpty(t, claims.Id)
+	assert.NotZero(t, claims.IssuedAt)
+	assert.Equal(t, claims.IssuedAt, claims.NotBefore)
+	assert.Equal(t, claims.IssuedAt+3600, claims.ExpiresAt)
+	assert.True(t, claims.EmailVerified)
+
+	other, err := jwt.New("HS256", "g0r$kt3$t1ng", 60, 1, jwt.Config{Issuer: "gorsk", Audience: "other-api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = other.ParseToken("Bearer " + token)
+	assert.Equal(t, jwt.ErrInvalidAudience, err)
+}
+
+func TestImpersonationToken(t *testing.T) {
+	jwtSvc, err := jwt.New("HS256", "g0r$kt3$t1ng", 60, 1, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwtSvc.GenerateImpersonationToken(gorsk.User{
+		Base:     gorsk.Base{ID: 7},
+		Username: "johndoe",
+		Role:     &gorsk.Role{AccessLevel: gorsk.UserRole},
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	au, err := jwtSvc.ParseToken("Bearer " + token)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, au.ID)
+	assert.Equal(t, 1, au.ActorID)
+	assert.Equal(t, "johndoe", au.Username)
+	assert.Equal(t, gorsk.UserRole, au.Role)
+	assert.Equal(t, 0, au.SessionID)
+	assert.NotEmpty(t, au.TokenID)
+
+	claims := new(jwt.Claims)
+	if _, _, err := new(jwtgo.Parser).ParseUnverified(token, claims); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, claims.IssuedAt+15*60, claims.ExpiresAt)
+}
@@ -2,18 +2,35 @@ package jwt
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/secure"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-var minSecretLen = 128
+// defaultMinSecretLen is the minimum secret length used when none is configured
+const defaultMinSecretLen = 128
 
-// New generates new JWT service necessary for auth middleware
-func New(algo, secret string, ttlMinutes, minSecretLength int) (Service, error) {
+// challengeTTL is the duration for which the two-factor authentication challenge token is valid
+const challengeTTL = 5 * time.Minute
+
+// impersonationTTL is the duration for which the impersonation token is valid
+const impersonationTTL = 15 * time.Minute
+
+// challengeType is the token type claim of two-factor authentication challenge tokens
+const challengeType = "mfa"
+
+// passwordChallengeType is the token type claim of password change challenge tokens
+const passwordChallengeType = "pwd"
+
+// New generates new JWT service necessary for auth middleware, signing tokens with HMAC secret
+func New(algo, secret string, ttlMinutes, minSecretLength int, cfg Config) (Service, error) {
+	minSecretLen := defaultMinSecretLen
 	if minSecretLength > 0 {
 		minSecretLen = minSecretLength
 	}
@@ -24,52 +41,216 @@ func New(algo, secret string, ttlMinutes, minSecretLength int) (Service, error)
 	if signingMethod == nil {
 		return Service{}, fmt.Errorf("invalid jwt signing method: %s", algo)
 	}
+	if _, ok := signingMethod.(*jwt.SigningMethodHMAC); !ok {
+		return Service{}, fmt.Errorf("jwt signing method %s requires keys instead of secret", algo)
+	}
 
+	key := Key{method: signingMethod, private: []byte(secret), public: []byte(secret)}
 	return Service{
-		key:  []byte(secret),
-		algo: signingMethod,
-		ttl:  time.Duration(ttlMinutes) * time.Minute,
+		signer: key,
+		keys:   map[string]Key{"": key},
+		ttl:    time.Duration(ttlMinutes) * time.Minute,
+		cfg:    cfg,
 	}, nil
 }
 
+// NewWithKeys generates new JWT service signing tokens with the asymmetric key identified by signingKeyID.
+// All the keys are used to verify tokens by their kid header, so keys can be rotated without invalidating live tokens.
+func NewWithKeys(signingKeyID string, keys []Key, ttlMinutes int, cfg Config) (Service, error) {
+	s := Service{
+		keys: make(map[string]Key, len(keys)),
+		ttl:  time.Duration(ttlMinutes) * time.Minute,
+		cfg:  cfg,
+	}
+	for _, k := range keys {
+		if k.ID == "" {
+			return Service{}, fmt.Errorf("jwt key id is required")
+		}
+		if _, ok := s.keys[k.ID]; ok {
+			return Service{}, fmt.Errorf("duplicate jwt key id: %s", k.ID)
+		}
+		s.keys[k.ID] = k
+	}
+
+	signer, ok := s.keys[signingKeyID]
+	if !ok {
+		return Service{}, fmt.Errorf("jwt signing key %s not found", signingKeyID)
+	}
+	if signer.private == nil {
+		return Service{}, fmt.Errorf("jwt signing key %s has no private key", signingKeyID)
+	}
+	s.signer = signer
+
+	return s, nil
+}
+
 // Service provides a Json-Web-Token authentication implementation
 type Service struct {
-	// Secret key used for signing.
-	key []byte
+	// Key used for signing.
+	signer Key
+
+	// Keys used for verifying, by their ID.
+	keys map[string]Key
 
 	// Duration for which the jwt token is valid.
 	ttl time.Duration
 
-	// JWT signing algorithm
-	algo jwt.SigningMethod
+	// Registered claims settings.
+	cfg Config
 }
 
-// ParseToken parses token from Authorization header
-func (s Service) ParseToken(authHeader string) (*jwt.Token, error) {
+// ParseToken parses access token from Authorization header, returning the authenticated user
+func (s Service) ParseToken(authHeader string) (gorsk.AuthUser, error) {
 	parts := strings.SplitN(authHeader, " ", 2)
 	if !(len(parts) == 2 && parts[0] == "Bearer") {
-		return nil, gorsk.ErrGeneric
+		return gorsk.AuthUser{}, gorsk.ErrGeneric
+	}
+
+	claims, err := s.parse(parts[1])
+	if err != nil {
+		return gorsk.AuthUser{}, err
+	}
+
+	// Typed tokens, like two-factor authentication challenges, are not access tokens
+	if claims.Type != "" {
+		return gorsk.AuthUser{}, ErrInvalidType
 	}
 
-	return jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-		if s.algo != token.Method {
-			return nil, gorsk.ErrGeneric
+	return claims.User()
+}
+
+// JWKS returns public keys used to verify tokens. Tokens signed with HMAC secret cannot be verified publicly.
+func (s Service) JWKS() gorsk.JWKS {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		if id != "" {
+			ids = append(ids, id)
 		}
-		return s.key, nil
-	})
+	}
+	sort.Strings(ids)
 
+	jwks := gorsk.JWKS{Keys: make([]gorsk.JWK, len(ids))}
+	for i, id := range ids {
+		jwks.Keys[i] = s.keys[id].jwk()
+	}
+	return jwks
 }
 
-// GenerateToken generates new JWT token and populates it with user data
-func (s Service) GenerateToken(u gorsk.User) (string, error) {
-	return jwt.NewWithClaims(s.algo, jwt.MapClaims{
-		"id":  u.Base.ID,
-		"u":   u.Username,
-		"e":   u.Email,
-		"r":   u.Role.AccessLevel,
-		"c":   u.CompanyID,
-		"l":   u.LocationID,
-		"exp": time.Now().Add(s.ttl).Unix(),
-	}).SignedString(s.key)
+// key looks up the key for verifying token by its kid header
+func (s Service) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := s.keys[kid]
+	if !ok || k.method != token.Method {
+		return nil, gorsk.ErrGeneric
+	}
+	return k.public, nil
+}
+
+// parse verifies token's signature and validates its claims
+func (s Service) parse(token string) (*Claims, error) {
+	claims := new(Claims)
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	t, err := parser.ParseWithClaims(token, claims, s.key)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Valid {
+		return nil, gorsk.ErrGeneric
+	}
+	if err := claims.validate(s.cfg, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// sign signs claims with signing key, setting its ID as kid header.
+// Registered claims are populated with the configured issuer and audience, and a new token ID.
+func (s Service) sign(claims Claims, ttl time.Duration) (string, error) {
+	jti, err := secure.NewToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims.Id = jti
+	claims.Issuer = s.cfg.Issuer
+	claims.Audience = s.cfg.Audience
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	token := jwt.NewWithClaims(s.signer.method, claims)
+	if s.signer.ID != "" {
+		token.Header["kid"] = s.signer.ID
+	}
+	return token.SignedString(s.signer.private)
+}
+
+// GenerateToken generates new JWT token and populates it with user and session data
+func (s Service) GenerateToken(u gorsk.User, sessionID int) (string, error) {
+	return s.sign(Claims{
+		StandardClaims: jwt.StandardClaims{Subject: strconv.Itoa(u.ID)},
+		Username:       u.Username,
+		Email:          u.Email,
+		Role:           u.Role.AccessLevel,
+		CompanyID:      u.CompanyID,
+		LocationID:     u.LocationID,
+		SessionID:      sessionID,
+		EmailVerified:  u.EmailVerified(),
+	}, s.ttl)
+}
 
+// GenerateImpersonationToken generates short-lived JWT token letting actor act as the user.
+// The token is not tied to a session, so it cannot be refreshed.
+func (s Service) GenerateImpersonationToken(u gorsk.User, actorID int) (string, error) {
+	return s.sign(Claims{
+		StandardClaims: jwt.StandardClaims{Subject: strconv.Itoa(u.ID)},
+		Username:       u.Username,
+		Email:          u.Email,
+		Role:           u.Role.AccessLevel,
+		CompanyID:      u.CompanyID,
+		LocationID:     u.LocationID,
+		Actor:          &Actor{Subject: strconv.Itoa(actorID)},
+		EmailVerified:  u.EmailVerified(),
+	}, impersonationTTL)
+}
+
+// GenerateChallenge generates short-lived JWT token proving the user passed the first authentication factor
+func (s Service) GenerateChallenge(u gorsk.User) (string, error) {
+	return s.challenge(u, challengeType)
+}
+
+// ParseChallenge parses two-factor authentication challenge token, returning user's ID
+func (s Service) ParseChallenge(token string) (int, error) {
+	return s.parseChallenge(token, challengeType)
+}
+
+// GeneratePasswordChallenge generates short-lived JWT token proving the user with expired password
+// passed password authentication, letting the user change the password
+func (s Service) GeneratePasswordChallenge(u gorsk.User) (string, error) {
+	return s.challenge(u, passwordChallengeType)
+}
+
+// ParsePasswordChallenge parses password change challenge token, returning user's ID
+func (s Service) ParsePasswordChallenge(token string) (int, error) {
+	return s.parseChallenge(token, passwordChallengeType)
+}
+
+// challenge generates short-lived JWT token of given type, holding only user's ID
+func (s Service) challenge(u gorsk.User, typ string) (string, error) {
+	return s.sign(Claims{
+		StandardClaims: jwt.StandardClaims{Subject: strconv.Itoa(u.ID)},
+		Type:           typ,
+	}, challengeTTL)
+}
+
+// parseChallenge parses challenge token of given type, returning user's ID
+func (s Service) parseChallenge(token, typ string) (int, error) {
+	claims, err := s.parse(token)
+	if err != nil {
+		return 0, err
+	}
+	if claims.Type != typ {
+		return 0, ErrInvalidType
+	}
+	return claims.UserID()
 }
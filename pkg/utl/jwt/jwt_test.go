@@ -23,6 +23,12 @@ func TestGenerateToken(t *testing.T) {
 			algo:    "invalid",
 			wantErr: true,
 		},
+		"asymmetric algo": {
+			algo:         "RS256",
+			secret:       "g0r$kt3$t1ng",
+			minSecretLen: 1,
+			wantErr:      true,
+		},
 		"secret not set": {
 			algo:    "HS256",
 			wantErr: true,
@@ -60,12 +66,60 @@ func TestGenerateToken(t *testing.T) {
 
 	for name, tt := range cases {
 		t.Run(name, func(t *testing.T) {
-			jwtSvc, err := jwt.New(tt.algo, tt.secret, 60, tt.minSecretLen)
+			jwtSvc, err := jwt.New(tt.algo, tt.secret, 60, tt.minSecretLen, jwt.Config{})
 			assert.Equal(t, tt.wantErr, err != nil)
 			if err == nil && !tt.wantErr {
-				token, _ := jwtSvc.GenerateToken(tt.req)
+				token, _ := jwtSvc.GenerateToken(tt.req, 1)
 				assert.Equal(t, tt.want, strings.Split(token, ".")[0])
 			}
 		})
 	}
 }
+
+func TestChallenge(t *testing.T) {
+	jwtSvc, err := jwt.New("HS256", "g0r$kt3$t1ng", 60, 1, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := jwtSvc.GenerateChallenge(gorsk.User{Base: gorsk.Base{ID: 7}})
+	assert.Nil(t, err)
+	id, err := jwtSvc.ParseChallenge(challenge)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, id)
+
+	token, err := jwtSvc.GenerateToken(gorsk.User{Base: gorsk.Base{ID: 7}, Role: &gorsk.Role{}}, 1)
+	assert.Nil(t, err)
+	_, err = jwtSvc.ParseChallenge(token)
+	assert.NotNil(t, err, "access token must not be accepted as challenge")
+
+	_, err = jwtSvc.ParseChallenge("invalid")
+	assert.NotNil(t, err)
+}
+
+func TestPasswordChallenge(t *testing.T) {
+	jwtSvc, err := jwt.New("HS256", "g0r$kt3$t1ng", 60, 1, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := jwtSvc.GeneratePasswordChallenge(gorsk.User{Base: gorsk.Base{ID: 7}})
+	assert.Nil(t, err)
+	id, err := jwtSvc.ParsePasswordChallenge(challenge)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, id)
+
+	_, err = jwtSvc.ParseChallenge(challenge)
+	assert.Equal(t, jwt.ErrInvalidType, err, "password challenge must not be accepted as MFA challenge")
+
+	mfa, err := jwtSvc.GenerateChallenge(gorsk.User{Base: gorsk.Base{ID: 7}})
+	assert.Nil(t, err)
+	_, err = jwtSvc.ParsePasswordChallenge(mfa)
+	assert.Equal(t, jwt.ErrInvalidType, err, "MFA challenge must not be accepted as password challenge")
+}
+
+func TestJWKS(t *testing.T) {
+	jwtSvc, err := jwt.New("HS256", "g0r$kt3$t1ng", 60, 1, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, jwtSvc.JWKS().Keys, "HMAC secret must not be published")
+}
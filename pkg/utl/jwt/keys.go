@@ -0,0 +1,163 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/ribice/gorsk"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA signs tokens with Ed25519 keys, which jwt-go does not support on its own
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+type signingMethodEdDSA struct{}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(priv, []byte(signingString))), nil
+}
+
+// Key represents an asymmetric key used for signing and verifying tokens, identified by its ID (kid).
+// Keys holding only the public part can verify tokens, but cannot sign them.
+type Key struct {
+	ID      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// ParseKey parses PEM encoded private or public key used with RS*, PS*, ES* or EdDSA algorithm
+func ParseKey(id, algo string, data []byte) (Key, error) {
+	method := jwt.GetSigningMethod(algo)
+	if method == nil {
+		return Key{}, fmt.Errorf("invalid jwt signing method: %s", algo)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("jwt key %s is not PEM encoded", id)
+	}
+
+	var (
+		private, public interface{}
+		err             error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("jwt key %s has unsupported PEM type: %s", id, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("jwt key %s: %v", id, err)
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		public = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		public = &k.PublicKey
+	case ed25519.PrivateKey:
+		public = k.Public()
+	}
+
+	if !keyMatches(method, public) {
+		return Key{}, fmt.Errorf("jwt key %s cannot be used with %s signing method", id, algo)
+	}
+
+	return Key{ID: id, method: method, private: private, public: public}, nil
+}
+
+// keyMatches checks whether public key is of the type signing method expects
+func keyMatches(method jwt.SigningMethod, public interface{}) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := public.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		k, ok := public.(*ecdsa.PublicKey)
+		return ok && k.Curve.Params().BitSize == m.CurveBits
+	case *signingMethodEdDSA:
+		_, ok := public.(ed25519.PublicKey)
+		return ok
+	}
+	return false
+}
+
+// jwk converts key's public part to JSON Web Key
+func (k Key) jwk() gorsk.JWK {
+	jwk := gorsk.JWK{Kid: k.ID, Alg: k.method.Alg(), Use: "sig"}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(pub.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encode(pad(pub.X.Bytes(), size))
+		jwk.Y = encode(pad(pub.Y.Bytes(), size))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(pub)
+	}
+	return jwk
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// pad left-pads elliptic curve coordinate to curve size, as required by JWK
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
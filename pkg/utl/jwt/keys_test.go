@@ -0,0 +1,249 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/jwt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func privatePEM(t *testing.T, key interface{}) []byte {
+	b, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b})
+}
+
+func publicPEM(t *testing.T, key interface{}) []byte {
+	b, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b})
+}
+
+func header(t *testing.T, token string) map[string]interface{} {
+	b, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := make(map[string]interface{})
+	if err := json.Unmarshal(b, &h); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestParseKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		algo    string
+		pem     []byte
+		wantErr bool
+	}{
+		"invalid algo": {
+			algo:    "invalid",
+			pem:     privatePEM(t, rsaKey),
+			wantErr: true,
+		},
+		"not PEM encoded": {
+			algo:    "RS256",
+			pem:     []byte("key"),
+			wantErr: true,
+		},
+		"unsupported PEM type": {
+			algo:    "RS256",
+			pem:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")}),
+			wantErr: true,
+		},
+		"key does not match algo": {
+			algo:    "ES256",
+			pem:     privatePEM(t, rsaKey),
+			wantErr: true,
+		},
+		"curve does not match algo": {
+			algo:    "ES384",
+			pem:     privatePEM(t, ecKey),
+			wantErr: true,
+		},
+		"HMAC algo": {
+			algo:    "HS256",
+			pem:     privatePEM(t, rsaKey),
+			wantErr: true,
+		},
+		"RSA PKCS1 private key": {
+			algo: "RS256",
+			pem:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+		},
+		"RSA public key": {
+			algo: "PS256",
+			pem:  publicPEM(t, &rsaKey.PublicKey),
+		},
+		"EC private key": {
+			algo: "ES256",
+			pem:  privatePEM(t, ecKey),
+		},
+		"Ed25519 public key": {
+			algo: "EdDSA",
+			pem:  publicPEM(t, edKey.Public()),
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			key, err := jwt.ParseKey("kid", tt.algo, tt.pem)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, "kid", key.ID)
+			}
+		})
+	}
+}
+
+func TestNewWithKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing, err := jwt.ParseKey("new", "ES256", privatePEM(t, ecKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifying, err := jwt.ParseKey("old", "ES256", publicPEM(t, &ecKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		kid     string
+		keys    []jwt.Key
+		wantErr bool
+	}{
+		"missing key id": {
+			kid:     "new",
+			keys:    []jwt.Key{signing, {}},
+			wantErr: true,
+		},
+		"duplicate key id": {
+			kid:     "new",
+			keys:    []jwt.Key{signing, signing},
+			wantErr: true,
+		},
+		"signing key not found": {
+			kid:     "unknown",
+			keys:    []jwt.Key{signing},
+			wantErr: true,
+		},
+		"signing key without private key": {
+			kid:     "old",
+			keys:    []jwt.Key{signing, verifying},
+			wantErr: true,
+		},
+		"success": {
+			kid:  "new",
+			keys: []jwt.Key{signing, verifying},
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jwt.NewWithKeys(tt.kid, tt.keys, 60, jwt.Config{})
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey, err := jwt.ParseKey("2019", "RS256", privatePEM(t, rsaKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPublic, err := jwt.ParseKey("2019", "RS256", publicPEM(t, &rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := jwt.ParseKey("2020", "EdDSA", privatePEM(t, edKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := gorsk.User{Base: gorsk.Base{ID: 1}, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}}
+
+	before, err := jwt.NewWithKeys("2019", []jwt.Key{oldKey}, 60, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := before.GenerateToken(user, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := jwt.NewWithKeys("2020", []jwt.Key{newKey, oldPublic}, 60, jwt.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := after.GenerateToken(user, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = after.ParseToken("Bearer " + oldToken)
+	assert.Nil(t, err, "token signed with rotated key must remain valid")
+	assert.Equal(t, "2019", header(t, oldToken)["kid"])
+
+	_, err = after.ParseToken("Bearer " + newToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "2020", header(t, newToken)["kid"])
+	assert.Equal(t, "EdDSA", header(t, newToken)["alg"])
+
+	_, err = before.ParseToken("Bearer " + newToken)
+	assert.NotNil(t, err, "token signed with unknown key must be rejected")
+
+	challenge, err := after.GenerateChallenge(user)
+	assert.Nil(t, err)
+	id, err := after.ParseChallenge(challenge)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, id)
+
+	jwks := after.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, gorsk.JWK{Kty: "RSA", Kid: "2019", Alg: "RS256", Use: "sig", N: jwks.Keys[0].N, E: "AQAB"}, jwks.Keys[0])
+		assert.NotEmpty(t, jwks.Keys[0].N)
+		assert.Equal(t, gorsk.JWK{Kty: "OKP", Kid: "2020", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: jwks.Keys[1].X}, jwks.Keys[1])
+		assert.Len(t, jwks.Keys[1].X, 43)
+	}
+}
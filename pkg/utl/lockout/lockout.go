@@ -0,0 +1,179 @@
+// Package lockout provides brute-force protection by delaying and locking out repeated failed attempts
+package lockout
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// maxShift caps exponential delay growth to avoid overflowing time.Duration
+const maxShift = 30
+
+// Policy represents brute-force protection policy. After each consecutive failure the next
+// attempt is delayed, starting from Delay and doubling on each failure. After MaxAttempts
+// consecutive failures, attempts are locked out for Lockout duration.
+type Policy struct {
+	MaxAttempts int
+	Delay       time.Duration
+	Lockout     time.Duration
+}
+
+// Wait returns how long the next attempt has to wait, given the number of
+// consecutive failures and time of the last one
+func (p Policy) Wait(failures int, last, now time.Time) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	var d time.Duration
+	switch {
+	case p.Locked(failures):
+		d = p.Lockout
+	case p.Delay > 0:
+		shift := failures - 1
+		if shift > maxShift {
+			shift = maxShift
+		}
+		d = p.Delay << uint(shift)
+		if p.Lockout > 0 && d > p.Lockout {
+			d = p.Lockout
+		}
+	}
+	if w := last.Add(d).Sub(now); w > 0 {
+		return w
+	}
+	return 0
+}
+
+// Locked checks whether the number of consecutive failures reached lockout threshold
+func (p Policy) Locked(failures int) bool {
+	return p.MaxAttempts > 0 && failures >= p.MaxAttempts
+}
+
+// Fail returns the number of consecutive failures after a new one. Failures older
+// than lockout duration are forgotten, so the count starts over.
+func (p Policy) Fail(failures int, last, now time.Time) int {
+	if p.Lockout > 0 && now.Sub(last) > p.Lockout {
+		return 1
+	}
+	return failures + 1
+}
+
+// Since returns the time consecutive failures are counted from, as failures older than
+// lockout duration are forgotten. Zero time means failures are never forgotten.
+func (p Policy) Since(now time.Time) time.Time {
+	if p.Lockout <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.Lockout)
+}
+
+// DefaultCapacity is the number of keys Memory tracks by default
+const DefaultCapacity = 100000
+
+// NewMemory creates new in-memory failed attempts tracker, tracking up to DefaultCapacity keys
+func NewMemory(p Policy) *Memory {
+	return NewMemoryCapacity(p, DefaultCapacity)
+}
+
+// NewMemoryCapacity creates new in-memory failed attempts tracker, tracking up to capacity keys.
+// Once full, keys whose last failure is the oldest are forgotten first.
+func NewMemoryCapacity(p Policy, capacity int) *Memory {
+	return &Memory{policy: p, capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Memory tracks consecutive failed attempts per key (e.g. IP address) in memory
+type Memory struct {
+	mu       sync.Mutex
+	policy   Policy
+	capacity int
+	// order holds attempts by time of the last failure, oldest first
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type attempts struct {
+	key      string
+	failures int
+	last     time.Time
+}
+
+// Wait returns how long the next attempt for key has to wait
+func (m *Memory) Wait(key string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	a := m.get(key, now)
+	return m.policy.Wait(a.failures, a.last, now)
+}
+
+// Fail records a failed attempt for key, returning the number of consecutive failures
+func (m *Memory) Fail(key string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.expire(now)
+	a := m.get(key, now)
+	a.key = key
+	a.failures = m.policy.Fail(a.failures, a.last, now)
+	a.last = now
+	if e, ok := m.entries[key]; ok {
+		e.Value = a
+		m.order.MoveToBack(e)
+		return a.failures
+	}
+	if m.capacity <= 0 {
+		return a.failures
+	}
+	if m.order.Len() >= m.capacity {
+		m.remove(m.order.Front())
+	}
+	m.entries[key] = m.order.PushBack(a)
+	return a.failures
+}
+
+// Locked checks whether key is locked out
+func (m *Memory) Locked(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.policy.Locked(m.get(key, time.Now()).failures)
+}
+
+// Reset forgets failed attempts for key
+func (m *Memory) Reset(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		m.remove(e)
+	}
+}
+
+// get returns attempts for key, forgetting them if they are older than lockout duration
+func (m *Memory) get(key string, now time.Time) attempts {
+	e, ok := m.entries[key]
+	if !ok {
+		return attempts{}
+	}
+	a := e.Value.(attempts)
+	if m.stale(a, now) {
+		m.remove(e)
+		return attempts{}
+	}
+	return a
+}
+
+// expire forgets the oldest attempts as long as they are older than lockout duration
+func (m *Memory) expire(now time.Time) {
+	for e := m.order.Front(); e != nil && m.stale(e.Value.(attempts), now); e = m.order.Front() {
+		m.remove(e)
+	}
+}
+
+func (m *Memory) stale(a attempts, now time.Time) bool {
+	return m.policy.Lockout > 0 && now.Sub(a.last) > m.policy.Lockout
+}
+
+func (m *Memory) remove(e *list.Element) {
+	m.order.Remove(e)
+	delete(m.entries, e.Value.(attempts).key)
+}
@@ -0,0 +1,120 @@
+package lockout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk/pkg/utl/lockout"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWait(t *testing.T) {
+	now := time.Now()
+	p := lockout.Policy{MaxAttempts: 5, Delay: time.Second, Lockout: time.Minute}
+	cases := []struct {
+		name     string
+		policy   lockout.Policy
+		failures int
+		last     time.Time
+		want     time.Duration
+	}{
+		{
+			name:   "No failures",
+			policy: p,
+			want:   0,
+		},
+		{
+			name:     "First failure",
+			policy:   p,
+			failures: 1,
+			last:     now,
+			want:     time.Second,
+		},
+		{
+			name:     "Progressive delay",
+			policy:   p,
+			failures: 3,
+			last:     now,
+			want:     4 * time.Second,
+		},
+		{
+			name:     "Delay passed",
+			policy:   p,
+			failures: 3,
+			last:     now.Add(-5 * time.Second),
+			want:     0,
+		},
+		{
+			name:     "Locked out",
+			policy:   p,
+			failures: 5,
+			last:     now.Add(-time.Second),
+			want:     59 * time.Second,
+		},
+		{
+			name:     "Delay capped at lockout",
+			policy:   lockout.Policy{Delay: time.Second, Lockout: time.Minute},
+			failures: 100,
+			last:     now,
+			want:     time.Minute,
+		},
+		{
+			name:     "Disabled",
+			failures: 100,
+			last:     now,
+			want:     0,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.Wait(tt.failures, tt.last, now))
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	now := time.Now()
+	p := lockout.Policy{MaxAttempts: 5, Delay: time.Second, Lockout: time.Minute}
+	assert.Equal(t, 3, p.Fail(2, now.Add(-time.Second), now))
+	assert.Equal(t, 1, p.Fail(5, now.Add(-2*time.Minute), now))
+}
+
+func TestSince(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, now.Add(-time.Minute), lockout.Policy{Lockout: time.Minute}.Since(now))
+	assert.True(t, lockout.Policy{}.Since(now).IsZero())
+}
+
+func TestMemory(t *testing.T) {
+	m := lockout.NewMemory(lockout.Policy{MaxAttempts: 2, Lockout: time.Minute})
+	assert.Equal(t, time.Duration(0), m.Wait("127.0.0.1"))
+	assert.Equal(t, 1, m.Fail("127.0.0.1"))
+	assert.False(t, m.Locked("127.0.0.1"))
+	assert.Equal(t, 2, m.Fail("127.0.0.1"))
+	assert.True(t, m.Locked("127.0.0.1"))
+	assert.True(t, m.Wait("127.0.0.1") > 0)
+	assert.Equal(t, time.Duration(0), m.Wait("10.0.0.1"))
+	m.Reset("127.0.0.1")
+	assert.Equal(t, time.Duration(0), m.Wait("127.0.0.1"))
+}
+
+func TestMemoryCapacity(t *testing.T) {
+	m := lockout.NewMemoryCapacity(lockout.Policy{MaxAttempts: 2}, 2)
+	m.Fail("10.0.0.1")
+	m.Fail("10.0.0.1")
+	m.Fail("10.0.0.2")
+	m.Fail("10.0.0.3")
+	assert.False(t, m.Locked("10.0.0.1"), "least recently failed key should be forgotten")
+	assert.Equal(t, 2, m.Fail("10.0.0.2"))
+	assert.Equal(t, 2, m.Fail("10.0.0.3"))
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	m := lockout.NewMemory(lockout.Policy{MaxAttempts: 2, Lockout: time.Millisecond})
+	m.Fail("10.0.0.1")
+	m.Fail("10.0.0.1")
+	time.Sleep(2 * time.Millisecond)
+	assert.False(t, m.Locked("10.0.0.1"))
+	assert.Equal(t, 1, m.Fail("10.0.0.1"))
+}
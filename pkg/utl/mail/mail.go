@@ -0,0 +1,93 @@
+// Package mail delivers plain-text emails through SMTP, or writes them to a log for development and tests
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidHeader is returned when message headers contain line breaks
+var ErrInvalidHeader = errors.New("mail: header contains line break")
+
+// Message represents a plain-text email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// encode formats the message as RFC 5322 email sent by from address
+func (m Message) encode(from string, date time.Time) ([]byte, error) {
+	for _, h := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(h, "\r\n") {
+			return nil, ErrInvalidHeader
+		}
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.Replace(m.Body, "\n", "\r\n", -1))
+	b.WriteString("\r\n")
+	return b.Bytes(), nil
+}
+
+// NewSMTP creates new mailer sending messages through SMTP server at addr (host:port).
+// PLAIN authentication is used when username is not empty.
+func NewSMTP(addr, username, password, from string) *SMTP {
+	s := &SMTP{addr: addr, from: from, send: smtp.SendMail}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, strings.Split(addr, ":")[0])
+	}
+	return s
+}
+
+// SMTP represents mailer sending messages through SMTP server
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send func(string, smtp.Auth, string, []string, []byte) error
+}
+
+// Send sends the message
+func (s *SMTP) Send(m Message) error {
+	msg, err := m.encode(s.from, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.send(s.addr, s.auth, s.from, []string{m.To}, msg)
+}
+
+// NewLog creates new mailer writing messages to w instead of sending them
+func NewLog(w io.Writer, from string) *Log {
+	return &Log{w: w, from: from}
+}
+
+// Log represents mailer writing messages to a file or log, used for development and testing
+type Log struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// Send writes the message, separated from previous ones with a blank line
+func (l *Log) Send(m Message) error {
+	msg, err := m.encode(l.from, time.Now())
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(msg, '\r', '\n'))
+	return err
+}
@@ -0,0 +1,113 @@
+package mail_test
+
+import (
+	"bytes"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/ribice/gorsk/pkg/utl/mail"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	cases := []struct {
+		name     string
+		msg      mail.Message
+		wantErr  error
+		wantData []string
+	}{
+		{
+			name:    "Fail on header injection",
+			msg:     mail.Message{To: "johndoe@mail.com", Subject: "Hello\r\nBcc: janedoe@mail.com"},
+			wantErr: mail.ErrInvalidHeader,
+		},
+		{
+			name: "Success",
+			msg:  mail.Message{To: "johndoe@mail.com", Subject: "Hello", Body: "first\nsecond"},
+			wantData: []string{
+				"From: gorsk@mail.com\r\n",
+				"To: johndoe@mail.com\r\n",
+				"Subject: Hello\r\n",
+				"\r\n\r\nfirst\r\nsecond\r\n",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := mail.NewLog(&buf, "gorsk@mail.com").Send(tt.msg)
+			assert.Equal(t, tt.wantErr, err)
+			for _, s := range tt.wantData {
+				assert.Contains(t, buf.String(), s)
+			}
+		})
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(t, ln, received)
+
+	err = mail.NewSMTP(ln.Addr().String(), "", "", "gorsk@mail.com").Send(mail.Message{
+		To:      "johndoe@mail.com",
+		Subject: "Hello",
+		Body:    "Hello John",
+	})
+	assert.Nil(t, err)
+
+	cmds := <-received
+	assert.Contains(t, cmds, "MAIL FROM:<gorsk@mail.com>")
+	assert.Contains(t, cmds, "RCPT TO:<johndoe@mail.com>")
+	assert.Contains(t, cmds, "Subject: Hello")
+	assert.Contains(t, cmds, "Hello John")
+}
+
+// serveSMTP accepts a single SMTP session, sending received lines once the client quits
+func serveSMTP(t *testing.T, ln net.Listener, received chan<- []string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	var lines []string
+	data := false
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		lines = append(lines, line)
+		switch {
+		case data:
+			if line == "." {
+				data = false
+				tp.PrintfLine("250 OK")
+			}
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			tp.PrintfLine("250 localhost")
+		case line == "DATA":
+			data = true
+			tp.PrintfLine("354 Go ahead")
+		case line == "QUIT":
+			tp.PrintfLine("221 Bye")
+			received <- lines
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
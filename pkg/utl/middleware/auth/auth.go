@@ -2,43 +2,98 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
 )
 
-// TokenParser represents JWT token parser
+// TokenParser represents JWT token parser, returning the user authenticated by the token
 type TokenParser interface {
-	ParseToken(string) (*jwt.Token, error)
+	ParseToken(string) (gorsk.AuthUser, error)
+}
+
+// Denylist represents storage of revoked token IDs
+type Denylist interface {
+	Contains(string) (bool, error)
+}
+
+// KeyAuthenticator represents API key authenticator
+type KeyAuthenticator interface {
+	Authenticate(string) (gorsk.AuthUser, error)
 }
 
 // Middleware makes JWT implement the Middleware interface.
-func Middleware(tokenParser TokenParser) echo.MiddlewareFunc {
+// Tokens whose ID is found in denylist are rejected.
+// Bearer API keys are accepted as well, given a key authenticator.
+// Requests without Authorization header are authenticated by access token cookie,
+// requiring CSRF token for unsafe methods.
+func Middleware(tokenParser TokenParser, denylist Denylist, keys KeyAuthenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			token, err := tokenParser.ParseToken(c.Request().Header.Get("Authorization"))
-			if err != nil || !token.Valid {
+			header := c.Request().Header.Get("Authorization")
+			if token := cookie.AccessToken(c); header == "" && token != "" {
+				if err := cookie.CheckCSRF(c); err != nil {
+					return err
+				}
+				header = "Bearer " + token
+			}
+			if key := strings.TrimPrefix(header, "Bearer "); keys != nil && strings.HasPrefix(key, gorsk.APIKeyPrefix) {
+				au, err := keys.Authenticate(key)
+				if err != nil {
+					return err
+				}
+				setUser(c, au)
+				return next(c)
+			}
+
+			au, err := tokenParser.ParseToken(header)
+			if err != nil {
 				return c.NoContent(http.StatusUnauthorized)
 			}
 
-			claims := token.Claims.(jwt.MapClaims)
+			if au.TokenID != "" {
+				revoked, err := denylist.Contains(au.TokenID)
+				if err != nil {
+					return err
+				}
+				if revoked {
+					return c.NoContent(http.StatusUnauthorized)
+				}
+			}
 
-			id := int(claims["id"].(float64))
-			companyID := int(claims["c"].(float64))
-			locationID := int(claims["l"].(float64))
-			username := claims["u"].(string)
-			email := claims["e"].(string)
-			role := gorsk.AccessRole(claims["r"].(float64))
+			setUser(c, au)
 
-			c.Set("id", id)
-			c.Set("company_id", companyID)
-			c.Set("location_id", locationID)
-			c.Set("username", username)
-			c.Set("email", email)
-			c.Set("role", role)
+			return next(c)
+		}
+	}
+}
+
+// setUser stores authenticated user in context, where it is read from by RBAC service
+func setUser(c echo.Context, au gorsk.AuthUser) {
+	c.Set("id", au.ID)
+	c.Set("company_id", au.CompanyID)
+	c.Set("location_id", au.LocationID)
+	c.Set("username", au.Username)
+	c.Set("email", au.Email)
+	c.Set("role", au.Role)
+	c.Set("session_id", au.SessionID)
+	c.Set("jti", au.TokenID)
+	c.Set("token_expires_at", au.TokenExpiresAt)
+	c.Set("actor_id", au.ActorID)
+	c.Set("email_verified", au.EmailVerified)
+}
 
+// VerifiedEmail rejects requests of users whose email address is not verified.
+// It has to be used after Middleware.
+func VerifiedEmail() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if verified, _ := c.Get("email_verified").(bool); !verified {
+				return gorsk.ErrUnverifiedEmail
+			}
 			return next(c)
 		}
 	}
@@ -4,12 +4,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/cookie"
+	"github.com/ribice/gorsk/pkg/utl/denylist"
 	"github.com/ribice/gorsk/pkg/utl/middleware/auth"
 )
 
@@ -19,6 +21,7 @@ func echoHandler(mw ...echo.MiddlewareFunc) *echo.Echo {
 		e.Use(v)
 	}
 	e.GET("/hello", hwHandler)
+	e.POST("/hello", hwHandler)
 	return e
 }
 
@@ -26,30 +29,36 @@ func hwHandler(c echo.Context) error {
 	return c.String(200, "Hello World")
 }
 
-type tokenParser struct {
-	ParseTokenFn func(string) (*jwt.Token, error)
-}
+type tokenParser struct{}
 
-func (t tokenParser) ParseToken(s string) (*jwt.Token, error) {
-	if s == "" {
-		return nil, gorsk.ErrGeneric
+func (t tokenParser) ParseToken(s string) (gorsk.AuthUser, error) {
+	switch s {
+	case "", "Bearer challenge", "Bearer malformed":
+		return gorsk.AuthUser{}, gorsk.ErrGeneric
+	case "Bearer revoked":
+		return gorsk.AuthUser{ID: 1, Role: gorsk.SuperAdminRole, SessionID: 1, TokenID: "revokedjti"}, nil
 	}
-	return &jwt.Token{
-		Raw:    "abcd",
-		Method: jwt.SigningMethodHS256,
-		Claims: jwt.MapClaims{
-			"c":   1.0,
-			"e":   "johndoe@mail.com",
-			"exp": 1581773411,
-			"id":  1.0,
-			"l":   1.0,
-			"r":   100.0,
-			"u":   "johndoe",
-		},
-		Valid: true,
+	return gorsk.AuthUser{
+		ID:         1,
+		CompanyID:  1,
+		LocationID: 1,
+		Username:   "johndoe",
+		Email:      "johndoe@mail.com",
+		Role:       gorsk.SuperAdminRole,
+		SessionID:  1,
+		TokenID:    "validjti",
 	}, nil
 }
 
+type keyAuthenticator struct{}
+
+func (keyAuthenticator) Authenticate(key string) (gorsk.AuthUser, error) {
+	if key != gorsk.APIKeyPrefix+"valid" {
+		return gorsk.AuthUser{}, echo.ErrUnauthorized
+	}
+	return gorsk.AuthUser{ID: 1, CompanyID: 1, LocationID: 1, Username: "johndoe", Role: gorsk.UserRole}, nil
+}
+
 func TestMWFunc(t *testing.T) {
 	cases := map[string]struct {
 		wantStatus int
@@ -59,12 +68,36 @@ func TestMWFunc(t *testing.T) {
 		"Empty header": {
 			wantStatus: http.StatusUnauthorized,
 		},
+		"Revoked token": {
+			header:     "Bearer revoked",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"Challenge token": {
+			header:     "Bearer challenge",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"Malformed token": {
+			header:     "Bearer malformed",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"Invalid API key": {
+			header:     "Bearer " + gorsk.APIKeyPrefix + "invalid",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"Success with API key": {
+			header:     "Bearer " + gorsk.APIKeyPrefix + "valid",
+			wantStatus: http.StatusOK,
+		},
 		"Success": {
 			header:     "Bearer 123",
 			wantStatus: http.StatusOK,
 		},
 	}
-	ts := httptest.NewServer(echoHandler(auth.Middleware(tokenParser{})))
+	dl := denylist.NewMemory()
+	if err := dl.Add("revokedjti", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(echoHandler(auth.Middleware(tokenParser{}, dl, keyAuthenticator{})))
 	defer ts.Close()
 	path := ts.URL + "/hello"
 	client := &http.Client{}
@@ -81,3 +114,113 @@ func TestMWFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestMWCookie(t *testing.T) {
+	cases := map[string]struct {
+		method     string
+		header     string
+		token      string
+		csrf       string
+		csrfHeader string
+		wantStatus int
+	}{
+		"Revoked token": {
+			method:     "GET",
+			token:      "revoked",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"Missing CSRF token": {
+			method:     "POST",
+			token:      "123",
+			wantStatus: http.StatusForbidden,
+		},
+		"Invalid CSRF token": {
+			method:     "POST",
+			token:      "123",
+			csrf:       "csrf",
+			csrfHeader: "forged",
+			wantStatus: http.StatusForbidden,
+		},
+		"Success with safe method": {
+			method:     "GET",
+			token:      "123",
+			wantStatus: http.StatusOK,
+		},
+		"Success with CSRF token": {
+			method:     "POST",
+			token:      "123",
+			csrf:       "csrf",
+			csrfHeader: "csrf",
+			wantStatus: http.StatusOK,
+		},
+		"Success with header": {
+			method:     "POST",
+			header:     "Bearer 123",
+			token:      "malformed",
+			wantStatus: http.StatusOK,
+		},
+	}
+	dl := denylist.NewMemory()
+	if err := dl.Add("revokedjti", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(echoHandler(auth.Middleware(tokenParser{}, dl, nil)))
+	defer ts.Close()
+	client := &http.Client{}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, ts.URL+"/hello", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			req.AddCookie(&http.Cookie{Name: cookie.AccessCookie, Value: tt.token})
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: cookie.CSRFCookie, Value: tt.csrf})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(cookie.CSRFHeader, tt.csrfHeader)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal("Cannot create http request")
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestVerifiedEmail(t *testing.T) {
+	cases := map[string]struct {
+		verified   bool
+		wantStatus int
+	}{
+		"Unverified email": {
+			wantStatus: http.StatusForbidden,
+		},
+		"Success": {
+			verified:   true,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			verified := tt.verified
+			setUser := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set("email_verified", verified)
+					return next(c)
+				}
+			}
+			ts := httptest.NewServer(echoHandler(setUser, auth.VerifiedEmail()))
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package secure
 import (
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+
+	"github.com/ribice/gorsk/pkg/utl/cookie"
 )
 
 // Headers adds general security headers for basic security measures
@@ -25,14 +27,22 @@ func Headers() echo.MiddlewareFunc {
 	}
 }
 
-// CORS adds Cross-Origin Resource Sharing support
-func CORS() echo.MiddlewareFunc {
-	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"*"},
-		MaxAge:           86400,
-		AllowMethods:     []string{"POST", "GET", "PUT", "DELETE", "PATCH", "HEAD"},
-		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	})
+// CORS adds Cross-Origin Resource Sharing support. Requests with credentials, such as cookies
+// of browser session mode, are allowed only from the given origins. Without them, requests
+// from any origin are allowed, but without credentials.
+func CORS(origins ...string) echo.MiddlewareFunc {
+	cfg := middleware.CORSConfig{
+		AllowOrigins:  []string{"*"},
+		MaxAge:        86400,
+		AllowMethods:  []string{"POST", "GET", "PUT", "DELETE", "PATCH", "HEAD"},
+		AllowHeaders:  []string{"*"},
+		ExposeHeaders: []string{"Content-Length"},
+	}
+	if len(origins) > 0 {
+		// Wildcards are not honored by browsers for requests with credentials
+		cfg.AllowOrigins = origins
+		cfg.AllowHeaders = []string{echo.HeaderAuthorization, echo.HeaderContentType, cookie.CSRFHeader}
+		cfg.AllowCredentials = true
+	}
+	return middleware.CORSWithConfig(cfg)
 }
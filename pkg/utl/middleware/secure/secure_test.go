@@ -48,7 +48,39 @@ func TestCORS(t *testing.T) {
 	assert.Equal(t, "86400", resp.Header.Get("Access-Control-Max-Age"))
 	assert.Equal(t, "POST,GET,PUT,DELETE,PATCH,HEAD", resp.Header.Get("Access-Control-Allow-Methods"))
 	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Headers"))
-	assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "", resp.Header.Get("Access-Control-Allow-Credentials"))
 	// assert.Equal(t, "Content-Length", resp.Header.Get("Access-Control-Expose-Headers"))
 	// assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
 }
+
+func TestCORSWithCredentials(t *testing.T) {
+	ts := httptest.NewServer(echoHandler(secure.CORS("https://app.gorsk.dev")))
+	defer ts.Close()
+	cases := map[string]struct {
+		origin     string
+		wantOrigin string
+	}{
+		"Allowed origin": {
+			origin:     "https://app.gorsk.dev",
+			wantOrigin: "https://app.gorsk.dev",
+		},
+		"Other origin": {
+			origin: "https://evil.dev",
+		},
+	}
+	var cl http.Client
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest("OPTIONS", ts.URL+"/hello", nil)
+			req.Header.Set("Origin", tt.origin)
+			resp, err := cl.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			assert.Equal(t, tt.wantOrigin, resp.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, "Authorization,Content-Type,X-CSRF-Token", resp.Header.Get("Access-Control-Allow-Headers"))
+		})
+	}
+}
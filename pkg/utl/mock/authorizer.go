@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+)
+
+// Authorizer mock
+type Authorizer struct {
+	AuthorizeFn func(echo.Context, gorsk.User) (gorsk.AuthToken, error)
+}
+
+// Authorize mock
+func (a *Authorizer) Authorize(c echo.Context, u gorsk.User) (gorsk.AuthToken, error) {
+	return a.AuthorizeFn(c, u)
+}
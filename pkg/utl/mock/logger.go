@@ -0,0 +1,15 @@
+package mock
+
+import (
+	"github.com/labstack/echo"
+)
+
+// Logger mock
+type Logger struct {
+	LogFn func(echo.Context, string, string, error, map[string]interface{})
+}
+
+// Log mock
+func (l *Logger) Log(c echo.Context, source, msg string, err error, params map[string]interface{}) {
+	l.LogFn(c, source, msg, err, params)
+}
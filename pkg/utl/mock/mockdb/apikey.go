@@ -0,0 +1,47 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// APIKey database mock
+type APIKey struct {
+	CreateFn     func(orm.DB, gorsk.APIKey) (gorsk.APIKey, error)
+	ViewFn       func(orm.DB, int) (gorsk.APIKey, error)
+	ListFn       func(orm.DB, int) ([]gorsk.APIKey, error)
+	FindByHashFn func(orm.DB, string) (gorsk.APIKey, error)
+	TouchFn      func(orm.DB, gorsk.APIKey) error
+	DeleteFn     func(orm.DB, gorsk.APIKey) error
+}
+
+// Create mock
+func (a *APIKey) Create(db orm.DB, key gorsk.APIKey) (gorsk.APIKey, error) {
+	return a.CreateFn(db, key)
+}
+
+// View mock
+func (a *APIKey) View(db orm.DB, id int) (gorsk.APIKey, error) {
+	return a.ViewFn(db, id)
+}
+
+// List mock
+func (a *APIKey) List(db orm.DB, userID int) ([]gorsk.APIKey, error) {
+	return a.ListFn(db, userID)
+}
+
+// FindByHash mock
+func (a *APIKey) FindByHash(db orm.DB, hash string) (gorsk.APIKey, error) {
+	return a.FindByHashFn(db, hash)
+}
+
+// Touch mock
+func (a *APIKey) Touch(db orm.DB, key gorsk.APIKey) error {
+	return a.TouchFn(db, key)
+}
+
+// Delete mock
+func (a *APIKey) Delete(db orm.DB, key gorsk.APIKey) error {
+	return a.DeleteFn(db, key)
+}
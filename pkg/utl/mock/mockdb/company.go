@@ -0,0 +1,23 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Company database mock
+type Company struct {
+	ViewFn   func(orm.DB, int) (gorsk.Company, error)
+	UpdateFn func(orm.DB, gorsk.Company) error
+}
+
+// View mock
+func (c *Company) View(db orm.DB, id int) (gorsk.Company, error) {
+	return c.ViewFn(db, id)
+}
+
+// Update mock
+func (c *Company) Update(db orm.DB, company gorsk.Company) error {
+	return c.UpdateFn(db, company)
+}
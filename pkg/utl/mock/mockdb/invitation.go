@@ -0,0 +1,47 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Invitation database mock
+type Invitation struct {
+	CreateFn     func(orm.DB, gorsk.Invitation) (gorsk.Invitation, error)
+	ViewFn       func(orm.DB, int) (gorsk.Invitation, error)
+	ListFn       func(orm.DB, *gorsk.ListQuery) ([]gorsk.Invitation, error)
+	FindByHashFn func(orm.DB, string) (gorsk.Invitation, error)
+	UpdateFn     func(orm.DB, gorsk.Invitation) error
+	DeleteFn     func(orm.DB, gorsk.Invitation) error
+}
+
+// Create mock
+func (i *Invitation) Create(db orm.DB, inv gorsk.Invitation) (gorsk.Invitation, error) {
+	return i.CreateFn(db, inv)
+}
+
+// View mock
+func (i *Invitation) View(db orm.DB, id int) (gorsk.Invitation, error) {
+	return i.ViewFn(db, id)
+}
+
+// List mock
+func (i *Invitation) List(db orm.DB, q *gorsk.ListQuery) ([]gorsk.Invitation, error) {
+	return i.ListFn(db, q)
+}
+
+// FindByHash mock
+func (i *Invitation) FindByHash(db orm.DB, hash string) (gorsk.Invitation, error) {
+	return i.FindByHashFn(db, hash)
+}
+
+// Update mock
+func (i *Invitation) Update(db orm.DB, inv gorsk.Invitation) error {
+	return i.UpdateFn(db, inv)
+}
+
+// Delete mock
+func (i *Invitation) Delete(db orm.DB, inv gorsk.Invitation) error {
+	return i.DeleteFn(db, inv)
+}
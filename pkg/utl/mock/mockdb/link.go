@@ -0,0 +1,29 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// LoginLink database mock
+type LoginLink struct {
+	CreateFn       func(orm.DB, gorsk.LoginLink) (gorsk.LoginLink, error)
+	FindByHashFn   func(orm.DB, string) (gorsk.LoginLink, error)
+	DeleteByUserFn func(orm.DB, int) error
+}
+
+// Create mock
+func (l *LoginLink) Create(db orm.DB, link gorsk.LoginLink) (gorsk.LoginLink, error) {
+	return l.CreateFn(db, link)
+}
+
+// FindByHash mock
+func (l *LoginLink) FindByHash(db orm.DB, hash string) (gorsk.LoginLink, error) {
+	return l.FindByHashFn(db, hash)
+}
+
+// DeleteByUser mock
+func (l *LoginLink) DeleteByUser(db orm.DB, userID int) error {
+	return l.DeleteByUserFn(db, userID)
+}
@@ -0,0 +1,29 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// LoginEvent database mock
+type LoginEvent struct {
+	CreateFn func(orm.DB, gorsk.LoginEvent) (gorsk.LoginEvent, error)
+	OriginFn func(orm.DB, int, string, string) (gorsk.LoginOrigin, error)
+	ListFn   func(orm.DB, int, gorsk.Pagination) ([]gorsk.LoginEvent, error)
+}
+
+// Create mock
+func (l *LoginEvent) Create(db orm.DB, e gorsk.LoginEvent) (gorsk.LoginEvent, error) {
+	return l.CreateFn(db, e)
+}
+
+// Origin mock
+func (l *LoginEvent) Origin(db orm.DB, userID int, device, ip string) (gorsk.LoginOrigin, error) {
+	return l.OriginFn(db, userID, device, ip)
+}
+
+// List mock
+func (l *LoginEvent) List(db orm.DB, userID int, p gorsk.Pagination) ([]gorsk.LoginEvent, error) {
+	return l.ListFn(db, userID, p)
+}
@@ -0,0 +1,29 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// PasswordReset database mock
+type PasswordReset struct {
+	CreateFn       func(orm.DB, gorsk.PasswordReset) (gorsk.PasswordReset, error)
+	FindByHashFn   func(orm.DB, string) (gorsk.PasswordReset, error)
+	DeleteByUserFn func(orm.DB, int) error
+}
+
+// Create mock
+func (r *PasswordReset) Create(db orm.DB, reset gorsk.PasswordReset) (gorsk.PasswordReset, error) {
+	return r.CreateFn(db, reset)
+}
+
+// FindByHash mock
+func (r *PasswordReset) FindByHash(db orm.DB, hash string) (gorsk.PasswordReset, error) {
+	return r.FindByHashFn(db, hash)
+}
+
+// DeleteByUser mock
+func (r *PasswordReset) DeleteByUser(db orm.DB, userID int) error {
+	return r.DeleteByUserFn(db, userID)
+}
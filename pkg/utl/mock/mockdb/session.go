@@ -0,0 +1,59 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// Session database mock
+type Session struct {
+	CreateFn       func(orm.DB, gorsk.Session) (gorsk.Session, error)
+	ViewFn         func(orm.DB, int) (gorsk.Session, error)
+	ListFn         func(orm.DB, int) ([]gorsk.Session, error)
+	FindByTokenFn  func(orm.DB, string) (gorsk.Session, error)
+	FindByFamilyFn func(orm.DB, string) (gorsk.Session, error)
+	RotateFn       func(orm.DB, gorsk.Session, string) error
+	DeleteFn       func(orm.DB, gorsk.Session) error
+	DeleteByUserFn func(orm.DB, int) error
+}
+
+// Create mock
+func (s *Session) Create(db orm.DB, sess gorsk.Session) (gorsk.Session, error) {
+	return s.CreateFn(db, sess)
+}
+
+// View mock
+func (s *Session) View(db orm.DB, id int) (gorsk.Session, error) {
+	return s.ViewFn(db, id)
+}
+
+// List mock
+func (s *Session) List(db orm.DB, userID int) ([]gorsk.Session, error) {
+	return s.ListFn(db, userID)
+}
+
+// FindByToken mock
+func (s *Session) FindByToken(db orm.DB, token string) (gorsk.Session, error) {
+	return s.FindByTokenFn(db, token)
+}
+
+// FindByFamily mock
+func (s *Session) FindByFamily(db orm.DB, family string) (gorsk.Session, error) {
+	return s.FindByFamilyFn(db, family)
+}
+
+// Rotate mock
+func (s *Session) Rotate(db orm.DB, sess gorsk.Session, old string) error {
+	return s.RotateFn(db, sess, old)
+}
+
+// Delete mock
+func (s *Session) Delete(db orm.DB, sess gorsk.Session) error {
+	return s.DeleteFn(db, sess)
+}
+
+// DeleteByUser mock
+func (s *Session) DeleteByUser(db orm.DB, userID int) error {
+	return s.DeleteByUserFn(db, userID)
+}
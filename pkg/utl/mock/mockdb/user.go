@@ -1,6 +1,8 @@
 package mockdb
 
 import (
+	"time"
+
 	"github.com/go-pg/pg/v9/orm"
 
 	"github.com/ribice/gorsk"
@@ -11,10 +13,19 @@ type User struct {
 	CreateFn         func(orm.DB, gorsk.User) (gorsk.User, error)
 	ViewFn           func(orm.DB, int) (gorsk.User, error)
 	FindByUsernameFn func(orm.DB, string) (gorsk.User, error)
-	FindByTokenFn    func(orm.DB, string) (gorsk.User, error)
+	FindByEmailFn    func(orm.DB, string) (gorsk.User, error)
 	ListFn           func(orm.DB, *gorsk.ListQuery, gorsk.Pagination) ([]gorsk.User, error)
 	DeleteFn         func(orm.DB, gorsk.User) error
 	UpdateFn         func(orm.DB, gorsk.User) error
+	UnlockFn         func(orm.DB, gorsk.User) error
+	UpdateMFAFn      func(orm.DB, gorsk.User) error
+	UpdateEmailFn    func(orm.DB, gorsk.User) error
+	LoginFailedFn    func(orm.DB, int, time.Time, time.Time) (int, error)
+	UseMFAStepFn     func(orm.DB, int, int64) (bool, error)
+
+	UseRecoveryCodeFn func(orm.DB, int, string) (bool, error)
+
+	RequirePasswordChangeFn func(orm.DB, gorsk.User) error
 }
 
 // Create mock
@@ -32,9 +43,9 @@ func (u *User) FindByUsername(db orm.DB, uname string) (gorsk.User, error) {
 	return u.FindByUsernameFn(db, uname)
 }
 
-// FindByToken mock
-func (u *User) FindByToken(db orm.DB, token string) (gorsk.User, error) {
-	return u.FindByTokenFn(db, token)
+// FindByEmail mock
+func (u *User) FindByEmail(db orm.DB, email string) (gorsk.User, error) {
+	return u.FindByEmailFn(db, email)
 }
 
 // List mock
@@ -51,3 +62,38 @@ func (u *User) Delete(db orm.DB, usr gorsk.User) error {
 func (u *User) Update(db orm.DB, usr gorsk.User) error {
 	return u.UpdateFn(db, usr)
 }
+
+// Unlock mock
+func (u *User) Unlock(db orm.DB, usr gorsk.User) error {
+	return u.UnlockFn(db, usr)
+}
+
+// UpdateMFA mock
+func (u *User) UpdateMFA(db orm.DB, usr gorsk.User) error {
+	return u.UpdateMFAFn(db, usr)
+}
+
+// UpdateEmail mock
+func (u *User) UpdateEmail(db orm.DB, usr gorsk.User) error {
+	return u.UpdateEmailFn(db, usr)
+}
+
+// RequirePasswordChange mock
+func (u *User) RequirePasswordChange(db orm.DB, usr gorsk.User) error {
+	return u.RequirePasswordChangeFn(db, usr)
+}
+
+// LoginFailed mock
+func (u *User) LoginFailed(db orm.DB, id int, at, since time.Time) (int, error) {
+	return u.LoginFailedFn(db, id, at, since)
+}
+
+// UseMFAStep mock
+func (u *User) UseMFAStep(db orm.DB, id int, step int64) (bool, error) {
+	return u.UseMFAStepFn(db, id, step)
+}
+
+// UseRecoveryCode mock
+func (u *User) UseRecoveryCode(db orm.DB, id int, hash string) (bool, error) {
+	return u.UseRecoveryCodeFn(db, id, hash)
+}
@@ -0,0 +1,35 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// EmailVerification database mock
+type EmailVerification struct {
+	CreateFn         func(orm.DB, gorsk.EmailVerification) (gorsk.EmailVerification, error)
+	FindByHashFn     func(orm.DB, string) (gorsk.EmailVerification, error)
+	FindActivationFn func(orm.DB, int) (gorsk.EmailVerification, error)
+	DeleteByUserFn   func(orm.DB, int) error
+}
+
+// Create mock
+func (v *EmailVerification) Create(db orm.DB, ver gorsk.EmailVerification) (gorsk.EmailVerification, error) {
+	return v.CreateFn(db, ver)
+}
+
+// FindByHash mock
+func (v *EmailVerification) FindByHash(db orm.DB, hash string) (gorsk.EmailVerification, error) {
+	return v.FindByHashFn(db, hash)
+}
+
+// FindActivation mock
+func (v *EmailVerification) FindActivation(db orm.DB, userID int) (gorsk.EmailVerification, error) {
+	return v.FindActivationFn(db, userID)
+}
+
+// DeleteByUser mock
+func (v *EmailVerification) DeleteByUser(db orm.DB, userID int) error {
+	return v.DeleteByUserFn(db, userID)
+}
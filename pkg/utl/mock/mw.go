@@ -6,10 +6,48 @@ import (
 
 // JWT mock
 type JWT struct {
-	GenerateTokenFn func(gorsk.User) (string, error)
+	GenerateTokenFn     func(gorsk.User, int) (string, error)
+	GenerateChallengeFn func(gorsk.User) (string, error)
+	ParseChallengeFn    func(string) (int, error)
+	JWKSFn              func() gorsk.JWKS
+
+	GenerateImpersonationTokenFn func(gorsk.User, int) (string, error)
+
+	GeneratePasswordChallengeFn func(gorsk.User) (string, error)
+	ParsePasswordChallengeFn    func(string) (int, error)
 }
 
 // GenerateToken mock
-func (j JWT) GenerateToken(u gorsk.User) (string, error) {
-	return j.GenerateTokenFn(u)
+func (j JWT) GenerateToken(u gorsk.User, sessionID int) (string, error) {
+	return j.GenerateTokenFn(u, sessionID)
+}
+
+// GenerateChallenge mock
+func (j JWT) GenerateChallenge(u gorsk.User) (string, error) {
+	return j.GenerateChallengeFn(u)
+}
+
+// ParseChallenge mock
+func (j JWT) ParseChallenge(token string) (int, error) {
+	return j.ParseChallengeFn(token)
+}
+
+// JWKS mock
+func (j JWT) JWKS() gorsk.JWKS {
+	return j.JWKSFn()
+}
+
+// GenerateImpersonationToken mock
+func (j JWT) GenerateImpersonationToken(u gorsk.User, actorID int) (string, error) {
+	return j.GenerateImpersonationTokenFn(u, actorID)
+}
+
+// GeneratePasswordChallenge mock
+func (j JWT) GeneratePasswordChallenge(u gorsk.User) (string, error) {
+	return j.GeneratePasswordChallengeFn(u)
+}
+
+// ParsePasswordChallenge mock
+func (j JWT) ParsePasswordChallenge(token string) (int, error) {
+	return j.ParsePasswordChallengeFn(token)
 }
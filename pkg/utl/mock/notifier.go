@@ -0,0 +1,15 @@
+package mock
+
+import (
+	"github.com/ribice/gorsk"
+)
+
+// Notifier mock
+type Notifier struct {
+	NewLoginFn func(gorsk.User, gorsk.LoginEvent) error
+}
+
+// NewLogin mock
+func (n *Notifier) NewLogin(u gorsk.User, e gorsk.LoginEvent) error {
+	return n.NewLoginFn(u, e)
+}
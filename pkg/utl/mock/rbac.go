@@ -9,12 +9,14 @@ import (
 // RBAC Mock
 type RBAC struct {
 	UserFn            func(echo.Context) gorsk.AuthUser
-	EnforceRoleFn     func(echo.Context, gorsk.AccessRole) error
-	EnforceUserFn     func(echo.Context, int) error
+	EnforceUserFn     func(echo.Context, gorsk.Permission, int) error
 	EnforceCompanyFn  func(echo.Context, int) error
 	EnforceLocationFn func(echo.Context, int) error
 	AccountCreateFn   func(echo.Context, gorsk.AccessRole, int, int) error
-	IsLowerRoleFn     func(echo.Context, gorsk.AccessRole) error
+	EnforceFn         func(echo.Context, gorsk.Permission, gorsk.Resource) error
+	GrantFn           func(echo.Context, gorsk.Permission) (gorsk.RolePermission, error)
+	RoleIncludesFn    func(gorsk.AccessRole, gorsk.AccessRole) (bool, error)
+	InvalidateFn      func(int)
 }
 
 // User mock
@@ -22,14 +24,9 @@ func (a RBAC) User(c echo.Context) gorsk.AuthUser {
 	return a.UserFn(c)
 }
 
-// EnforceRole mock
-func (a RBAC) EnforceRole(c echo.Context, role gorsk.AccessRole) error {
-	return a.EnforceRoleFn(c, role)
-}
-
 // EnforceUser mock
-func (a RBAC) EnforceUser(c echo.Context, id int) error {
-	return a.EnforceUserFn(c, id)
+func (a RBAC) EnforceUser(c echo.Context, p gorsk.Permission, id int) error {
+	return a.EnforceUserFn(c, p, id)
 }
 
 // EnforceCompany mock
@@ -47,7 +44,22 @@ func (a RBAC) AccountCreate(c echo.Context, roleID gorsk.AccessRole, companyID,
 	return a.AccountCreateFn(c, roleID, companyID, locationID)
 }
 
-// IsLowerRole mock
-func (a RBAC) IsLowerRole(c echo.Context, role gorsk.AccessRole) error {
-	return a.IsLowerRoleFn(c, role)
+// Enforce mock
+func (a RBAC) Enforce(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+	return a.EnforceFn(c, p, r)
+}
+
+// Grant mock
+func (a RBAC) Grant(c echo.Context, p gorsk.Permission) (gorsk.RolePermission, error) {
+	return a.GrantFn(c, p)
+}
+
+// RoleIncludes mock
+func (a RBAC) RoleIncludes(role, other gorsk.AccessRole) (bool, error) {
+	return a.RoleIncludesFn(role, other)
+}
+
+// Invalidate mock
+func (a RBAC) Invalidate(id int) {
+	a.InvalidateFn(id)
 }
@@ -1,20 +1,34 @@
 package mock
 
+import "github.com/ribice/gorsk/pkg/utl/secure"
+
 // Secure mock
 type Secure struct {
-	PasswordFn            func(string, ...string) bool
-	HashFn                func(string) string
+	PasswordFn            func(string, ...string) error
+	CheckPasswordFn       func(string, ...string) secure.Feedback
+	BreachedFn            func(string) (bool, error)
+	HashFn                func(string) (string, error)
 	HashMatchesPasswordFn func(string, string) bool
-	TokenFn               func(string) string
+	NeedsRehashFn         func(string) bool
 }
 
 // Password mock
-func (s *Secure) Password(pw string, inputs ...string) bool {
+func (s *Secure) Password(pw string, inputs ...string) error {
 	return s.PasswordFn(pw, inputs...)
 }
 
+// CheckPassword mock
+func (s *Secure) CheckPassword(pw string, inputs ...string) secure.Feedback {
+	return s.CheckPasswordFn(pw, inputs...)
+}
+
+// Breached mock
+func (s *Secure) Breached(pw string) (bool, error) {
+	return s.BreachedFn(pw)
+}
+
 // Hash mock
-func (s *Secure) Hash(pw string) string {
+func (s *Secure) Hash(pw string) (string, error) {
 	return s.HashFn(pw)
 }
 
@@ -23,7 +37,7 @@ func (s *Secure) HashMatchesPassword(hash, pw string) bool {
 	return s.HashMatchesPasswordFn(hash, pw)
 }
 
-// Token mock
-func (s *Secure) Token(token string) string {
-	return s.TokenFn(token)
+// NeedsRehash mock
+func (s *Secure) NeedsRehash(hash string) bool {
+	return s.NeedsRehashFn(hash)
 }
@@ -0,0 +1,23 @@
+package mock
+
+import (
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk"
+)
+
+// Verifier mock
+type Verifier struct {
+	SendFn           func(echo.Context, gorsk.User, string) error
+	SendActivationFn func(echo.Context, gorsk.User) error
+}
+
+// Send mock
+func (v *Verifier) Send(c echo.Context, u gorsk.User, email string) error {
+	return v.SendFn(c, u, email)
+}
+
+// SendActivation mock
+func (v *Verifier) SendActivation(c echo.Context, u gorsk.User) error {
+	return v.SendActivationFn(c, u)
+}
@@ -0,0 +1,44 @@
+// Package notify informs users about security related activity on their accounts
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+)
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
+}
+
+// NewMail creates new notifier emailing users
+func NewMail(m Mailer) *Mail {
+	return &Mail{mailer: m}
+}
+
+// Mail represents notifier emailing users
+type Mail struct {
+	mailer Mailer
+}
+
+const newLoginBody = `Hi %s,
+
+your account was signed in to from a new device or IP address:
+
+Device: %s
+IP address: %s
+Time: %s
+
+If this was you, you can ignore this email. Otherwise, change your password and revoke sessions you do not recognize.`
+
+// NewLogin emails the user about login from a new device or IP address
+func (m *Mail) NewLogin(u gorsk.User, e gorsk.LoginEvent) error {
+	return m.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "New sign-in to your account",
+		Body:    fmt.Sprintf(newLoginBody, u.FirstName, e.Device, e.IP, e.CreatedAt.UTC().Format(time.RFC1123)),
+	})
+}
@@ -0,0 +1,36 @@
+package notify_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mail"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/notify"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogin(t *testing.T) {
+	var msg mail.Message
+	n := notify.NewMail(&mock.Mailer{
+		SendFn: func(m mail.Message) error {
+			msg = m
+			return nil
+		},
+	})
+
+	err := n.NewLogin(gorsk.User{FirstName: "John", Email: "johndoe@mail.com"}, gorsk.LoginEvent{
+		Base:   gorsk.Base{CreatedAt: time.Date(2020, 2, 1, 10, 30, 0, 0, time.UTC)},
+		Device: "iPhone",
+		IP:     "192.0.2.1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "johndoe@mail.com", msg.To)
+	assert.Equal(t, "New sign-in to your account", msg.Subject)
+	for _, s := range []string{"Hi John", "iPhone", "192.0.2.1", "Sat, 01 Feb 2020 10:30:00 UTC"} {
+		assert.True(t, strings.Contains(msg.Body, s), s)
+	}
+}
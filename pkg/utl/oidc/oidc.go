@@ -0,0 +1,338 @@
+// Package oidc implements OpenID Connect authorization code flow with PKCE against external identity providers
+package oidc
+
+import (
+	"container/list"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/ribice/gorsk/pkg/utl/secure"
+)
+
+// StateTTL is the time user has to complete the login at identity provider
+const StateTTL = 10 * time.Minute
+
+// MaxPending is the number of logins in progress a provider keeps. Once reached, the oldest login is forgotten.
+const MaxPending = 10000
+
+// Custom errors
+var (
+	ErrInvalidState   = errors.New("oidc: invalid or expired state")
+	ErrInvalidIDToken = errors.New("oidc: invalid id token")
+)
+
+// Config holds identity provider client settings
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Identity represents user identity asserted by identity provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// New creates new identity provider client. Provider metadata is discovered on first use.
+func New(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Provider{cfg: cfg, client: client, order: list.New(), pending: make(map[string]*list.Element)}
+}
+
+// Provider represents OpenID Connect identity provider client.
+// It keeps PKCE verifiers and nonces of logins in progress in memory.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]*rsa.PublicKey
+	// order holds logins in progress by their start, oldest first
+	order   *list.List
+	pending map[string]*list.Element
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type pending struct {
+	state     string
+	verifier  string
+	nonce     string
+	expiresAt time.Time
+}
+
+// AuthCodeURL starts a new login, returning identity provider URL the user should be redirected to
+// and the login's state. Callers bind the state to the user's browser, so that the login can only
+// be completed in the browser that started it.
+func (p *Provider) AuthCodeURL(ctx context.Context) (string, string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := secure.NewToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := secure.NewToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := secure.NewToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	for e := p.order.Front(); e != nil && (p.order.Len() >= MaxPending || now.After(e.Value.(pending).expiresAt)); e = p.order.Front() {
+		p.remove(e)
+	}
+	p.pending[state] = p.order.PushBack(pending{state: state, verifier: verifier, nonce: nonce, expiresAt: now.Add(StateTTL)})
+	p.mu.Unlock()
+
+	scopes := append([]string{"openid"}, p.cfg.Scopes...)
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", challenge(verifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + v.Encode(), state, nil
+}
+
+// Exchange completes the login started with AuthCodeURL, exchanging authorization code for
+// identity asserted in verified id token
+func (p *Provider) Exchange(ctx context.Context, state, code string) (Identity, error) {
+	p.mu.Lock()
+	e, ok := p.pending[state]
+	if ok {
+		p.remove(e)
+	}
+	p.mu.Unlock()
+	if !ok {
+		return Identity{}, ErrInvalidState
+	}
+	pend := e.Value.(pending)
+	if time.Now().After(pend.expiresAt) {
+		return Identity{}, ErrInvalidState
+	}
+
+	md, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("code_verifier", pend.verifier)
+	if p.cfg.ClientSecret != "" {
+		v.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, md.TokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(ctx, req, &resp); err != nil {
+		return Identity{}, err
+	}
+
+	return p.verify(ctx, md, resp.IDToken, pend.nonce)
+}
+
+// remove forgets a login in progress. Callers hold the mutex.
+func (p *Provider) remove(e *list.Element) {
+	p.order.Remove(e)
+	delete(p.pending, e.Value.(pending).state)
+}
+
+// verify checks id token signature and claims, returning identity it asserts
+func (p *Provider) verify(ctx context.Context, md *metadata, token, nonce string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	t, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrInvalidIDToken
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, md, kid)
+	})
+	if err != nil || !t.Valid {
+		return Identity{}, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyIssuer(md.Issuer, true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) ||
+		!audience(claims, p.cfg.ClientID) || claims["nonce"] != nonce {
+		return Identity{}, ErrInvalidIDToken
+	}
+
+	id := Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.GivenName, _ = claims["given_name"].(string)
+	id.FamilyName, _ = claims["family_name"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = v == "true"
+	}
+	if id.Subject == "" {
+		return Identity{}, ErrInvalidIDToken
+	}
+	return id, nil
+}
+
+// discover fetches and caches identity provider metadata
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	md := p.metadata
+	p.mu.Unlock()
+	if md != nil {
+		return md, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	md = new(metadata)
+	if err := p.do(ctx, req, md); err != nil {
+		return nil, err
+	}
+	if md.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: issuer %q does not match configured %q", md.Issuer, p.cfg.Issuer)
+	}
+
+	p.mu.Lock()
+	p.metadata = md
+	p.mu.Unlock()
+	return md, nil
+}
+
+// key returns identity provider's signing key by ID, refetching the key set when the key is not known
+func (p *Provider) key(ctx context.Context, md *metadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, md.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.do(ctx, req, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrInvalidIDToken
+}
+
+func (p *Provider) do(ctx context.Context, req *http.Request, v interface{}) error {
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s %s returned %s", req.Method, req.URL, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// audience checks whether token's audience, either a string or an array, contains clientID
+func audience(claims jwt.MapClaims, clientID string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// challenge returns S256 PKCE code challenge for verifier
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,150 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/oidc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := mock.NewIdP(t, "gorsk", nil)
+	defer idp.Close()
+
+	p := oidc.New(oidc.Config{
+		Issuer:      idp.URL,
+		ClientID:    "gorsk",
+		RedirectURL: "http://localhost:8080/login/oidc/corp/callback",
+		Scopes:      []string{"email", "profile"},
+	}, nil)
+
+	authURL, state, err := p.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	assert.Equal(t, idp.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "gorsk", q.Get("client_id"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.NotEmpty(t, q.Get("code_challenge"))
+	assert.Equal(t, state, q.Get("state"))
+	assert.NotEmpty(t, state)
+	assert.NotEmpty(t, q.Get("nonce"))
+}
+
+func TestAuthCodeURLIssuerMismatch(t *testing.T) {
+	idp := mock.NewIdP(t, "gorsk", nil)
+	defer idp.Close()
+
+	p := oidc.New(oidc.Config{Issuer: idp.URL + "/", ClientID: "gorsk"}, nil)
+	_, _, err := p.AuthCodeURL(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestExchange(t *testing.T) {
+	cases := []struct {
+		name     string
+		clientID string
+		claims   map[string]interface{}
+		state    func(string) string
+		wantData oidc.Identity
+		wantErr  error
+	}{
+		{
+			name:     "Fail on unknown state",
+			clientID: "gorsk",
+			state:    func(string) string { return "unknown" },
+			wantErr:  oidc.ErrInvalidState,
+		},
+		{
+			name:     "Fail on audience",
+			clientID: "gorsk",
+			claims:   map[string]interface{}{"aud": "other"},
+			wantErr:  oidc.ErrInvalidIDToken,
+		},
+		{
+			name:     "Fail on missing expiry",
+			clientID: "gorsk",
+			claims:   map[string]interface{}{"exp": nil},
+			wantErr:  oidc.ErrInvalidIDToken,
+		},
+		{
+			name:     "Fail on missing subject",
+			clientID: "gorsk",
+			claims:   map[string]interface{}{"sub": ""},
+			wantErr:  oidc.ErrInvalidIDToken,
+		},
+		{
+			name:     "Success",
+			clientID: "gorsk",
+			claims: map[string]interface{}{
+				"aud":            []string{"gorsk", "other"},
+				"email":          "johndoe@mail.com",
+				"email_verified": true,
+				"given_name":     "John",
+				"family_name":    "Doe",
+			},
+			wantData: oidc.Identity{
+				Subject:       "subject",
+				Email:         "johndoe@mail.com",
+				EmailVerified: true,
+				GivenName:     "John",
+				FamilyName:    "Doe",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := mock.NewIdP(t, tt.clientID, tt.claims)
+			defer idp.Close()
+
+			p := oidc.New(oidc.Config{
+				Issuer:      idp.URL,
+				ClientID:    tt.clientID,
+				RedirectURL: "http://localhost:8080/login/oidc/corp/callback",
+			}, nil)
+			authURL, _, err := p.AuthCodeURL(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, code := idp.Authorize(t, authURL)
+			if tt.state != nil {
+				state = tt.state(state)
+			}
+
+			id, err := p.Exchange(context.Background(), state, code)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, id)
+
+			_, err = p.Exchange(context.Background(), state, code)
+			assert.Equal(t, oidc.ErrInvalidState, err, "state must be usable only once")
+		})
+	}
+}
+
+func TestPendingLimit(t *testing.T) {
+	idp := mock.NewIdP(t, "gorsk", nil)
+	defer idp.Close()
+
+	p := oidc.New(oidc.Config{Issuer: idp.URL, ClientID: "gorsk"}, nil)
+	_, first, err := p.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < oidc.MaxPending; i++ {
+		if _, _, err := p.AuthCodeURL(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = p.Exchange(context.Background(), first, "code")
+	assert.Equal(t, oidc.ErrInvalidState, err, "the oldest login should be forgotten")
+}
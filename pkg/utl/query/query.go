@@ -6,15 +6,21 @@ import (
 	"github.com/ribice/gorsk"
 )
 
-// List prepares data for list queries
-func List(u gorsk.AuthUser) (*gorsk.ListQuery, error) {
-	switch true {
-	case u.Role <= gorsk.AdminRole: // user is SuperAdmin or Admin
-		return nil, nil
-	case u.Role == gorsk.CompanyAdminRole:
-		return &gorsk.ListQuery{Query: "company_id = ?", ID: u.CompanyID}, nil
-	case u.Role == gorsk.LocationAdminRole:
-		return &gorsk.ListQuery{Query: "location_id = ?", ID: u.LocationID}, nil
+// List prepares data for list queries of users within scope and roles granted to the user.
+// Roles are not applied in own scope, as users can always see their own account.
+func List(u gorsk.AuthUser, g gorsk.RolePermission) (*gorsk.ListQuery, error) {
+	switch g.Scope {
+	case gorsk.ScopeAny:
+		if len(g.Roles) == 0 {
+			return nil, nil
+		}
+		return &gorsk.ListQuery{Roles: g.Roles}, nil
+	case gorsk.ScopeCompany:
+		return &gorsk.ListQuery{Query: "company_id = ?", ID: u.CompanyID, Roles: g.Roles}, nil
+	case gorsk.ScopeLocation:
+		return &gorsk.ListQuery{Query: "location_id = ?", ID: u.LocationID, Roles: g.Roles}, nil
+	case gorsk.ScopeOwn:
+		return &gorsk.ListQuery{Query: `"user"."id" = ?`, ID: u.ID}, nil
 	default:
 		return nil, echo.ErrForbidden
 	}
@@ -14,7 +14,8 @@ import (
 
 func TestList(t *testing.T) {
 	type args struct {
-		user gorsk.AuthUser
+		user  gorsk.AuthUser
+		grant gorsk.RolePermission
 	}
 	cases := []struct {
 		name     string
@@ -23,34 +24,53 @@ func TestList(t *testing.T) {
 		wantErr  error
 	}{
 		{
-			name: "Super admin user",
+			name: "Any scope",
 			args: args{user: gorsk.AuthUser{
 				Role: gorsk.SuperAdminRole,
-			}},
+			}, grant: gorsk.RolePermission{Scope: gorsk.ScopeAny}},
+		},
+		{
+			name: "Any scope limited to roles",
+			args: args{user: gorsk.AuthUser{
+				Role: gorsk.AdminRole,
+			}, grant: gorsk.RolePermission{Scope: gorsk.ScopeAny, Roles: []gorsk.AccessRole{gorsk.UserRole}}},
+			wantData: &gorsk.ListQuery{
+				Roles: []gorsk.AccessRole{gorsk.UserRole}},
 		},
 		{
-			name: "Company admin user",
+			name: "Company scope",
 			args: args{user: gorsk.AuthUser{
 				Role:      gorsk.CompanyAdminRole,
 				CompanyID: 1,
-			}},
+			}, grant: gorsk.RolePermission{Scope: gorsk.ScopeCompany}},
 			wantData: &gorsk.ListQuery{
 				Query: "company_id = ?",
 				ID:    1},
 		},
 		{
-			name: "Location admin user",
+			name: "Location scope",
 			args: args{user: gorsk.AuthUser{
 				Role:       gorsk.LocationAdminRole,
 				CompanyID:  1,
 				LocationID: 2,
-			}},
+			}, grant: gorsk.RolePermission{Scope: gorsk.ScopeLocation, Roles: []gorsk.AccessRole{gorsk.UserRole}}},
 			wantData: &gorsk.ListQuery{
 				Query: "location_id = ?",
-				ID:    2},
+				ID:    2,
+				Roles: []gorsk.AccessRole{gorsk.UserRole}},
+		},
+		{
+			name: "Own scope",
+			args: args{user: gorsk.AuthUser{
+				ID:   5,
+				Role: gorsk.UserRole,
+			}, grant: gorsk.RolePermission{Scope: gorsk.ScopeOwn, Roles: []gorsk.AccessRole{gorsk.AdminRole}}},
+			wantData: &gorsk.ListQuery{
+				Query: `"user"."id" = ?`,
+				ID:    5},
 		},
 		{
-			name: "Normal user",
+			name: "Not granted",
 			args: args{user: gorsk.AuthUser{
 				Role: gorsk.UserRole,
 			}},
@@ -59,7 +79,7 @@ func TestList(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			q, err := query.List(tt.args.user)
+			q, err := query.List(tt.args.user, tt.args.grant)
 			assert.Equal(t, tt.wantData, q)
 			assert.Equal(t, tt.wantErr, err)
 		})
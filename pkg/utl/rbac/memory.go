@@ -0,0 +1,16 @@
+package rbac
+
+import "github.com/ribice/gorsk"
+
+// NewMemory creates new in-memory permission store
+func NewMemory(perms []gorsk.RolePermission) Memory {
+	return Memory(perms)
+}
+
+// Memory is an in-memory permission store, such as gorsk.DefaultPermissions
+type Memory []gorsk.RolePermission
+
+// Permissions returns permissions granted to roles
+func (m Memory) Permissions() ([]gorsk.RolePermission, error) {
+	return m, nil
+}
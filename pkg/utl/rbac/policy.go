@@ -0,0 +1,130 @@
+package rbac
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ribice/gorsk"
+)
+
+var roles = map[string]gorsk.AccessRole{
+	"super_admin":    gorsk.SuperAdminRole,
+	"admin":          gorsk.AdminRole,
+	"company_admin":  gorsk.CompanyAdminRole,
+	"location_admin": gorsk.LocationAdminRole,
+	"user":           gorsk.UserRole,
+}
+
+// LoadPolicy loads permissions granted to roles from YAML policy file
+func LoadPolicy(path string) (Memory, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file, %s", err)
+	}
+	return ParsePolicy(b)
+}
+
+// ParsePolicy parses YAML policy, declaring for each resource and action the roles allowed to perform it
+// and scope of resources they can act on: any, company (same company), location (same location) or own.
+// Roles are one of super_admin, admin, company_admin, location_admin or user. Acting on users can be
+// limited to users of listed roles, by declaring scope along with the roles.
+//
+//	users:
+//	  read:
+//	    admin: any
+//	    company_admin: company
+//	  delete:
+//	    company_admin: {scope: company, roles: [location_admin, user]}
+func ParsePolicy(b []byte) (Memory, error) {
+	var policy map[string]map[string]map[string]grant
+	if err := yaml.UnmarshalStrict(b, &policy); err != nil {
+		return nil, fmt.Errorf("unable to decode policy, %v", err)
+	}
+
+	var perms Memory
+	for resource, actions := range policy {
+		for action, grants := range actions {
+			p := gorsk.Permission(resource + ":" + action)
+			if !p.Valid() {
+				return nil, fmt.Errorf("invalid policy permission: %s", p)
+			}
+			for name, g := range grants {
+				role, ok := roles[name]
+				if !ok {
+					return nil, fmt.Errorf("invalid policy role for %s: %s", p, name)
+				}
+				if !g.Scope.Valid() {
+					return nil, fmt.Errorf("invalid policy scope for %s of %s: %s", p, name, g.Scope)
+				}
+				rp := gorsk.RolePermission{RoleID: role, Permission: p, Scope: g.Scope}
+				for _, target := range g.Roles {
+					r, ok := roles[target]
+					if !ok {
+						return nil, fmt.Errorf("invalid policy role for %s of %s: %s", p, name, target)
+					}
+					rp.Roles = append(rp.Roles, r)
+				}
+				perms = append(perms, rp)
+			}
+		}
+	}
+
+	sort.Slice(perms, func(i, j int) bool {
+		if perms[i].RoleID != perms[j].RoleID {
+			return perms[i].RoleID < perms[j].RoleID
+		}
+		return perms[i].Permission < perms[j].Permission
+	})
+	return perms, nil
+}
+
+// grant is scope of permission granted to a role, and roles of users it is limited to, if any
+type grant struct {
+	Scope gorsk.Scope `yaml:"scope"`
+	Roles []string    `yaml:"roles"`
+}
+
+// UnmarshalYAML decodes grant declared either as scope only, or as scope and roles
+func (g *grant) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&g.Scope); err == nil {
+		return nil
+	}
+	type plain grant
+	return unmarshal((*plain)(g))
+}
+
+// NewOverride creates permission store granting permissions declared in policy in place of store's grants
+// of the same permissions. Permissions the policy does not declare are granted as in store.
+func NewOverride(store Store, policy Memory) *Override {
+	return &Override{store: store, policy: policy}
+}
+
+// Override is a permission store overriding grants of another store with grants declared in a policy
+type Override struct {
+	store  Store
+	policy Memory
+}
+
+// Permissions returns permissions granted to roles, by the policy or the overridden store
+func (o *Override) Permissions() ([]gorsk.RolePermission, error) {
+	perms, err := o.store.Permissions()
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[gorsk.Permission]bool)
+	for _, rp := range o.policy {
+		declared[rp.Permission] = true
+	}
+
+	merged := append([]gorsk.RolePermission(nil), o.policy...)
+	for _, rp := range perms {
+		if !declared[rp.Permission] {
+			merged = append(merged, rp)
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,119 @@
+package rbac_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/rbac"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		wantData rbac.Memory
+		wantErr  bool
+	}{
+		{
+			name:    "Fail on non-existing file",
+			path:    "notExists",
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			path:     "../../../cmd/api/policy.yaml",
+			wantData: gorsk.DefaultPermissions,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			perms, err := rbac.LoadPolicy(tt.path)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.ElementsMatch(t, tt.wantData, perms)
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   string
+		wantData rbac.Memory
+		wantErr  string
+	}{
+		{
+			name:    "Fail on invalid YAML",
+			policy:  "users: [read]",
+			wantErr: "unable to decode policy",
+		},
+		{
+			name:    "Fail on unknown permission",
+			policy:  "users:\n  fly:\n    admin: any",
+			wantErr: "invalid policy permission: users:fly",
+		},
+		{
+			name:    "Fail on unknown role",
+			policy:  "users:\n  read:\n    owner: any",
+			wantErr: "invalid policy role for users:read: owner",
+		},
+		{
+			name:    "Fail on unknown scope",
+			policy:  "users:\n  read:\n    admin: everywhere",
+			wantErr: "invalid policy scope for users:read of admin: everywhere",
+		},
+		{
+			name:    "Fail on unknown role of users",
+			policy:  "users:\n  delete:\n    admin: {scope: any, roles: [owner]}",
+			wantErr: "invalid policy role for users:delete of admin: owner",
+		},
+		{
+			name:    "Fail on unknown grant field",
+			policy:  "users:\n  delete:\n    admin: {scope: any, role: user}",
+			wantErr: "unable to decode policy",
+		},
+		{
+			name:   "Success",
+			policy: "users:\n  read:\n    user: own\n    admin: any\nlogins:\n  read:\n    company_admin: {scope: company, roles: [location_admin, user]}",
+			wantData: rbac.Memory{
+				{RoleID: gorsk.AdminRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeAny},
+				{RoleID: gorsk.CompanyAdminRole, Permission: gorsk.LoginsRead, Scope: gorsk.ScopeCompany, Roles: []gorsk.AccessRole{gorsk.LocationAdminRole, gorsk.UserRole}},
+				{RoleID: gorsk.UserRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeOwn},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			perms, err := rbac.ParsePolicy([]byte(tt.policy))
+			if tt.wantErr != "" {
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantData, perms)
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	policy := rbac.Memory{
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeCompany},
+	}
+
+	_, err := rbac.NewOverride(&store{err: errors.New("db error")}, policy).Permissions()
+	assert.NotNil(t, err)
+
+	st := &store{perms: []gorsk.RolePermission{
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeAny},
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeAny},
+		{RoleID: gorsk.CompanyAdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeCompany},
+	}}
+	perms, err := rbac.NewOverride(st, policy).Permissions()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []gorsk.RolePermission{
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeAny},
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeCompany},
+	}, perms)
+}
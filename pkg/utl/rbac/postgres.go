@@ -0,0 +1,32 @@
+package rbac
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/ribice/gorsk"
+)
+
+// NewPG creates new postgres backed permission store
+func NewPG(db orm.DB) *PG {
+	return &PG{db: db}
+}
+
+// PG is a postgres backed permission store. Permissions are stored in role_permissions table,
+// and users permissions are enforced on are loaded from users table.
+type PG struct {
+	db orm.DB
+}
+
+// Permissions returns permissions granted to roles
+func (p *PG) Permissions() ([]gorsk.RolePermission, error) {
+	var perms []gorsk.RolePermission
+	err := p.db.Model(&perms).Select()
+	return perms, err
+}
+
+// Member returns company, location and role of a user
+func (p *PG) Member(id int) (gorsk.User, error) {
+	u := gorsk.User{Base: gorsk.Base{ID: id}}
+	err := p.db.Model(&u).Column("id", "company_id", "location_id", "role_id").WherePK().Where("deleted_at is null").Select()
+	return u, err
+}
@@ -0,0 +1,46 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/mock"
+	"github.com/ribice/gorsk/pkg/utl/rbac"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPG(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.RolePermission{}, &gorsk.Role{}, &gorsk.User{})
+
+	perms := []gorsk.RolePermission{
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeAny},
+		{RoleID: gorsk.UserRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeOwn},
+	}
+	for i := range perms {
+		if err := mock.InsertMultiple(db, &perms[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mock.InsertMultiple(db, &gorsk.Role{ID: gorsk.UserRole, AccessLevel: gorsk.UserRole, Name: "USER"},
+		&gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Email: "johndoe@mail.com", RoleID: gorsk.UserRole, CompanyID: 2, LocationID: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	pg := rbac.NewPG(db)
+
+	got, err := pg.Permissions()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, perms, got)
+
+	u, err := pg.Member(1)
+	assert.Nil(t, err)
+	assert.Equal(t, gorsk.Resource{UserID: 1, CompanyID: 2, LocationID: 3}, u.Resource())
+	assert.Equal(t, gorsk.UserRole, u.RoleID)
+
+	_, err = pg.Member(2)
+	assert.NotNil(t, err)
+}
@@ -1,13 +1,57 @@
 package rbac
 
 import (
+	"container/list"
+	"sync"
+	"time"
+
 	"github.com/labstack/echo"
 
 	"github.com/ribice/gorsk"
 )
 
+// MemberCapacity is the number of users permissions are enforced on whose company, location and role are cached
+const MemberCapacity = 10000
+
+// New creates new RBAC application service, granting roles permissions loaded from store.
+// Permissions, and company, location and role of up to MemberCapacity users loaded from users,
+// are cached and reloaded after ttl. Zero ttl loads them only once. Cached users are dropped
+// once Invalidate is called for them, and least recently used ones are dropped first once full.
+func New(store Store, users Users, ttl time.Duration) *Service {
+	return &Service{store: store, users: users, ttl: ttl, order: list.New(), members: make(map[int]*list.Element)}
+}
+
 // Service is RBAC application service
-type Service struct{}
+type Service struct {
+	store Store
+	users Users
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	grants   map[gorsk.AccessRole]map[gorsk.Permission]gorsk.RolePermission
+	loadedAt time.Time
+
+	mmu sync.Mutex
+	// order holds cached users, least recently used first
+	order   *list.List
+	members map[int]*list.Element
+}
+
+type member struct {
+	id       int
+	user     gorsk.User
+	loadedAt time.Time
+}
+
+// Store represents storage of permissions granted to roles
+type Store interface {
+	Permissions() ([]gorsk.RolePermission, error)
+}
+
+// Users represents storage of users permissions are enforced on
+type Users interface {
+	Member(int) (gorsk.User, error)
+}
 
 func checkBool(b bool) error {
 	if b {
@@ -17,13 +61,18 @@ func checkBool(b bool) error {
 }
 
 // User returns user data stored in jwt token
-func (s Service) User(c echo.Context) gorsk.AuthUser {
+func (s *Service) User(c echo.Context) gorsk.AuthUser {
 	id := c.Get("id").(int)
 	companyID := c.Get("company_id").(int)
 	locationID := c.Get("location_id").(int)
 	user := c.Get("username").(string)
 	email := c.Get("email").(string)
 	role := c.Get("role").(gorsk.AccessRole)
+	sessionID, _ := c.Get("session_id").(int)
+	jti, _ := c.Get("jti").(string)
+	tokenExpiresAt, _ := c.Get("token_expires_at").(time.Time)
+	actorID, _ := c.Get("actor_id").(int)
+	emailVerified, _ := c.Get("email_verified").(bool)
 	return gorsk.AuthUser{
 		ID:         id,
 		Username:   user,
@@ -31,69 +80,167 @@ func (s Service) User(c echo.Context) gorsk.AuthUser {
 		LocationID: locationID,
 		Email:      email,
 		Role:       role,
+		SessionID:  sessionID,
+		TokenID:    jti,
+		ActorID:    actorID,
+
+		TokenExpiresAt: tokenExpiresAt,
+		EmailVerified:  emailVerified,
 	}
 }
 
-// EnforceRole authorizes request by AccessRole
-func (s Service) EnforceRole(c echo.Context, r gorsk.AccessRole) error {
-	return checkBool(!(c.Get("role").(gorsk.AccessRole) > r))
+// Enforce checks whether the requesting user's role is granted the permission
+// with scope and roles covering the resource
+func (s *Service) Enforce(c echo.Context, p gorsk.Permission, r gorsk.Resource) error {
+	g, err := s.grant(c.Get("role").(gorsk.AccessRole), p)
+	if err != nil {
+		return err
+	}
+	return checkBool(g.Covers(actor(c), r))
 }
 
-// EnforceUser checks whether the request to change user data is done by the same user
-func (s Service) EnforceUser(c echo.Context, ID int) error {
-	// TODO: Implement querying db and checking the requested user's company_id/location_id
-	// to allow company/location admins to view the user
-	if s.isAdmin(c) {
-		return nil
+// Grant returns the permission granted to the requesting user's role, with an empty scope if not granted
+func (s *Service) Grant(c echo.Context, p gorsk.Permission) (gorsk.RolePermission, error) {
+	return s.grant(c.Get("role").(gorsk.AccessRole), p)
+}
+
+// RoleIncludes checks whether role is granted every permission the other role is granted,
+// in at least as broad scope and on at least the same roles of users
+func (s *Service) RoleIncludes(role, other gorsk.AccessRole) (bool, error) {
+	grants, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	for p, g := range grants[other] {
+		if !grants[role][p].Includes(g) {
+			return false, nil
+		}
 	}
-	return checkBool(c.Get("id").(int) == ID)
+	return true, nil
 }
 
-// EnforceCompany checks whether the request to apply change to company data
-// is done by the user belonging to the that company and that the user has role CompanyAdmin.
-// If user has admin role, the check for company doesnt need to pass.
-func (s Service) EnforceCompany(c echo.Context, ID int) error {
-	if s.isAdmin(c) {
-		return nil
+func (s *Service) grant(role gorsk.AccessRole, p gorsk.Permission) (gorsk.RolePermission, error) {
+	grants, err := s.load()
+	if err != nil {
+		return gorsk.RolePermission{}, err
 	}
-	if err := s.EnforceRole(c, gorsk.CompanyAdminRole); err != nil {
-		return err
+	return grants[role][p], nil
+}
+
+// load returns permissions granted to roles, reloading them from store once cached ones expire
+func (s *Service) load() (map[gorsk.AccessRole]map[gorsk.Permission]gorsk.RolePermission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.grants == nil || (s.ttl > 0 && time.Since(s.loadedAt) > s.ttl) {
+		perms, err := s.store.Permissions()
+		if err != nil {
+			return nil, err
+		}
+		s.grants = make(map[gorsk.AccessRole]map[gorsk.Permission]gorsk.RolePermission)
+		for _, rp := range perms {
+			if s.grants[rp.RoleID] == nil {
+				s.grants[rp.RoleID] = make(map[gorsk.Permission]gorsk.RolePermission)
+			}
+			s.grants[rp.RoleID][rp.Permission] = rp
+		}
+		s.loadedAt = time.Now()
 	}
-	return checkBool(c.Get("company_id").(int) == ID)
+	return s.grants, nil
 }
 
-// EnforceLocation checks whether the request to change location data
-// is done by the user belonging to the requested location
-func (s Service) EnforceLocation(c echo.Context, ID int) error {
-	if s.isCompanyAdmin(c) {
+// EnforceUser checks whether the requesting user is granted the permission on the user with ID.
+// Users granted the permission in any scope can act on their own account. Other users have to be
+// covered by the permission's scope and roles.
+func (s *Service) EnforceUser(c echo.Context, p gorsk.Permission, ID int) error {
+	g, err := s.grant(c.Get("role").(gorsk.AccessRole), p)
+	if err != nil {
+		return err
+	}
+	switch {
+	case g.Scope == "":
+		return echo.ErrForbidden
+	case c.Get("id").(int) == ID:
+		return nil
+	case g.Scope == gorsk.ScopeAny && len(g.Roles) == 0:
 		return nil
+	case g.Scope == gorsk.ScopeOwn:
+		return echo.ErrForbidden
 	}
-	if err := s.EnforceRole(c, gorsk.LocationAdminRole); err != nil {
+
+	u, err := s.member(ID)
+	if err != nil {
 		return err
 	}
-	return checkBool(c.Get("location_id").(int) == ID)
+	return checkBool(g.Covers(actor(c), u.Resource()))
 }
 
-func (s Service) isAdmin(c echo.Context) bool {
-	return !(c.Get("role").(gorsk.AccessRole) > gorsk.AdminRole)
+// Invalidate drops cached company, location and role of the user with ID,
+// so that changes of the user apply to the next check
+func (s *Service) Invalidate(ID int) {
+	s.mmu.Lock()
+	defer s.mmu.Unlock()
+	if e, ok := s.members[ID]; ok {
+		s.remove(e)
+	}
 }
 
-func (s Service) isCompanyAdmin(c echo.Context) bool {
-	// Must query company ID in database for the given user
-	return !(c.Get("role").(gorsk.AccessRole) > gorsk.CompanyAdminRole)
-}
+// member returns the user with id, loading it from users unless it is cached
+func (s *Service) member(id int) (gorsk.User, error) {
+	s.mmu.Lock()
+	if e, ok := s.members[id]; ok {
+		m := e.Value.(member)
+		if s.ttl <= 0 || time.Since(m.loadedAt) <= s.ttl {
+			s.order.MoveToBack(e)
+			s.mmu.Unlock()
+			return m.user, nil
+		}
+		s.remove(e)
+	}
+	s.mmu.Unlock()
 
-// AccountCreate performs auth check when creating a new account
-// Location admin cannot create accounts, needs to be fixed on EnforceLocation function
-func (s Service) AccountCreate(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
-	if err := s.EnforceLocation(c, locationID); err != nil {
-		return err
+	u, err := s.users.Member(id)
+	if err != nil {
+		return gorsk.User{}, err
 	}
-	return s.IsLowerRole(c, roleID)
+
+	s.mmu.Lock()
+	defer s.mmu.Unlock()
+	if e, ok := s.members[id]; ok {
+		s.remove(e)
+	}
+	if s.order.Len() >= MemberCapacity {
+		s.remove(s.order.Front())
+	}
+	s.members[id] = s.order.PushBack(member{id: id, user: u, loadedAt: time.Now()})
+	return u, nil
+}
+
+func (s *Service) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.members, e.Value.(member).id)
+}
+
+// EnforceCompany checks whether the requesting user can change data of the company
+func (s *Service) EnforceCompany(c echo.Context, ID int) error {
+	return s.Enforce(c, gorsk.CompaniesUpdate, gorsk.Resource{CompanyID: ID})
+}
+
+// EnforceLocation checks whether the requesting user can change data of the location
+// in the user's company
+func (s *Service) EnforceLocation(c echo.Context, ID int) error {
+	companyID, _ := c.Get("company_id").(int)
+	return s.Enforce(c, gorsk.LocationsUpdate, gorsk.Resource{CompanyID: companyID, LocationID: ID})
+}
+
+// AccountCreate performs auth check when creating a new account with the role, company and location
+func (s *Service) AccountCreate(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
+	return s.Enforce(c, gorsk.UsersCreate, gorsk.Resource{CompanyID: companyID, LocationID: locationID, RoleID: roleID})
 }
 
-// IsLowerRole checks whether the requesting user has higher role than the user it wants to change
-// Used for account creation/deletion
-func (s Service) IsLowerRole(c echo.Context, r gorsk.AccessRole) error {
-	return checkBool(c.Get("role").(gorsk.AccessRole) < r)
+// actor returns the requesting user permissions are enforced for
+func actor(c echo.Context) gorsk.AuthUser {
+	id, _ := c.Get("id").(int)
+	companyID, _ := c.Get("company_id").(int)
+	locationID, _ := c.Get("location_id").(int)
+	return gorsk.AuthUser{ID: id, CompanyID: companyID, LocationID: locationID}
 }
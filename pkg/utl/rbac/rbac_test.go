@@ -1,7 +1,9 @@
 package rbac_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/ribice/gorsk"
 	"github.com/ribice/gorsk/pkg/utl/mock"
@@ -14,8 +16,8 @@ import (
 
 func TestUser(t *testing.T) {
 	ctx := mock.EchoCtxWithKeys([]string{
-		"id", "company_id", "location_id", "username", "email", "role"},
-		9, 15, 52, "ribice", "ribice@gmail.com", gorsk.SuperAdminRole)
+		"id", "company_id", "location_id", "username", "email", "role", "session_id", "jti", "actor_id"},
+		9, 15, 52, "ribice", "ribice@gmail.com", gorsk.SuperAdminRole, 3, "tokenid", 1)
 	wantUser := gorsk.AuthUser{
 		ID:         9,
 		Username:   "ribice",
@@ -23,76 +25,164 @@ func TestUser(t *testing.T) {
 		LocationID: 52,
 		Email:      "ribice@gmail.com",
 		Role:       gorsk.SuperAdminRole,
+		SessionID:  3,
+		TokenID:    "tokenid",
+		ActorID:    1,
 	}
-	rbacSvc := rbac.Service{}
+	rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), nil, 0)
 	assert.Equal(t, wantUser, rbacSvc.User(ctx))
 }
 
-func TestEnforceRole(t *testing.T) {
-	type args struct {
-		ctx  echo.Context
-		role gorsk.AccessRole
-	}
-	cases := []struct {
-		name    string
-		args    args
-		wantErr bool
-	}{
-		{
-			name:    "Not authorized",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"role"}, gorsk.CompanyAdminRole), role: gorsk.SuperAdminRole},
-			wantErr: true,
-		},
-		{
-			name:    "Authorized",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"role"}, gorsk.SuperAdminRole), role: gorsk.CompanyAdminRole},
-			wantErr: false,
-		},
-	}
-	for _, tt := range cases {
-		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.Service{}
-			res := rbacSvc.EnforceRole(tt.args.ctx, tt.args.role)
-			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
-		})
+type users struct {
+	users map[int]gorsk.User
+	loads int
+}
+
+func (u *users) Member(id int) (gorsk.User, error) {
+	u.loads++
+	usr, ok := u.users[id]
+	if !ok {
+		return gorsk.User{}, gorsk.ErrGeneric
 	}
+	return usr, nil
 }
 
 func TestEnforceUser(t *testing.T) {
+	keys := []string{"id", "company_id", "location_id", "role"}
 	type args struct {
-		ctx echo.Context
-		id  int
+		ctx  echo.Context
+		perm gorsk.Permission
+		id   int
 	}
 	cases := []struct {
-		name    string
-		args    args
-		wantErr bool
+		name      string
+		args      args
+		wantErr   error
+		wantLoads int
 	}{
+		{
+			name:    "Permission not granted",
+			args:    args{ctx: mock.EchoCtxWithKeys(keys, 8, 1, 1, gorsk.UserRole), perm: gorsk.UsersDelete, id: 8},
+			wantErr: echo.ErrForbidden,
+		},
 		{
 			name:    "Not same user, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"id", "role"}, 15, gorsk.LocationAdminRole), id: 122},
-			wantErr: true,
+			args:    args{ctx: mock.EchoCtxWithKeys(keys, 15, 1, 1, gorsk.UserRole), perm: gorsk.UsersUpdate, id: 122},
+			wantErr: echo.ErrForbidden,
 		},
 		{
-			name:    "Not same user, but admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"id", "role"}, 22, gorsk.SuperAdminRole), id: 44},
-			wantErr: false,
+			name: "Not same user, but admin",
+			args: args{ctx: mock.EchoCtxWithKeys(keys, 22, 1, 1, gorsk.SuperAdminRole), perm: gorsk.UsersUpdate, id: 44},
 		},
 		{
-			name:    "Same user",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"id", "role"}, 8, gorsk.AdminRole), id: 8},
-			wantErr: false,
+			name: "Same user",
+			args: args{ctx: mock.EchoCtxWithKeys(keys, 8, 1, 1, gorsk.UserRole), perm: gorsk.UsersUpdate, id: 8},
+		},
+		{
+			name:      "Company admin, user of the company",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 3, 1, 1, gorsk.CompanyAdminRole), perm: gorsk.UsersRead, id: 10},
+			wantLoads: 1,
+		},
+		{
+			name:      "Company admin, user of another company",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 3, 2, 1, gorsk.CompanyAdminRole), perm: gorsk.UsersRead, id: 10},
+			wantErr:   echo.ErrForbidden,
+			wantLoads: 1,
+		},
+		{
+			name:      "Company admin, admin of the company",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 3, 1, 1, gorsk.CompanyAdminRole), perm: gorsk.UsersUpdate, id: 11},
+			wantErr:   echo.ErrForbidden,
+			wantLoads: 1,
+		},
+		{
+			name:      "Super admin, credentials of a user",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 22, 1, 1, gorsk.SuperAdminRole), perm: gorsk.UsersCredentials, id: 10},
+			wantLoads: 1,
+		},
+		{
+			name:      "Super admin, credentials of another super admin",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 22, 1, 1, gorsk.SuperAdminRole), perm: gorsk.UsersCredentials, id: 12},
+			wantErr:   echo.ErrForbidden,
+			wantLoads: 1,
+		},
+		{
+			name:      "Location admin, user of the location",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 4, 1, 2, gorsk.LocationAdminRole), perm: gorsk.UsersUpdate, id: 10},
+			wantLoads: 1,
+		},
+		{
+			name:      "Location admin, user of another location",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 4, 1, 3, gorsk.LocationAdminRole), perm: gorsk.UsersUpdate, id: 10},
+			wantErr:   echo.ErrForbidden,
+			wantLoads: 1,
+		},
+		{
+			name:      "Location admin, user does not exist",
+			args:      args{ctx: mock.EchoCtxWithKeys(keys, 4, 1, 2, gorsk.LocationAdminRole), perm: gorsk.UsersUpdate, id: 99},
+			wantErr:   gorsk.ErrGeneric,
+			wantLoads: 1,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.Service{}
-			res := rbacSvc.EnforceUser(tt.args.ctx, tt.args.id)
-			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
+			u := &users{users: map[int]gorsk.User{
+				10: {Base: gorsk.Base{ID: 10}, RoleID: gorsk.UserRole, CompanyID: 1, LocationID: 2},
+				11: {Base: gorsk.Base{ID: 11}, RoleID: gorsk.AdminRole, CompanyID: 1, LocationID: 1},
+				12: {Base: gorsk.Base{ID: 12}, RoleID: gorsk.SuperAdminRole, CompanyID: 1, LocationID: 1},
+			}}
+			rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), u, 0)
+			err := rbacSvc.EnforceUser(tt.args.ctx, tt.args.perm, tt.args.id)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantLoads, u.loads)
 		})
 	}
 }
 
+func TestEnforceUserCache(t *testing.T) {
+	ctx := mock.EchoCtxWithKeys([]string{"id", "company_id", "location_id", "role"}, 3, 1, 1, gorsk.CompanyAdminRole)
+	u := &users{users: map[int]gorsk.User{
+		10: {Base: gorsk.Base{ID: 10}, RoleID: gorsk.UserRole, CompanyID: 1, LocationID: 2},
+	}}
+
+	rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), u, 0)
+	assert.Nil(t, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, 10))
+
+	u.users[10] = gorsk.User{Base: gorsk.Base{ID: 10}, RoleID: gorsk.UserRole, CompanyID: 2, LocationID: 2}
+	assert.Nil(t, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, 10))
+	assert.Equal(t, 1, u.loads)
+
+	rbacSvc.Invalidate(10)
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, 10))
+	assert.Equal(t, 2, u.loads)
+
+	rbacSvc = rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), u, time.Millisecond)
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, 10))
+	u.users[10] = gorsk.User{Base: gorsk.Base{ID: 10}, RoleID: gorsk.UserRole, CompanyID: 1, LocationID: 2}
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, 10))
+	assert.Equal(t, 4, u.loads)
+}
+
+func TestEnforceUserCacheCapacity(t *testing.T) {
+	ctx := mock.EchoCtxWithKeys([]string{"id", "company_id", "location_id", "role"}, 3, 1, 1, gorsk.CompanyAdminRole)
+	u := &users{users: make(map[int]gorsk.User)}
+	for id := 10; id <= rbac.MemberCapacity+10; id++ {
+		u.users[id] = gorsk.User{Base: gorsk.Base{ID: id}, RoleID: gorsk.UserRole, CompanyID: 1}
+	}
+
+	rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), u, 0)
+	for id := 10; id <= rbac.MemberCapacity+10; id++ {
+		assert.Nil(t, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, id))
+	}
+	assert.Equal(t, rbac.MemberCapacity+1, u.loads)
+
+	assert.Nil(t, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, rbac.MemberCapacity+10))
+	assert.Equal(t, rbac.MemberCapacity+1, u.loads)
+	assert.Nil(t, rbacSvc.EnforceUser(ctx, gorsk.UsersRead, 10))
+	assert.Equal(t, rbac.MemberCapacity+2, u.loads)
+}
+
 func TestEnforceCompany(t *testing.T) {
 	type args struct {
 		ctx echo.Context
@@ -126,7 +216,7 @@ func TestEnforceCompany(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.Service{}
+			rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), nil, 0)
 			res := rbacSvc.EnforceCompany(tt.args.ctx, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -166,7 +256,7 @@ func TestEnforceLocation(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.Service{}
+			rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), nil, 0)
 			res := rbacSvc.EnforceLocation(tt.args.ctx, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
@@ -187,51 +277,258 @@ func TestAccountCreate(t *testing.T) {
 	}{
 		{
 			name:    "Different location, company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.UserRole), roleID: 500, companyID: 7, locationID: 8},
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.UserRole), roleID: gorsk.UserRole, companyID: 7, locationID: 8},
 			wantErr: true,
 		},
 		{
 			name:    "Same location, not company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.UserRole), roleID: 500, companyID: 2, locationID: 8},
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.UserRole), roleID: gorsk.UserRole, companyID: 2, locationID: 8},
 			wantErr: true,
 		},
 		{
-			name:    "Different location, company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: 400, companyID: 2, locationID: 4},
+			name:    "Different location, same company, creating location admin role, company admin",
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: gorsk.LocationAdminRole, companyID: 2, locationID: 4},
 			wantErr: false,
 		},
 		{
-			name:    "Same location, company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: 500, companyID: 2, locationID: 3},
+			name:    "Same location, company, creating user role, company admin",
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: gorsk.UserRole, companyID: 2, locationID: 3},
 			wantErr: false,
 		},
 		{
-			name:    "Same location, company, creating user role, admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: 500, companyID: 2, locationID: 3},
-			wantErr: false,
+			name:    "Same location, company, creating company admin role, company admin",
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: gorsk.CompanyAdminRole, companyID: 2, locationID: 3},
+			wantErr: true,
 		},
 		{
 			name:    "Different everything, admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.AdminRole), roleID: 200, companyID: 7, locationID: 4},
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.AdminRole), roleID: gorsk.UserRole, companyID: 7, locationID: 4},
 			wantErr: false,
 		},
+		{
+			name:    "Creating admin role, admin",
+			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.AdminRole), roleID: gorsk.AdminRole, companyID: 2, locationID: 3},
+			wantErr: true,
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.Service{}
+			rbacSvc := rbac.New(rbac.NewMemory(gorsk.DefaultPermissions), nil, 0)
 			res := rbacSvc.AccountCreate(tt.args.ctx, tt.args.roleID, tt.args.companyID, tt.args.locationID)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
 	}
 }
 
-func TestIsLowerRole(t *testing.T) {
-	ctx := mock.EchoCtxWithKeys([]string{"role"}, gorsk.CompanyAdminRole)
-	rbacSvc := rbac.Service{}
-	if rbacSvc.IsLowerRole(ctx, gorsk.LocationAdminRole) != nil {
-		t.Error("The requested user is higher role than the user requesting it")
+func TestEnforce(t *testing.T) {
+	store := rbac.NewMemory([]gorsk.RolePermission{
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeAny},
+		{RoleID: gorsk.CompanyAdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeCompany},
+		{RoleID: gorsk.LocationAdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeLocation},
+		{RoleID: gorsk.UserRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeOwn},
+	})
+	res := gorsk.Resource{UserID: 5, CompanyID: 1, LocationID: 2}
+	keys := []string{"id", "company_id", "location_id", "role"}
+	cases := []struct {
+		name    string
+		ctx     echo.Context
+		perm    gorsk.Permission
+		wantErr bool
+	}{
+		{
+			name:    "Permission not granted",
+			ctx:     mock.EchoCtxWithKeys(keys, 1, 3, 5, gorsk.AdminRole),
+			perm:    gorsk.UsersCreate,
+			wantErr: true,
+		},
+		{
+			name: "Any scope",
+			ctx:  mock.EchoCtxWithKeys(keys, 1, 3, 5, gorsk.AdminRole),
+			perm: gorsk.UsersDelete,
+		},
+		{
+			name:    "Company scope, another company",
+			ctx:     mock.EchoCtxWithKeys(keys, 1, 3, 2, gorsk.CompanyAdminRole),
+			perm:    gorsk.UsersDelete,
+			wantErr: true,
+		},
+		{
+			name: "Company scope, same company",
+			ctx:  mock.EchoCtxWithKeys(keys, 1, 1, 7, gorsk.CompanyAdminRole),
+			perm: gorsk.UsersDelete,
+		},
+		{
+			name:    "Location scope, another company",
+			ctx:     mock.EchoCtxWithKeys(keys, 1, 3, 2, gorsk.LocationAdminRole),
+			perm:    gorsk.UsersDelete,
+			wantErr: true,
+		},
+		{
+			name:    "Location scope, another location",
+			ctx:     mock.EchoCtxWithKeys(keys, 1, 1, 7, gorsk.LocationAdminRole),
+			perm:    gorsk.UsersDelete,
+			wantErr: true,
+		},
+		{
+			name: "Location scope, same location",
+			ctx:  mock.EchoCtxWithKeys(keys, 1, 1, 2, gorsk.LocationAdminRole),
+			perm: gorsk.UsersDelete,
+		},
+		{
+			name:    "Own scope, another user",
+			ctx:     mock.EchoCtxWithKeys(keys, 1, 1, 2, gorsk.UserRole),
+			perm:    gorsk.UsersDelete,
+			wantErr: true,
+		},
+		{
+			name: "Own scope, same user",
+			ctx:  mock.EchoCtxWithKeys(keys, 5, 1, 2, gorsk.UserRole),
+			perm: gorsk.UsersDelete,
+		},
+	}
+	rbacSvc := rbac.New(store, nil, 0)
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rbacSvc.Enforce(tt.ctx, tt.perm, res)
+			assert.Equal(t, tt.wantErr, err == echo.ErrForbidden)
+		})
+	}
+}
+
+func TestEnforceRoles(t *testing.T) {
+	store := rbac.NewMemory([]gorsk.RolePermission{
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeAny, Roles: []gorsk.AccessRole{gorsk.CompanyAdminRole, gorsk.UserRole}},
+		{RoleID: gorsk.AdminRole, Permission: gorsk.UsersCredentials, Scope: gorsk.ScopeAny},
+	})
+	ctx := mock.EchoCtxWithKeys([]string{"id", "company_id", "location_id", "role"}, 1, 1, 1, gorsk.AdminRole)
+	cases := []struct {
+		name    string
+		perm    gorsk.Permission
+		res     gorsk.Resource
+		wantErr bool
+	}{
+		{
+			name: "User of listed role",
+			perm: gorsk.UsersDelete,
+			res:  gorsk.Resource{UserID: 5, CompanyID: 2, LocationID: 2, RoleID: gorsk.UserRole},
+		},
+		{
+			name:    "User of unlisted role",
+			perm:    gorsk.UsersDelete,
+			res:     gorsk.Resource{UserID: 5, CompanyID: 2, LocationID: 2, RoleID: gorsk.AdminRole},
+			wantErr: true,
+		},
+		{
+			name:    "Own account of unlisted role",
+			perm:    gorsk.UsersDelete,
+			res:     gorsk.Resource{UserID: 1, CompanyID: 1, LocationID: 1, RoleID: gorsk.AdminRole},
+			wantErr: true,
+		},
+		{
+			name: "Resource not belonging to a user",
+			perm: gorsk.UsersDelete,
+			res:  gorsk.Resource{CompanyID: 2},
+		},
+		{
+			name: "No roles listed",
+			perm: gorsk.UsersCredentials,
+			res:  gorsk.Resource{UserID: 5, CompanyID: 2, LocationID: 2, RoleID: gorsk.SuperAdminRole},
+		},
+	}
+	rbacSvc := rbac.New(store, nil, 0)
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rbacSvc.Enforce(ctx, tt.perm, tt.res)
+			assert.Equal(t, tt.wantErr, err == echo.ErrForbidden)
+		})
+	}
+}
+
+func TestRoleIncludes(t *testing.T) {
+	custom := gorsk.AccessRole(250)
+	rbacSvc := rbac.New(rbac.NewMemory(append([]gorsk.RolePermission{
+		{RoleID: custom, Permission: gorsk.UsersDelete, Scope: gorsk.ScopeCompany, Roles: []gorsk.AccessRole{gorsk.AdminRole}},
+	}, gorsk.DefaultPermissions...)), nil, 0)
+	cases := []struct {
+		name  string
+		role  gorsk.AccessRole
+		other gorsk.AccessRole
+		want  bool
+	}{
+		{
+			name:  "Same role",
+			role:  gorsk.CompanyAdminRole,
+			other: gorsk.CompanyAdminRole,
+			want:  true,
+		},
+		{
+			name:  "Narrower scopes and roles",
+			role:  gorsk.CompanyAdminRole,
+			other: gorsk.LocationAdminRole,
+			want:  true,
+		},
+		{
+			name:  "Own scope",
+			role:  gorsk.LocationAdminRole,
+			other: gorsk.UserRole,
+			want:  true,
+		},
+		{
+			name:  "Broader scope",
+			role:  gorsk.CompanyAdminRole,
+			other: gorsk.AdminRole,
+		},
+		{
+			name:  "Roles not acted on",
+			role:  gorsk.AdminRole,
+			other: custom,
+		},
+		{
+			name:  "Permission not granted",
+			role:  gorsk.LocationAdminRole,
+			other: gorsk.CompanyAdminRole,
+		},
 	}
-	if rbacSvc.IsLowerRole(ctx, gorsk.AdminRole) == nil {
-		t.Error("The requested user is lower role than the user requesting it")
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := rbacSvc.RoleIncludes(tt.role, tt.other)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
 	}
 }
+
+type store struct {
+	perms []gorsk.RolePermission
+	err   error
+	loads int
+}
+
+func (s *store) Permissions() ([]gorsk.RolePermission, error) {
+	s.loads++
+	return s.perms, s.err
+}
+
+func TestEnforceCache(t *testing.T) {
+	ctx := mock.EchoCtxWithKeys([]string{"id", "company_id", "location_id", "role"}, 1, 1, 1, gorsk.UserRole)
+
+	st := &store{err: errors.New("db error")}
+	rbacSvc := rbac.New(st, nil, 0)
+	assert.Equal(t, st.err, rbacSvc.Enforce(ctx, gorsk.UsersRead, gorsk.Resource{UserID: 1}))
+
+	st.err = nil
+	st.perms = []gorsk.RolePermission{{RoleID: gorsk.UserRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeOwn}}
+	assert.Nil(t, rbacSvc.Enforce(ctx, gorsk.UsersRead, gorsk.Resource{UserID: 1}))
+
+	st.perms = nil
+	assert.Nil(t, rbacSvc.Enforce(ctx, gorsk.UsersRead, gorsk.Resource{UserID: 1}))
+	assert.Equal(t, 2, st.loads)
+
+	rbacSvc = rbac.New(st, nil, time.Millisecond)
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.Enforce(ctx, gorsk.UsersRead, gorsk.Resource{UserID: 1}))
+
+	st.perms = []gorsk.RolePermission{{RoleID: gorsk.UserRole, Permission: gorsk.UsersRead, Scope: gorsk.ScopeOwn}}
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, rbacSvc.Enforce(ctx, gorsk.UsersRead, gorsk.Resource{UserID: 1}))
+	assert.Equal(t, 4, st.loads)
+}
@@ -0,0 +1,190 @@
+package secure
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ErrInvalidIndex is returned for binary indexes with size not being a multiple of SHA-1 digest size
+var ErrInvalidIndex = errors.New("breached password index is not a list of SHA-1 digests")
+
+// BreachChecker represents corpus of passwords known from data breaches
+type BreachChecker interface {
+	// Breached checks whether the password appears in the corpus
+	Breached(password string) (bool, error)
+}
+
+// HIBPFile looks up passwords in a local file in Have I Been Pwned format, holding
+// a <SHA-1>:<count> line per password, ordered by hash. The file is binary searched on
+// every lookup, and never loaded into memory.
+type HIBPFile struct {
+	f    *os.File
+	size int64
+}
+
+// NewHIBPFile opens HIBP formatted file at path
+func NewHIBPFile(path string) (*HIBPFile, error) {
+	f, size, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &HIBPFile{f: f, size: size}, nil
+}
+
+// Breached checks whether SHA-1 of the password is listed in the file
+func (h *HIBPFile) Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := []byte(hex.EncodeToString(sum[:]))
+
+	// lo is always at the beginning of a line, and the password, if listed, is on a line starting in [lo, hi)
+	lo, hi := int64(0), h.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := h.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		key := line
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			key = line[:i]
+		}
+		switch cmp := bytes.Compare(bytes.ToLower(key), target); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = start
+		}
+	}
+
+	return false, nil
+}
+
+// lineAt returns offset and content, without line ending, of the first line starting at or after off
+func (h *HIBPFile) lineAt(off int64) (int64, []byte, error) {
+	start := off
+	if off > 0 {
+		start--
+	}
+	r := bufio.NewReader(io.NewSectionReader(h.f, start, h.size-start))
+	if off > 0 {
+		skipped, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return h.size, nil, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := r.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	return start, bytes.TrimRight(line, "\r\n"), nil
+}
+
+// Index looks up passwords in a local binary index, being a sorted list of raw 20 byte SHA-1 digests.
+// Index is smaller and faster to search than HIBP formatted file, and is created using BuildIndex.
+type Index struct {
+	f *os.File
+	n int64
+}
+
+// NewIndex opens binary index at path
+func NewIndex(path string) (*Index, error) {
+	f, size, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	if size%sha1.Size != 0 {
+		f.Close()
+		return nil, ErrInvalidIndex
+	}
+	return &Index{f: f, n: size / sha1.Size}, nil
+}
+
+// Breached checks whether SHA-1 of the password is listed in the index
+func (x *Index) Breached(password string) (bool, error) {
+	target := sha1.Sum([]byte(password))
+	var (
+		digest [sha1.Size]byte
+		err    error
+	)
+	i := sort.Search(int(x.n), func(i int) bool {
+		if err != nil {
+			return true
+		}
+		_, err = x.f.ReadAt(digest[:], int64(i)*sha1.Size)
+		return bytes.Compare(digest[:], target[:]) >= 0
+	})
+	if err != nil {
+		return false, err
+	}
+	if int64(i) == x.n {
+		return false, nil
+	}
+	_, err = x.f.ReadAt(digest[:], int64(i)*sha1.Size)
+	return err == nil && digest == target, err
+}
+
+// BuildIndex converts HIBP formatted file, ordered by hash, to binary index
+func BuildIndex(w io.Writer, hibp io.Reader) error {
+	var prev []byte
+	bw := bufio.NewWriter(w)
+	sc := bufio.NewScanner(hibp)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		digest := make([]byte, sha1.Size)
+		if len(line) != hex.EncodedLen(sha1.Size) {
+			return fmt.Errorf("invalid SHA-1 on line %d", n)
+		}
+		if _, err := hex.Decode(digest, line); err != nil {
+			return fmt.Errorf("invalid SHA-1 on line %d: %v", n, err)
+		}
+		if bytes.Compare(prev, digest) >= 0 {
+			return fmt.Errorf("SHA-1 on line %d is not in order", n)
+		}
+		if _, err := bw.Write(digest); err != nil {
+			return err
+		}
+		prev = digest
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func open(path string) (*os.File, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
@@ -0,0 +1,145 @@
+package secure_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ribice/gorsk"
+	"github.com/ribice/gorsk/pkg/utl/secure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var breached = []string{"password", "123456", "qwerty", "letmein", "Thranduil8822", "iloveyou", "dragon"}
+
+func init() {
+	for i := 0; i < 500; i++ {
+		breached = append(breached, fmt.Sprintf("leaked%d", i))
+	}
+}
+
+// hibp returns breached passwords in HIBP format, ordered by hash
+func hibp() string {
+	lines := make([]string, len(breached))
+	for i, p := range breached {
+		lines[i] = fmt.Sprintf("%X:%d", sha1.Sum([]byte(p)), i+1)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testChecker(t *testing.T, c secure.BreachChecker) {
+	for _, p := range breached {
+		ok, err := c.Breached(p)
+		assert.Nil(t, err)
+		assert.True(t, ok, p)
+	}
+	for _, p := range []string{"", "Password", "callmelater", "Thranduil8823", "leaked500"} {
+		ok, err := c.Breached(p)
+		assert.Nil(t, err)
+		assert.False(t, ok, p)
+	}
+}
+
+func TestHIBPFile(t *testing.T) {
+	f, err := secure.NewHIBPFile(writeFile(t, "pwned.txt", []byte(hibp())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testChecker(t, f)
+
+	empty, err := secure.NewHIBPFile(writeFile(t, "empty.txt", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := empty.Breached("password")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = secure.NewHIBPFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestIndex(t *testing.T) {
+	var buf bytes.Buffer
+	if err := secure.BuildIndex(&buf, strings.NewReader(hibp())); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(breached)*sha1.Size, buf.Len())
+
+	x, err := secure.NewIndex(writeFile(t, "pwned.idx", buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testChecker(t, x)
+
+	_, err = secure.NewIndex(writeFile(t, "invalid.idx", buf.Bytes()[1:]))
+	assert.Equal(t, secure.ErrInvalidIndex, err)
+}
+
+func TestBuildIndex(t *testing.T) {
+	cases := []struct {
+		name    string
+		hibp    string
+		wantErr bool
+	}{
+		{
+			name:    "Fail on invalid hash",
+			hibp:    "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD:3\n",
+			wantErr: true,
+		},
+		{
+			name:    "Fail on unordered hashes",
+			hibp:    "7C4A8D09CA3762AF61E59520943DC26494F8941B:2\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3\n",
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			hibp: "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3\n7c4a8d09ca3762af61e59520943dc26494f8941b\n\n",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := secure.BuildIndex(&buf, strings.NewReader(tt.hibp))
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, 2*sha1.Size, buf.Len())
+			}
+		})
+	}
+}
+
+func TestBreached(t *testing.T) {
+	ok, err := secure.New(1).Breached("password")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	f, err := secure.NewHIBPFile(writeFile(t, "pwned.txt", []byte(hibp())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := secure.NewWithHasher(secure.DefaultPolicy(1), secure.DefaultArgon2id(), f)
+	ok, err = s.Breached("password")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	assert.Nil(t, secure.New(1).Password("Thranduil8822"))
+	assert.Equal(t, gorsk.ErrBreachedPassword, s.Password("Thranduil8822"))
+	assert.Nil(t, s.Password("Thranduil8823"))
+}
@@ -0,0 +1,155 @@
+package secure
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Custom errors
+var (
+	ErrInvalidHash = errors.New("hash is not in the expected format")
+)
+
+// Hasher represents password hashing algorithm producing PHC formatted hashes
+type Hasher interface {
+	// Hash hashes the password with a random salt
+	Hash(password string) (string, error)
+	// Matches checks whether the hash, produced by the algorithm with any parameters, matches the password
+	Matches(hash, password string) bool
+	// NeedsRehash checks whether the hash was produced by another algorithm or with different parameters
+	NeedsRehash(hash string) bool
+}
+
+// Default argon2id parameters, as recommended by RFC 9106
+const (
+	DefaultArgon2Memory      = 64 * 1024
+	DefaultArgon2Iterations  = 3
+	DefaultArgon2Parallelism = 4
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	argon2SaltLen  = 16
+	argon2KeyLen   = 32
+)
+
+var b64 = base64.RawStdEncoding
+
+// Argon2id hashes passwords using argon2id.
+// Hashes are formatted as $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2id struct {
+	// Memory is the amount of memory used, in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2id returns argon2id hasher with default parameters
+func DefaultArgon2id() Argon2id {
+	return Argon2id{
+		Memory:      DefaultArgon2Memory,
+		Iterations:  DefaultArgon2Iterations,
+		Parallelism: DefaultArgon2Parallelism,
+	}
+}
+
+// Hash hashes the password using argon2id
+func (a Argon2id) Hash(password string) (string, error) {
+	if a.Memory == 0 || a.Iterations == 0 || a.Parallelism == 0 {
+		return "", fmt.Errorf("invalid argon2id parameters m=%d, t=%d, p=%d", a.Memory, a.Iterations, a.Parallelism)
+	}
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		a.Memory, a.Iterations, a.Parallelism, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Matches checks whether argon2id hash matches the password, using parameters held in the hash
+func (a Argon2id) Matches(hash, password string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// NeedsRehash checks whether the hash is not an argon2id hash with the hasher's parameters
+func (a Argon2id) NeedsRehash(hash string) bool {
+	params, _, key, err := decodeArgon2id(hash)
+	return err != nil || params != a || len(key) != argon2KeyLen
+}
+
+// decodeArgon2id parses parameters, salt and key of argon2id hash
+func decodeArgon2id(hash string) (Argon2id, []byte, []byte, error) {
+	var a Argon2id
+	parts := strings.Split(strings.TrimPrefix(hash, argon2idPrefix), "$")
+	if !strings.HasPrefix(hash, argon2idPrefix) || len(parts) != 4 {
+		return a, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return a, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &a.Memory, &a.Iterations, &a.Parallelism); err != nil {
+		return a, nil, nil, ErrInvalidHash
+	}
+	if a.Memory == 0 || a.Iterations == 0 || a.Parallelism == 0 {
+		return a, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := b64.DecodeString(parts[2])
+	if err != nil || len(salt) == 0 {
+		return a, nil, nil, ErrInvalidHash
+	}
+	key, err := b64.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return a, nil, nil, ErrInvalidHash
+	}
+	return a, salt, key, nil
+}
+
+// Bcrypt hashes passwords using bcrypt, formatted as $2a$<cost>$<salt and key>
+type Bcrypt struct {
+	Cost int
+}
+
+// Hash hashes the password using bcrypt
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(hash), err
+}
+
+// Matches checks whether bcrypt hash matches the password
+func (Bcrypt) Matches(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash checks whether the hash is not a bcrypt hash with the hasher's cost
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
+
+// hasherFor returns hasher able to verify the hash, based on its algorithm identifier
+func hasherFor(hash string) Hasher {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return Argon2id{}
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return Bcrypt{}
+	default:
+		return nil
+	}
+}
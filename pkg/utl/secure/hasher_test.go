@@ -0,0 +1,63 @@
+package secure_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ribice/gorsk/pkg/utl/secure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgon2id(t *testing.T) {
+	hasher := secure.Argon2id{Memory: 1024, Iterations: 2, Parallelism: 1}
+	hash, err := hasher.Hash("gamepad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=2,p=1$"))
+	assert.True(t, hasher.Matches(hash, "gamepad"))
+	assert.False(t, hasher.Matches(hash, "gamepads"))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	other, err := hasher.Hash("gamepad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, hash, other, "hashes must be salted")
+
+	assert.True(t, secure.Argon2id{Memory: 1024, Iterations: 3, Parallelism: 1}.NeedsRehash(hash))
+	assert.True(t, secure.Argon2id{Memory: 2048, Iterations: 2, Parallelism: 1}.Matches(hash, "gamepad"),
+		"parameters are read from the hash")
+
+	_, err = secure.Argon2id{}.Hash("gamepad")
+	assert.NotNil(t, err)
+}
+
+func TestArgon2idMalformed(t *testing.T) {
+	hasher := secure.DefaultArgon2id()
+	cases := []string{
+		"",
+		"$argon2i$v=19$m=1024,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=18$m=1024,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=0,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=1024,t=2,p=1$c2FsdHNhbHQ",
+		"$argon2id$v=19$m=1024,t=2,p=1$!!$a2V5",
+	}
+	for _, hash := range cases {
+		assert.False(t, hasher.Matches(hash, "gamepad"), hash)
+		assert.True(t, hasher.NeedsRehash(hash), hash)
+	}
+}
+
+func TestBcrypt(t *testing.T) {
+	hasher := secure.Bcrypt{Cost: 4}
+	hash, err := hasher.Hash("gamepad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, hasher.Matches(hash, "gamepad"))
+	assert.False(t, hasher.Matches(hash, "gamepads"))
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, secure.Bcrypt{Cost: 5}.NeedsRehash(hash))
+	assert.True(t, hasher.NeedsRehash("$argon2id$v=19$m=1024,t=2,p=1$c2FsdHNhbHQ$a2V5"))
+}
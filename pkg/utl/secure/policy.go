@@ -0,0 +1,264 @@
+package secure
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/labstack/echo"
+	"github.com/nbutton23/zxcvbn-go"
+	"github.com/nbutton23/zxcvbn-go/scoring"
+)
+
+// Password rules, identifying reasons of rejecting a password
+const (
+	RuleLength           = "length"
+	RuleCharacterClasses = "character_classes"
+	RuleScore            = "score"
+	RuleSimilarity       = "similarity"
+	RuleDictionary       = "dictionary"
+	// RuleBreached is reported for passwords known from data breaches
+	RuleBreached = "breached"
+)
+
+// DefaultMinLength is minimum password length of default policy
+const DefaultMinLength = 8
+
+// Reason explains why a password does not satisfy a rule
+type Reason struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Feedback is the result of checking a password against policy.
+// Score, Warning and Suggestions come from zxcvbn, and help choosing a stronger password.
+type Feedback struct {
+	Valid       bool     `json:"valid"`
+	Score       int      `json:"score"`
+	Reasons     []Reason `json:"reasons,omitempty"`
+	Warning     string   `json:"warning,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// PasswordError is the body of error returned for passwords not satisfying policy
+type PasswordError struct {
+	Message string `json:"message"`
+	Feedback
+}
+
+// Err returns nil for valid passwords, and otherwise bad request error holding the feedback
+func (f Feedback) Err() error {
+	if f.Valid {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, PasswordError{Message: "insecure password", Feedback: f})
+}
+
+// Candidate is a password being checked, along with user's attributes and zxcvbn estimate of its strength
+type Candidate struct {
+	Password string
+	Inputs   []string
+	Strength scoring.MinEntropyMatch
+}
+
+// Rule represents a single password requirement
+type Rule interface {
+	// Check returns reason of rejecting the password, or nil if it satisfies the rule
+	Check(Candidate) *Reason
+}
+
+// RuleFunc is an adapter allowing use of ordinary functions as rules
+type RuleFunc func(Candidate) *Reason
+
+// Check calls f(c)
+func (f RuleFunc) Check(c Candidate) *Reason {
+	return f(c)
+}
+
+// Policy combines password rules. A password is valid if it satisfies all of them.
+type Policy struct {
+	rules []Rule
+}
+
+// NewPolicy creates password policy out of given rules
+func NewPolicy(rules ...Rule) Policy {
+	return Policy{rules: rules}
+}
+
+// DefaultPolicy requires passwords of DefaultMinLength characters with zxcvbn score of at least minScore
+func DefaultPolicy(minScore int) Policy {
+	return NewPolicy(MinLength(DefaultMinLength), MinScore(minScore))
+}
+
+// Check checks the password against all rules. Inputs are user's attributes, such as name or email,
+// which make passwords containing them easier to guess.
+func (p Policy) Check(pass string, inputs ...string) Feedback {
+	c := Candidate{Password: pass, Inputs: inputs, Strength: zxcvbn.PasswordStrength(pass, inputs)}
+	f := Feedback{Score: c.Strength.Score}
+	for _, r := range p.rules {
+		if reason := r.Check(c); reason != nil {
+			f.Reasons = append(f.Reasons, *reason)
+		}
+	}
+	f.Valid = len(f.Reasons) == 0
+	f.Warning, f.Suggestions = suggest(c.Strength)
+	return f
+}
+
+// MinLength requires passwords of at least n characters
+func MinLength(n int) Rule {
+	return RuleFunc(func(c Candidate) *Reason {
+		if utf8.RuneCountInString(c.Password) >= n {
+			return nil
+		}
+		return &Reason{Rule: RuleLength, Message: fmt.Sprintf("Password must be at least %d characters long", n)}
+	})
+}
+
+// CharacterClasses requires passwords to contain characters of at least n classes,
+// out of lowercase letters, uppercase letters, digits and symbols
+func CharacterClasses(n int) Rule {
+	return RuleFunc(func(c Candidate) *Reason {
+		var lower, upper, digit, symbol int
+		for _, r := range c.Password {
+			switch {
+			case unicode.IsLower(r):
+				lower = 1
+			case unicode.IsUpper(r):
+				upper = 1
+			case unicode.IsDigit(r):
+				digit = 1
+			default:
+				symbol = 1
+			}
+		}
+		if lower+upper+digit+symbol >= n {
+			return nil
+		}
+		return &Reason{Rule: RuleCharacterClasses, Message: fmt.Sprintf(
+			"Password must contain at least %d of: lowercase letters, uppercase letters, digits and symbols", n)}
+	})
+}
+
+// MinScore requires passwords with zxcvbn strength score, ranging from 0 to 4, of at least n
+func MinScore(n int) Rule {
+	return RuleFunc(func(c Candidate) *Reason {
+		if c.Strength.Score >= n {
+			return nil
+		}
+		return &Reason{Rule: RuleScore, Message: "Password is too easy to guess"}
+	})
+}
+
+// minSimilarLength is length of the shortest user's attribute considered when checking similarity
+const minSimilarLength = 3
+
+// NotSimilar rejects passwords containing user's attributes, such as name, username or email address,
+// or its local part, as well as passwords being part of them
+func NotSimilar() Rule {
+	return RuleFunc(func(c Candidate) *Reason {
+		pass := strings.ToLower(c.Password)
+		for _, input := range c.Inputs {
+			input = strings.ToLower(input)
+			attrs := []string{input}
+			if i := strings.LastIndex(input, "@"); i > 0 {
+				attrs = append(attrs, input[:i])
+			}
+			for _, attr := range attrs {
+				if len(attr) < minSimilarLength {
+					continue
+				}
+				if strings.Contains(pass, attr) || (len(pass) >= minSimilarLength && strings.Contains(attr, pass)) {
+					return &Reason{Rule: RuleSimilarity, Message: "Password must not contain your name, username or email address"}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+var leet = strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+// Dictionary rejects passwords containing any of the words, such as company or product names.
+// Words are matched case insensitively, and regardless of common character substitutions.
+func Dictionary(words ...string) Rule {
+	dict := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			dict = append(dict, w)
+		}
+	}
+	return RuleFunc(func(c Candidate) *Reason {
+		pass := strings.ToLower(c.Password)
+		unleet := leet.Replace(pass)
+		for _, w := range dict {
+			if strings.Contains(pass, w) || strings.Contains(unleet, w) {
+				return &Reason{Rule: RuleDictionary, Message: "Password must not contain commonly used or forbidden words"}
+			}
+		}
+		return nil
+	})
+}
+
+// leetSuffix is appended by zxcvbn to names of dictionaries matched after undoing character substitutions
+const leetSuffix = "_3117"
+
+// suggest returns warning and suggestions for improving the password, based on patterns zxcvbn found in it
+func suggest(s scoring.MinEntropyMatch) (string, []string) {
+	if s.Score > 2 {
+		return "", nil
+	}
+
+	var (
+		warning     string
+		suggestions = []string{"Add another word or two. Uncommon words are better."}
+		seen        = make(map[string]bool)
+	)
+	add := func(w string, sug ...string) {
+		if warning == "" {
+			warning = w
+		}
+		for _, s := range sug {
+			if !seen[s] {
+				seen[s] = true
+				suggestions = append(suggestions, s)
+			}
+		}
+	}
+
+	for _, m := range s.MatchSequence {
+		switch m.Pattern {
+		case "dictionary":
+			if strings.HasSuffix(m.DictionaryName, leetSuffix) {
+				add("", "Predictable substitutions like '@' instead of 'a' don't help very much")
+			}
+			switch strings.TrimSuffix(m.DictionaryName, leetSuffix) {
+			case "Passwords":
+				add("This is similar to a commonly used password")
+			case "English":
+				add("A word by itself is easy to guess")
+			case "MaleNames", "FemaleNames", "Surname":
+				add("Names and surnames by themselves are easy to guess")
+			case "user_inputs":
+				add("Passwords containing your personal information are easy to guess")
+			}
+			if strings.ToUpper(m.Token) == m.Token && strings.ToLower(m.Token) != m.Token {
+				add("", "All-uppercase is almost as easy to guess as all-lowercase")
+			} else if len(m.Token) > 0 && unicode.IsUpper(rune(m.Token[0])) {
+				add("", "Capitalization doesn't help very much")
+			}
+		case "spatial":
+			add("Short keyboard patterns are easy to guess", "Use a longer keyboard pattern with more turns")
+		case "repeat":
+			add(`Repeats like "aaa" or "abcabc" are easy to guess`, "Avoid repeated words and characters")
+		case "sequence":
+			add("Sequences like abc or 6543 are easy to guess", "Avoid sequences")
+		case "date":
+			add("Dates are often easy to guess", "Avoid dates and years that are associated with you")
+		}
+	}
+
+	return warning, suggestions
+}
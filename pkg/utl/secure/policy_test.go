@@ -0,0 +1,139 @@
+package secure_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+
+	"github.com/ribice/gorsk/pkg/utl/secure"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	inputs := []string{"John", "Doe", "johndoe", "jdoe@mail.com"}
+	cases := []struct {
+		name        string
+		policy      secure.Policy
+		pass        string
+		wantRules   []string
+		wantWarning string
+	}{
+		{
+			name:      "Fail on length",
+			policy:    secure.NewPolicy(secure.MinLength(8)),
+			pass:      "Th8822!",
+			wantRules: []string{secure.RuleLength},
+		},
+		{
+			name:      "Length is counted in characters",
+			policy:    secure.NewPolicy(secure.MinLength(8)),
+			pass:      "ćevapčić",
+			wantRules: nil,
+		},
+		{
+			name:      "Fail on character classes",
+			policy:    secure.NewPolicy(secure.CharacterClasses(3)),
+			pass:      "thranduil8822",
+			wantRules: []string{secure.RuleCharacterClasses},
+		},
+		{
+			name:      "Character classes",
+			policy:    secure.NewPolicy(secure.CharacterClasses(4)),
+			pass:      "Thranduil 8822",
+			wantRules: nil,
+		},
+		{
+			name:        "Fail on score",
+			policy:      secure.NewPolicy(secure.MinScore(3)),
+			pass:        "qwertyuiop",
+			wantRules:   []string{secure.RuleScore},
+			wantWarning: "This is similar to a commonly used password",
+		},
+		{
+			name:      "Fail on password containing user's name",
+			policy:    secure.NewPolicy(secure.NotSimilar()),
+			pass:      "Thranduil-JohnDoe",
+			wantRules: []string{secure.RuleSimilarity},
+		},
+		{
+			name:      "Fail on password containing email's local part",
+			policy:    secure.NewPolicy(secure.NotSimilar()),
+			pass:      "Thranduil8822jdoe",
+			wantRules: []string{secure.RuleSimilarity},
+		},
+		{
+			name:      "Fail on password being part of user's attribute",
+			policy:    secure.NewPolicy(secure.NotSimilar()),
+			pass:      "doe@mail",
+			wantRules: []string{secure.RuleSimilarity},
+		},
+		{
+			name:      "Fail on dictionary word",
+			policy:    secure.NewPolicy(secure.Dictionary("gorsk", " ")),
+			pass:      "Thranduil-GORSK",
+			wantRules: []string{secure.RuleDictionary},
+		},
+		{
+			name:      "Fail on dictionary word with substitutions",
+			policy:    secure.NewPolicy(secure.Dictionary("gorsk")),
+			pass:      "Thranduil-g0r$k",
+			wantRules: []string{secure.RuleDictionary},
+		},
+		{
+			name: "Fail on multiple rules",
+			policy: secure.NewPolicy(secure.MinLength(8), secure.MinScore(3), secure.CharacterClasses(3),
+				secure.NotSimilar(), secure.Dictionary("gorsk")),
+			pass:        "johndoe",
+			wantRules:   []string{secure.RuleLength, secure.RuleScore, secure.RuleCharacterClasses, secure.RuleSimilarity},
+			wantWarning: "Passwords containing your personal information are easy to guess",
+		},
+		{
+			name: "Success",
+			policy: secure.NewPolicy(secure.MinLength(8), secure.MinScore(3), secure.CharacterClasses(3),
+				secure.NotSimilar(), secure.Dictionary("gorsk")),
+			pass: "Thranduil8822",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.policy.Check(tt.pass, inputs...)
+			var rules []string
+			for _, r := range f.Reasons {
+				assert.NotEmpty(t, r.Message)
+				rules = append(rules, r.Rule)
+			}
+			assert.Equal(t, tt.wantRules, rules)
+			assert.Equal(t, tt.wantRules == nil, f.Valid)
+			if tt.wantWarning != "" {
+				assert.Equal(t, tt.wantWarning, f.Warning)
+				assert.NotEmpty(t, f.Suggestions)
+			}
+		})
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	p := secure.DefaultPolicy(3)
+	assert.True(t, p.Check("Thranduil8822").Valid)
+	assert.False(t, p.Check("z7#Kq").Valid)
+	assert.False(t, p.Check("password1").Valid)
+}
+
+func TestFeedbackErr(t *testing.T) {
+	assert.Nil(t, secure.Feedback{Valid: true, Score: 4}.Err())
+
+	f := secure.Feedback{
+		Score:       0,
+		Reasons:     []secure.Reason{{Rule: secure.RuleScore, Message: "Password is too easy to guess"}},
+		Warning:     "This is similar to a commonly used password",
+		Suggestions: []string{"Add another word or two. Uncommon words are better."},
+	}
+	err, ok := f.Err().(*echo.HTTPError)
+	if !ok {
+		t.Fatal("expected HTTP error")
+	}
+	assert.Equal(t, http.StatusBadRequest, err.Code)
+	assert.Equal(t, secure.PasswordError{Message: "insecure password", Feedback: f}, err.Message)
+}
@@ -1,46 +1,91 @@
 package secure
 
 import (
-	"fmt"
-	"hash"
-	"strconv"
-	"time"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 
-	"github.com/nbutton23/zxcvbn-go"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/ribice/gorsk"
 )
 
-// New initializes security service
-func New(minPWStr int, h hash.Hash) *Service {
-	return &Service{minPWStr: minPWStr, h: h}
+// New initializes security service with default password policy, hashing passwords using argon2id with default parameters
+func New(minPWStr int) *Service {
+	return NewWithHasher(DefaultPolicy(minPWStr), DefaultArgon2id(), nil)
+}
+
+// NewWithHasher initializes security service checking passwords against policy and hashing them using given hasher.
+// Passwords are screened against breached passwords corpus unless it is nil.
+func NewWithHasher(policy Policy, hasher Hasher, breaches BreachChecker) *Service {
+	return &Service{policy: policy, hasher: hasher, breaches: breaches}
 }
 
 // Service holds security related methods
 type Service struct {
-	minPWStr int
-	h        hash.Hash
+	policy   Policy
+	hasher   Hasher
+	breaches BreachChecker
+}
+
+// Password checks whether password satisfies password policy and is not known from data breaches,
+// returning error explaining why it can not be used
+func (s *Service) Password(pass string, inputs ...string) error {
+	if err := s.policy.Check(pass, inputs...).Err(); err != nil {
+		return err
+	}
+	breached, err := s.Breached(pass)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return gorsk.ErrBreachedPassword
+	}
+	return nil
+}
+
+// CheckPassword checks password against password policy, returning feedback on how to improve it
+func (s *Service) CheckPassword(pass string, inputs ...string) Feedback {
+	return s.policy.Check(pass, inputs...)
 }
 
-// Password checks whether password is secure enough using zxcvbn library
-func (s *Service) Password(pass string, inputs ...string) bool {
-	pwStrength := zxcvbn.PasswordStrength(pass, inputs)
-	return pwStrength.Score >= s.minPWStr
+// Breached checks whether password is known from data breaches.
+// It always returns false if breached passwords corpus is not configured.
+func (s *Service) Breached(pass string) (bool, error) {
+	if s.breaches == nil {
+		return false, nil
+	}
+	return s.breaches.Breached(pass)
 }
 
-// Hash hashes the password using bcrypt
-func (*Service) Hash(password string) string {
-	hashedPW, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedPW)
+// Hash hashes the password using configured hasher
+func (s *Service) Hash(password string) (string, error) {
+	return s.hasher.Hash(password)
 }
 
 // HashMatchesPassword matches hash with password. Returns true if hash and password match.
+// Hashes of all supported algorithms are accepted, regardless of configured hasher.
 func (*Service) HashMatchesPassword(hash, password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	h := hasherFor(hash)
+	return h != nil && h.Matches(hash, password)
+}
+
+// NeedsRehash checks whether the hash has to be replaced, as it was not produced
+// by configured hasher with its current parameters
+func (s *Service) NeedsRehash(hash string) bool {
+	return s.hasher.NeedsRehash(hash)
+}
+
+// NewToken generates random, hex encoded token, suitable for secrets sent to users
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
-// Token generates new unique token
-func (s *Service) Token(str string) string {
-	s.h.Reset()
-	fmt.Fprintf(s.h, "%s%s", str, strconv.Itoa(time.Now().Nanosecond()))
-	return fmt.Sprintf("%x", s.h.Sum(nil))
+// HashToken returns hex encoded SHA-256 of the token. Only hashes of tokens are stored,
+// so leaked database does not disclose valid tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -1,11 +1,11 @@
 package secure_test
 
 import (
-	"crypto/sha1"
 	"testing"
 
 	"github.com/ribice/gorsk/pkg/utl/secure"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestPassword(t *testing.T) {
@@ -35,37 +35,78 @@ func TestPassword(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := secure.New(1, nil)
-			got := s.Password(tt.pass, tt.inputs...)
-			assert.Equal(t, tt.want, got)
+			s := secure.New(1)
+			err := s.Password(tt.pass, tt.inputs...)
+			assert.Equal(t, tt.want, err == nil)
 		})
 	}
 }
 
 func TestHashAndMatch(t *testing.T) {
+	bcryptHash, err := secure.Bcrypt{Cost: bcrypt.MinCost}.Hash("gamepad")
+	if err != nil {
+		t.Fatal(err)
+	}
 	cases := []struct {
-		name string
-		pass string
-		want bool
+		name        string
+		hash        string
+		pass        string
+		want        bool
+		wantRehash  bool
+		hashDefault bool
 	}{
 		{
-			name: "Success",
-			pass: "gamepad",
-			want: true,
+			name:        "Success",
+			pass:        "gamepad",
+			hashDefault: true,
+			want:        true,
+		},
+		{
+			name:        "Wrong password",
+			pass:        "gamepads",
+			hashDefault: true,
+		},
+		{
+			name:       "Legacy bcrypt hash",
+			hash:       bcryptHash,
+			pass:       "gamepad",
+			want:       true,
+			wantRehash: true,
+		},
+		{
+			name:       "Unknown algorithm",
+			hash:       "gamepad",
+			pass:       "gamepad",
+			wantRehash: true,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := secure.New(1, nil)
-			hash := s.Hash(tt.pass)
+			s := secure.NewWithHasher(secure.DefaultPolicy(1), secure.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1}, nil)
+			hash := tt.hash
+			if tt.hashDefault {
+				var err error
+				if hash, err = s.Hash("gamepad"); err != nil {
+					t.Fatal(err)
+				}
+			}
 			assert.Equal(t, tt.want, s.HashMatchesPassword(hash, tt.pass))
+			assert.Equal(t, tt.wantRehash, s.NeedsRehash(hash))
 		})
 	}
 }
 
-func TestToken(t *testing.T) {
-	s := secure.New(1, sha1.New())
-	token := "token"
-	tokenized := s.Token(token)
-	assert.NotEqual(t, tokenized, token)
+func TestNewToken(t *testing.T) {
+	a, err := secure.NewToken()
+	assert.Nil(t, err)
+	b, err := secure.NewToken()
+	assert.Nil(t, err)
+	assert.Len(t, a, 64)
+	assert.NotEqual(t, a, b)
+}
+
+func TestHashToken(t *testing.T) {
+	hash := secure.HashToken("token")
+	assert.Equal(t, "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0", hash)
+	assert.NotEqual(t, hash, secure.HashToken("token2"))
 }
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+const clientIPKey = "client_ip"
+
+// Client returns user agent and IP address the request originates from.
+// The address is the one of the connection's peer, unless the request came through a proxy trusted by TrustProxies.
+func Client(c echo.Context) (string, string) {
+	if c == nil || c.Request() == nil {
+		return "", ""
+	}
+	if ip, ok := c.Get(clientIPKey).(string); ok {
+		return c.Request().UserAgent(), ip
+	}
+	return c.Request().UserAgent(), remoteIP(c.Request())
+}
+
+// TrustProxies returns middleware resolving client IP address from X-Forwarded-For and X-Real-IP headers,
+// honoured only on requests sent by the given proxies. Proxies are IP addresses or CIDR ranges.
+func TrustProxies(proxies ...string) (echo.MiddlewareFunc, error) {
+	var nets []*net.IPNet
+	for _, p := range proxies {
+		cidr := p
+		if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+			cidr += "/32"
+		} else if ip != nil {
+			cidr += "/128"
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", p, err)
+		}
+		nets = append(nets, n)
+	}
+	trusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(clientIPKey, forwardedIP(c.Request(), trusted))
+			return next(c)
+		}
+	}, nil
+}
+
+// forwardedIP walks the proxy chain from the connection's peer backwards, stopping at the first untrusted address
+func forwardedIP(r *http.Request, trusted func(string) bool) string {
+	ip := remoteIP(r)
+	if !trusted(ip) {
+		return ip
+	}
+	if xff := r.Header.Get(echo.HeaderXForwardedFor); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				return ip
+			}
+			ip = hop
+			if !trusted(ip) {
+				return ip
+			}
+		}
+		return ip
+	}
+	if xri := strings.TrimSpace(r.Header.Get(echo.HeaderXRealIP)); net.ParseIP(xri) != nil {
+		return xri
+	}
+	return ip
+}
+
+// remoteIP returns IP address of the connection's peer
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
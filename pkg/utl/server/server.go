@@ -14,11 +14,11 @@ import (
 	"github.com/labstack/echo"
 )
 
-// New instantates new Echo server
-func New() *echo.Echo {
+// New instantates new Echo server. Requests with credentials are allowed only from the given origins.
+func New(origins ...string) *echo.Echo {
 	e := echo.New()
 	e.Use(middleware.Logger(), middleware.Recover(),
-		secure.CORS(), secure.Headers())
+		secure.CORS(origins...), secure.Headers())
 	e.GET("/", healthCheck)
 	e.Validator = &CustomValidator{V: validator.New()}
 	custErr := &customErrHandler{e: e}
@@ -57,7 +57,7 @@ func Start(e *echo.Echo, cfg *Config) {
 
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 10 seconds.
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
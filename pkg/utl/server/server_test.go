@@ -1,8 +1,12 @@
 package server_test
 
 import (
+	"net/http/httptest"
 	"testing"
 
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
 	"github.com/ribice/gorsk/pkg/utl/server"
 )
 
@@ -13,3 +17,84 @@ func TestNew(t *testing.T) {
 		t.Errorf("Server should not be nil")
 	}
 }
+
+func TestClient(t *testing.T) {
+	cases := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		xff        string
+		xRealIP    string
+		wantIP     string
+	}{
+		{
+			name:       "Peer address without trusted proxies",
+			remoteAddr: "192.0.2.1:1234",
+			xff:        "10.0.0.1",
+			xRealIP:    "10.0.0.1",
+			wantIP:     "192.0.2.1",
+		},
+		{
+			name:       "Headers from untrusted peer are ignored",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "192.0.2.1:1234",
+			xff:        "198.51.100.1",
+			xRealIP:    "198.51.100.1",
+			wantIP:     "192.0.2.1",
+		},
+		{
+			name:       "X-Forwarded-For from trusted proxy",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:1234",
+			xff:        "198.51.100.1",
+			wantIP:     "198.51.100.1",
+		},
+		{
+			name:       "Spoofed X-Forwarded-For entries before trusted chain are ignored",
+			proxies:    []string{"10.0.0.0/8", "192.0.2.5"},
+			remoteAddr: "10.0.0.2:1234",
+			xff:        "203.0.113.9, 198.51.100.1, 192.0.2.5",
+			wantIP:     "198.51.100.1",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			proxies:    []string{"10.0.0.2"},
+			remoteAddr: "10.0.0.2:1234",
+			xRealIP:    "198.51.100.1",
+			wantIP:     "198.51.100.1",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.GET, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("User-Agent", "Mozilla/5.0")
+			if tt.xff != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.xff)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tt.xRealIP)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			next := func(echo.Context) error { return nil }
+			if tt.proxies != nil {
+				mw, err := server.TrustProxies(tt.proxies...)
+				assert.Nil(t, err)
+				next = mw(next)
+			}
+			assert.Nil(t, next(c))
+			ua, ip := server.Client(c)
+			assert.Equal(t, "Mozilla/5.0", ua)
+			assert.Equal(t, tt.wantIP, ip)
+		})
+	}
+
+	ua, ip := server.Client(nil)
+	assert.Equal(t, "", ua)
+	assert.Equal(t, "", ip)
+}
+
+func TestTrustProxiesInvalid(t *testing.T) {
+	_, err := server.TrustProxies("proxy.local")
+	assert.NotNil(t, err)
+}
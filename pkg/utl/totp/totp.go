@@ -0,0 +1,124 @@
+// Package totp implements RFC 6238 time-based one-time passwords and recovery codes
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the number of digits in a generated code
+	Digits = 6
+	// Period is the time step a code is valid for
+	Period = 30 * time.Second
+	// Skew is the number of time steps before and after the current one accepted on validation
+	Skew = 1
+
+	secretSize        = 20
+	recoveryCodeSize  = 5
+	recoveryCodeCount = 10
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates a new random base32 encoded shared secret
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns otpauth URI used for provisioning authenticator apps, usually encoded as QR code
+func URI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(int(Period/time.Second)))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// Code returns the code for given secret valid at time t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	return code(key, uint64(Step(t))), nil
+}
+
+// Validate checks whether code is valid for given secret at time t, allowing for clock skew
+func Validate(secret, code string, t time.Time) bool {
+	_, ok := Match(secret, code, t, 0)
+	return ok
+}
+
+// Match checks whether code is valid for given secret at time t, allowing for clock skew, and returns
+// the time step of the code. Codes of steps up to last, the step of the last accepted code, are rejected,
+// so a code cannot be replayed while it is still valid.
+func Match(secret, code string, t time.Time, last int64) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+	for i := -Skew; i <= Skew; i++ {
+		at := t.Add(time.Duration(i) * Period)
+		step := Step(at)
+		if step <= last {
+			continue
+		}
+		want, err := Code(secret, at)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// Step returns the time step codes valid at time t belong to
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// RecoveryCodes generates a set of random one-time recovery codes
+func RecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		c := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = c[:4] + "-" + c[4:]
+	}
+	return codes, nil
+}
+
+func code(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
@@ -0,0 +1,170 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk/pkg/utl/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 shared secret from RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	cases := []struct {
+		name     string
+		secret   string
+		time     int64
+		wantData string
+		wantErr  bool
+	}{
+		{
+			name:    "Fail on invalid secret",
+			secret:  "not base32!",
+			wantErr: true,
+		},
+		{
+			name:     "RFC 6238 vector 59",
+			secret:   rfcSecret,
+			time:     59,
+			wantData: "287082",
+		},
+		{
+			name:     "RFC 6238 vector 1111111109",
+			secret:   rfcSecret,
+			time:     1111111109,
+			wantData: "081804",
+		},
+		{
+			name:     "RFC 6238 vector 2000000000",
+			secret:   rfcSecret,
+			time:     2000000000,
+			wantData: "279037",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := totp.Code(tt.secret, time.Unix(tt.time, 0))
+			assert.Equal(t, tt.wantData, code)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	cases := []struct {
+		name string
+		code string
+		time time.Time
+		want bool
+	}{
+		{
+			name: "Valid code",
+			code: "081804",
+			time: now,
+			want: true,
+		},
+		{
+			name: "Previous time step",
+			code: "081804",
+			time: now.Add(totp.Period),
+			want: true,
+		},
+		{
+			name: "Expired code",
+			code: "081804",
+			time: now.Add(3 * totp.Period),
+		},
+		{
+			name: "Invalid length",
+			code: "81804",
+			time: now,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, totp.Validate(rfcSecret, tt.code, tt.time))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := totp.Step(now)
+	cases := []struct {
+		name     string
+		code     string
+		time     time.Time
+		last     int64
+		wantStep int64
+		want     bool
+	}{
+		{
+			name:     "Valid code",
+			code:     "081804",
+			time:     now,
+			wantStep: step,
+			want:     true,
+		},
+		{
+			name:     "Previous time step",
+			code:     "081804",
+			time:     now.Add(totp.Period),
+			last:     step - 1,
+			wantStep: step,
+			want:     true,
+		},
+		{
+			name: "Replayed code",
+			code: "081804",
+			time: now,
+			last: step,
+		},
+		{
+			name: "Code older than the last accepted one",
+			code: "081804",
+			time: now.Add(totp.Period),
+			last: step + 1,
+		},
+		{
+			name: "Invalid code",
+			code: "000000",
+			time: now,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := totp.Match(rfcSecret, tt.code, tt.time, tt.last)
+			assert.Equal(t, tt.want, ok)
+			assert.Equal(t, tt.wantStep, step)
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := totp.NewSecret()
+	assert.Nil(t, err)
+	code, err := totp.Code(secret, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, totp.Validate(secret, code, time.Now()))
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("Gorsk", "johndoe", "SECRET")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Gorsk:johndoe?"))
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=Gorsk")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := totp.RecoveryCodes()
+	assert.Nil(t, err)
+	assert.Len(t, codes, 10)
+	assert.NotEqual(t, codes[0], codes[1])
+	assert.Len(t, codes[0], 9)
+}
@@ -35,6 +35,10 @@ func (z *Log) Log(ctx echo.Context, source, msg string, err error, params map[st
 		params["user"] = ctx.Get("username").(string)
 	}
 
+	if actorID, ok := ctx.Get("actor_id").(int); ok && actorID != 0 {
+		params["actor_id"] = actorID
+	}
+
 	if err != nil {
 		params["error"] = err
 		z.logger.Error().Fields(params).Msg(msg)
@@ -0,0 +1,36 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// Session represents user's login session on a single device
+type Session struct {
+	Base
+	UserID int `json:"-"`
+
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+
+	Token          string    `json:"-"`
+	Family         string    `json:"-"`
+	TokenExpiresAt time.Time `json:"-"`
+}
+
+// Rotate replaces session's refresh token with a new one from the same family
+func (s *Session) Rotate(token string, expiry time.Time) {
+	s.Token = token
+	s.TokenExpiresAt = expiry
+	s.LastUsedAt = time.Now()
+}
+
+// Expired checks whether the refresh token or the session itself has expired.
+// Zero expiry times are never considered expired.
+func (s *Session) Expired(now time.Time) bool {
+	return (!s.TokenExpiresAt.IsZero() && now.After(s.TokenExpiresAt)) ||
+		(!s.ExpiresAt.IsZero() && now.After(s.ExpiresAt))
+}
@@ -0,0 +1,59 @@
+package gorsk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ribice/gorsk"
+)
+
+func TestRotate(t *testing.T) {
+	s := &gorsk.Session{Token: "family.old"}
+	expiry := time.Now().Add(time.Hour)
+	s.Rotate("family.new", expiry)
+	if s.Token != "family.new" {
+		t.Errorf("Token was not rotated")
+	}
+	if s.TokenExpiresAt != expiry {
+		t.Errorf("Token expiry was not changed")
+	}
+	if s.LastUsedAt.IsZero() {
+		t.Errorf("Last used time was not changed")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name    string
+		session gorsk.Session
+		want    bool
+	}{
+		{
+			name: "No expiry",
+			want: false,
+		},
+		{
+			name:    "Token expired",
+			session: gorsk.Session{TokenExpiresAt: now.Add(-time.Minute)},
+			want:    true,
+		},
+		{
+			name:    "Session expired",
+			session: gorsk.Session{TokenExpiresAt: now.Add(time.Minute), ExpiresAt: now.Add(-time.Minute)},
+			want:    true,
+		},
+		{
+			name:    "Valid",
+			session: gorsk.Session{TokenExpiresAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)},
+			want:    false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.Expired(now); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
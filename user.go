@@ -13,6 +13,8 @@ type User struct {
 	Password  string `json:"-"`
 	Email     string `json:"email"`
 
+	EmailVerifiedAt time.Time `json:"email_verified_at,omitempty"`
+
 	Mobile  string `json:"mobile,omitempty"`
 	Phone   string `json:"phone,omitempty"`
 	Address string `json:"address,omitempty"`
@@ -21,8 +23,16 @@ type User struct {
 
 	LastLogin          time.Time `json:"last_login,omitempty"`
 	LastPasswordChange time.Time `json:"last_password_change,omitempty"`
+	MustChangePassword bool      `json:"must_change_password"`
+	PasswordHistory    []string  `json:"-" pg:",array"`
+
+	FailedLogins    int       `json:"-"`
+	LastFailedLogin time.Time `json:"-"`
 
-	Token string `json:"-"`
+	MFAEnabled       bool     `json:"mfa_enabled"`
+	MFASecret        string   `json:"-"`
+	MFARecoveryCodes []string `json:"-" pg:",array"`
+	MFALastStep      int64    `json:"-"`
 
 	Role *Role `json:"role,omitempty"`
 
@@ -39,16 +49,105 @@ type AuthUser struct {
 	Username   string
 	Email      string
 	Role       AccessRole
+	SessionID  int
+	TokenID    string
+
+	// TokenExpiresAt is the expiry of the jwt token the user authenticated with, zero for API keys
+	TokenExpiresAt time.Time
+
+	// ActorID is the ID of the super admin impersonating the user, zero otherwise
+	ActorID int
+
+	EmailVerified bool
 }
 
 // ChangePassword updates user's password related fields
 func (u *User) ChangePassword(hash string) {
 	u.Password = hash
 	u.LastPasswordChange = time.Now()
+	u.MustChangePassword = false
+}
+
+// RetirePassword moves user's current password hash to password history, keeping at most n most recent hashes
+func (u *User) RetirePassword(n int) {
+	if n <= 0 {
+		u.PasswordHistory = nil
+		return
+	}
+	history := append([]string{u.Password}, u.PasswordHistory...)
+	if u.Password == "" {
+		history = history[1:]
+	}
+	if len(history) > n {
+		history = history[:n]
+	}
+	u.PasswordHistory = history
 }
 
-// UpdateLastLogin updates last login field
-func (u *User) UpdateLastLogin(token string) {
-	u.Token = token
+// EmailVerified checks whether user's email address was verified
+func (u *User) EmailVerified() bool {
+	return !u.EmailVerifiedAt.IsZero()
+}
+
+// VerifyEmail sets user's email address, confirmed by the user at given time
+func (u *User) VerifyEmail(email string, at time.Time) {
+	u.Email = email
+	u.EmailVerifiedAt = at
+}
+
+// UpdateLastLogin updates last login field and clears failed login attempts
+func (u *User) UpdateLastLogin() {
 	u.LastLogin = time.Now()
+	u.Unlock()
+}
+
+// LoginFailed records a failed login attempt
+func (u *User) LoginFailed(failures int, at time.Time) {
+	u.FailedLogins = failures
+	u.LastFailedLogin = at
+}
+
+// Resource returns user as resource permissions are enforced on
+func (u *User) Resource() Resource {
+	return Resource{UserID: u.ID, CompanyID: u.CompanyID, LocationID: u.LocationID, RoleID: u.RoleID}
+}
+
+// Unlock clears failed login attempts, lifting any lockout
+func (u *User) Unlock() {
+	u.FailedLogins = 0
+	u.LastFailedLogin = time.Time{}
+}
+
+// EnrollMFA sets a new, not yet confirmed, two-factor authentication secret
+func (u *User) EnrollMFA(secret string) {
+	u.MFAEnabled = false
+	u.MFASecret = secret
+	u.MFARecoveryCodes = nil
+	u.MFALastStep = 0
+}
+
+// EnableMFA enables two-factor authentication with given hashed recovery codes
+func (u *User) EnableMFA(recoveryCodes []string) {
+	u.MFAEnabled = true
+	u.MFARecoveryCodes = recoveryCodes
+}
+
+// DisableMFA disables two-factor authentication, removing its secret and recovery codes
+func (u *User) DisableMFA() {
+	u.MFAEnabled = false
+	u.MFASecret = ""
+	u.MFARecoveryCodes = nil
+	u.MFALastStep = 0
+}
+
+// UseRecoveryCode consumes the recovery code with given hash.
+// It returns false if user has no such recovery code.
+func (u *User) UseRecoveryCode(hash string) bool {
+	for i, h := range u.MFARecoveryCodes {
+		if h == hash {
+			u.MFARecoveryCodes = append(u.MFARecoveryCodes[:i:i], u.MFARecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
@@ -1,7 +1,9 @@
 package gorsk_test
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ribice/gorsk"
 )
@@ -24,20 +26,81 @@ func TestChangePassword(t *testing.T) {
 	}
 }
 
+func TestRetirePassword(t *testing.T) {
+	user := &gorsk.User{Password: "c", PasswordHistory: []string{"b", "a"}, MustChangePassword: true}
+	user.RetirePassword(2)
+	user.ChangePassword("d")
+	if !reflect.DeepEqual(user.PasswordHistory, []string{"c", "b"}) {
+		t.Errorf("Password history was not updated, received %v", user.PasswordHistory)
+	}
+	if user.MustChangePassword {
+		t.Errorf("Password change requirement was not cleared")
+	}
+	user.RetirePassword(0)
+	if user.PasswordHistory != nil {
+		t.Errorf("Password history was not cleared")
+	}
+}
+
 func TestUpdateLastLogin(t *testing.T) {
 	user := &gorsk.User{
 		FirstName: "TestGuy",
 	}
-
-	token := "helloWorld"
-
-	user.UpdateLastLogin(token)
+	user.UpdateLastLogin()
 	if user.LastLogin.IsZero() {
 		t.Errorf("Last login time was not changed")
 	}
+}
 
-	if user.Token != token {
-		t.Errorf("Token was not changed")
+func TestLoginFailed(t *testing.T) {
+	user := &gorsk.User{
+		FirstName: "TestGuy",
+	}
+	now := time.Now()
+	user.LoginFailed(3, now)
+	if user.FailedLogins != 3 || !user.LastFailedLogin.Equal(now) {
+		t.Errorf("Failed login was not recorded")
+	}
+	user.UpdateLastLogin()
+	if user.FailedLogins != 0 || !user.LastFailedLogin.IsZero() {
+		t.Errorf("Failed logins were not cleared")
+	}
+}
 
+func TestVerifyEmail(t *testing.T) {
+	user := &gorsk.User{
+		Email: "johndoe@mail.com",
+	}
+	if user.EmailVerified() {
+		t.Errorf("Email should not be verified")
+	}
+	user.VerifyEmail("john@mail.com", time.Now())
+	if !user.EmailVerified() || user.Email != "john@mail.com" {
+		t.Errorf("Email was not verified")
+	}
+}
+
+func TestMFA(t *testing.T) {
+	user := &gorsk.User{
+		FirstName: "TestGuy",
+	}
+	user.EnrollMFA("secret")
+	if user.MFAEnabled || user.MFASecret != "secret" {
+		t.Errorf("MFA secret was not enrolled")
+	}
+	user.EnableMFA([]string{"a", "b", "c"})
+	if !user.MFAEnabled {
+		t.Errorf("MFA was not enabled")
+	}
+	if !user.UseRecoveryCode("b") || user.UseRecoveryCode("b") {
+		t.Errorf("Recovery code was not consumed")
+	}
+	if len(user.MFARecoveryCodes) != 2 {
+		t.Errorf("Expected 2 recovery codes, got %v", len(user.MFARecoveryCodes))
+	}
+	user.MFALastStep = 1
+	user.DisableMFA()
+	if user.MFAEnabled || user.MFASecret != "" || user.MFARecoveryCodes != nil || user.MFALastStep != 0 {
+		t.Errorf("MFA was not disabled")
 	}
 }
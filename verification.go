@@ -0,0 +1,22 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// EmailVerification represents one-time token confirming user's email address.
+// Email differs from user's current email when the user is changing it. Only the token's hash is stored.
+// Activate marks tokens sent to signed up users, whose accounts become active once verified.
+type EmailVerification struct {
+	Base
+	UserID    int       `json:"-"`
+	Email     string    `json:"email"`
+	Hash      string    `json:"-"`
+	Activate  bool      `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired checks whether the verification token has expired
+func (v *EmailVerification) Expired(now time.Time) bool {
+	return now.After(v.ExpiresAt)
+}